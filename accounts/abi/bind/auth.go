@@ -17,12 +17,16 @@
 package bind
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"time"
 
 	"github.com/KasperLiu/gobcos/accounts"
+	"github.com/KasperLiu/gobcos/accounts/external"
 	"github.com/KasperLiu/gobcos/accounts/keystore"
 	"github.com/KasperLiu/gobcos/core/types"
 	"github.com/KasperLiu/gobcos/crypto"
@@ -93,3 +97,43 @@ func NewKeyedTransactor(key *ecdsa.PrivateKey) *TransactOpts {
 // 		},
 // 	}
 // }
+
+// DefaultRemoteSignTimeout is how long a remote signer is waited for when no
+// timeout is given to NewRemoteTransactor.
+const DefaultRemoteSignTimeout = 30 * time.Second
+
+// NewRemoteTransactor is a utility method to easily create a transaction signer
+// whose key of address is held by a remote signer, see the external package for
+// the protocol. Every signature request is aborted after timeout, or after
+// DefaultRemoteSignTimeout if timeout is 0. The remote signer may be shared by
+// several transactors and is closed by the caller once they are not used anymore.
+func NewRemoteTransactor(remote *external.ExternalSigner, address common.Address, timeout time.Duration) *TransactOpts {
+	if timeout <= 0 {
+		timeout = DefaultRemoteSignTimeout
+	}
+	return &TransactOpts{
+		From: address,
+		Signer: func(signer types.RawSigner, from common.Address, tx *types.RawTransaction) (*types.RawTransaction, error) {
+			if from != address {
+				return nil, errors.New("not authorized to sign this account")
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			signature, err := remote.SignHash(ctx, address, signer.Hash(tx).Bytes())
+			if err != nil {
+				return nil, err
+			}
+			signedTx, err := tx.WithSignature(signer, signature)
+			if err != nil {
+				return nil, err
+			}
+			// make sure the remote signer used the key of the account
+			if sender, err := types.RawSender(signer, signedTx); err != nil {
+				return nil, err
+			} else if sender != address {
+				return nil, fmt.Errorf("remote signer signed with %s instead of %s", sender.Hex(), address.Hex())
+			}
+			return signedTx, nil
+		},
+	}
+}
@@ -19,6 +19,7 @@ package bind
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/KasperLiu/gobcos/core/types"
@@ -38,8 +39,21 @@ var (
 	// This error is returned by WaitDeployed if contract creation leaves an
 	// empty contract behind.
 	ErrNoCodeAfterDeploy = errors.New("no contract code after deployment")
+
+	// This error is returned when waiting for a transaction through a backend
+	// that doesn't implement DeployBackend.
+	ErrNoReceipts = errors.New("backend does not support transaction receipts")
 )
 
+// TransactionError is returned when a transaction is mined but its execution failed.
+type TransactionError struct {
+	Receipt *types.Receipt // Receipt of the failed transaction
+}
+
+func (e *TransactionError) Error() string {
+	return fmt.Sprintf("transaction %s failed: %s", e.Receipt.GetTransactionHash(), common.GetStatusMessage(e.Receipt.GetStatus()))
+}
+
 // ContractCaller defines the methods needed to allow operating with contract on a read
 // only basis.
 type ContractCaller interface {
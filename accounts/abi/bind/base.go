@@ -28,6 +28,7 @@ import (
 	"github.com/KasperLiu/gobcos/core/types"
 	"github.com/KasperLiu/gobcos/common"
 	"github.com/KasperLiu/gobcos/event"
+	"github.com/KasperLiu/gobcos/log"
 )
 
 // SignerFn is a signer function callback when a contract requires a method to
@@ -46,16 +47,37 @@ type CallOpts struct {
 // valid Ethereum transaction.
 type TransactOpts struct {
 	From   common.Address // Ethereum account to send the transaction from
-	Nonce  *big.Int       // Nonce to use for the transaction execution (nil = use pending state)
+	Nonce  *big.Int       // Nonce to use for the transaction execution (nil = random nonce)
 	Signer SignerFn       // Method to use for signing the transaction (mandatory)
 
 	Value    *big.Int // Funds to transfer along along the transaction (nil = 0 = no funds)
 	GasPrice *big.Int // Gas price to use for the transaction execution (nil = gas price oracle)
 	GasLimit *big.Int   // Gas limit to set for the transaction execution (0 = estimate)
 
+	BlockLimit *big.Int // Block limit of the transaction (nil = current block number + 500)
+	ChainID    *big.Int // Chain ID of the transaction (nil = chain ID of the node)
+	GroupID    *big.Int // Group ID of the transaction (nil = group ID of the backend)
+
 	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
 }
 
+// PrecompileGasLimit is the gas limit of the transactions to the precompiled
+// contracts, which have no code to estimate it with.
+const PrecompileGasLimit = 30000000
+
+// WithDefaultGasLimit returns a copy of opts whose gas limit is gasLimit if opts
+// has none, leaving opts unchanged. It fails if opts is nil.
+func WithDefaultGasLimit(opts *TransactOpts, gasLimit uint64) (*TransactOpts, error) {
+	if opts == nil {
+		return nil, errors.New("no transaction options to sign with")
+	}
+	copied := *opts
+	if copied.GasLimit == nil {
+		copied.GasLimit = new(big.Int).SetUint64(gasLimit)
+	}
+	return &copied, nil
+}
+
 // FilterOpts is the collection of options to fine tune filtering for events
 // within a bound contract.
 type FilterOpts struct {
@@ -133,18 +155,26 @@ func DeployContract(opts *TransactOpts, abi abi.ABI, bytecode []byte, backend Co
 // returns, a slice of interfaces for anonymous returns and a struct for named
 // returns.
 func (c *BoundContract) Call(opts *CallOpts, result interface{}, method string, params ...interface{}) error {
-	// Don't crash on a lazy user
-	if opts == nil {
-		opts = new(CallOpts)
-	}
-
-	
 	// Pack the input, call and unpack the results
 	input, err := c.abi.Pack(method, params...)
 	if err != nil {
 		return err
 	}
+	output, err := c.call(opts, input)
+	if err != nil {
+		return err
+	}
+	return c.abi.Unpack(result, method, output)
+}
+
+// call invokes the contract with the packed input and returns the raw output.
+func (c *BoundContract) call(opts *CallOpts, input []byte) ([]byte, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(CallOpts)
+	}
 	var (
+		err    error
 		msg    = common.CallMsg{From: opts.From, To: &c.address, Data: input}
 		ctx    = ensureContext(opts.Context)
 		code   []byte
@@ -154,15 +184,15 @@ func (c *BoundContract) Call(opts *CallOpts, result interface{}, method string,
 	if opts.Pending {
 		pb, ok := c.caller.(PendingContractCaller)
 		if !ok {
-			return ErrNoPendingState
+			return nil, ErrNoPendingState
 		}
 		output, err = pb.PendingCallContract(ctx, msg)
 		if err == nil && len(output) == 0 {
 			// Make sure we have a contract to operate on, and bail out otherwise.
 			if code, err = pb.PendingCodeAt(ctx, c.address); err != nil {
-				return err
+				return nil, err
 			} else if len(code) == 0 {
-				return ErrNoCode
+				return nil, ErrNoCode
 			}
 		}
 	} else {
@@ -170,16 +200,13 @@ func (c *BoundContract) Call(opts *CallOpts, result interface{}, method string,
 		if err == nil && len(output) == 0 {
 			// Make sure we have a contract to operate on, and bail out otherwise.
 			if code, err = c.caller.CodeAt(ctx, c.address, opts.BlockNumber); err != nil {
-				return err
+				return nil, err
 			} else if len(code) == 0 {
-				return ErrNoCode
+				return nil, ErrNoCode
 			}
 		}
 	}
-	if err != nil {
-		return err
-	}
-	return c.abi.Unpack(result, method, output)
+	return output, err
 }
 
 // Transact invokes the (paid) contract method with params as input values.
@@ -192,15 +219,117 @@ func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...in
 	return c.transact(opts, &c.address, input)
 }
 
+// TransactAndWait invokes the (paid) contract method with params as input values,
+// waits for the transaction to be mined and sets the output of the method carried
+// by the receipt to result, unless result is nil. If the transaction was not executed
+// successfully, the receipt is returned with a *TransactionError.
+func (c *BoundContract) TransactAndWait(ctx context.Context, opts *TransactOpts, result interface{}, method string, params ...interface{}) (*types.Receipt, error) {
+	tx, err := c.Transact(opts, method, params...)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := c.WaitMined(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		if receipt.GetStatus() != common.Success {
+			return receipt, &TransactionError{Receipt: receipt}
+		}
+		return receipt, nil
+	}
+	return receipt, c.UnpackReceiptOutput(result, method, receipt)
+}
+
+// UnpackReceiptOutput sets the return values of method, carried by the Output of
+// a FISCO BCOS receipt, to result. The result type might be a single field for
+// simple returns, a slice of interfaces for anonymous returns and a struct for
+// named returns. A receipt of a failed transaction is reported as a *TransactionError.
+func (c *BoundContract) UnpackReceiptOutput(result interface{}, method string, receipt *types.Receipt) error {
+	if receipt.GetStatus() != common.Success {
+		return &TransactionError{Receipt: receipt}
+	}
+	return c.abi.Unpack(result, method, common.FromHex(receipt.GetOutput()))
+}
+
+// WaitMined waits for tx to be mined, querying the receipt through the transactor
+// of the contract. It stops waiting when the context is canceled.
+func (c *BoundContract) WaitMined(ctx context.Context, tx *types.RawTransaction) (*types.Receipt, error) {
+	backend, ok := c.transactor.(DeployBackend)
+	if !ok {
+		return nil, ErrNoReceipts
+	}
+	return WaitMined(ctx, backend, tx)
+}
+
+// ReceiptLogs returns the logs of receipt that were emitted by the contract.
+func (c *BoundContract) ReceiptLogs(receipt *types.Receipt) ([]types.Log, error) {
+	var logs []types.Log
+	for _, l := range receipt.Logs {
+		log, err := l.ToLog()
+		if err != nil {
+			return nil, err
+		}
+		if log.Address == c.address {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
 // Transfer initiates a plain transaction to move funds to the contract, calling
 // its default method if one is available.
 func (c *BoundContract) Transfer(opts *TransactOpts) (*types.RawTransaction, error) {
 	return c.transact(opts, &c.address, nil)
 }
 
+// BuildTransaction packs the parameters of a contract method invocation into an
+// unsigned transaction. The blockLimit, chain ID and group ID are taken from opts
+// when set, otherwise they are requested from the backend. The result can be
+// serialized to JSON, signed elsewhere with Sign and broadcast with SendTransaction.
+func (c *BoundContract) BuildTransaction(opts *TransactOpts, method string, params ...interface{}) (*types.RawTransaction, error) {
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildTransaction(opts, &c.address, input)
+}
+
+// Sign signs an unsigned transaction with the signer method of opts.
+func Sign(opts *TransactOpts, tx *types.RawTransaction) (*types.RawTransaction, error) {
+	if opts.Signer == nil {
+		return nil, errors.New("no signer to authorize the transaction with")
+	}
+	return opts.Signer(types.HomesteadRawSigner{}, opts.From, tx)
+}
+
 // transact executes an actual transaction invocation, first deriving any missing 
 // authorization fields, and then scheduling the transaction for execution.
 func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, input []byte) (*types.RawTransaction, error) {
+	rawTx, err := c.buildTransaction(opts, contract, input)
+	if err != nil {
+		log.Warn("Failed to build the transaction", "from", opts.From, "to", contract, "err", err)
+		return nil, err
+	}
+	log.Debug("Transaction built", "from", opts.From, "to", rawTx.To(), "nonce", rawTx.Nonce(), "blockLimit", rawTx.BlockLimit(), "size", len(input))
+	signedTx, err := Sign(opts, rawTx)
+	if err != nil {
+		log.Warn("Failed to sign the transaction", "from", opts.From, "err", err)
+		return nil, err
+	}
+	logger := log.New("hash", signedTx.Hash())
+	logger.Debug("Transaction signed", "from", opts.From)
+	if err := c.transactor.SendTransaction(ensureContext(opts.Context), signedTx); err != nil {
+		logger.Warn("Failed to send the transaction", "err", err)
+		return nil, err
+	}
+	logger.Info("Transaction sent", "from", opts.From, "to", signedTx.To())
+	return signedTx, nil
+}
+
+// buildTransaction derives any missing authorization fields and creates the
+// unsigned transaction.
+func (c *BoundContract) buildTransaction(opts *TransactOpts, contract *common.Address, input []byte) (*types.RawTransaction, error) {
 	var err error
 
 	// Ensure a valid value field and resolve the account nonce
@@ -208,14 +337,17 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 	if value == nil {
 		value = new(big.Int)
 	}
-    // generate random Nonce between 0 - 2^250 - 1
-    max := new(big.Int)
-	max.Exp(big.NewInt(2), big.NewInt(250), nil).Sub(max, big.NewInt(1))
-	//Generate cryptographically strong pseudo-random between 0 - max
-	nonce, err := rand.Int(rand.Reader, max)
-	if err != nil {
-		//error handling
-		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	nonce := opts.Nonce
+	if nonce == nil {
+		// generate random Nonce between 0 - 2^250 - 1
+		max := new(big.Int)
+		max.Exp(big.NewInt(2), big.NewInt(250), nil).Sub(max, big.NewInt(1))
+		//Generate cryptographically strong pseudo-random between 0 - max
+		nonce, err = rand.Int(rand.Reader, max)
+		if err != nil {
+			//error handling
+			return nil, fmt.Errorf("failed to generate nonce: %v", err)
+		}
 	}
 
 	// Figure out the gas allowance and gas price values
@@ -239,44 +371,37 @@ func (c *BoundContract) transact(opts *TransactOpts, contract *common.Address, i
 		gasLimit = big.NewInt(30000000)
 	}
 
-    var blockLimit *big.Int
-	blockLimit, err = c.transactor.GetBlockLimit(ensureContext(opts.Context))
-	if err != nil {
-		 return nil, err
+	blockLimit := opts.BlockLimit
+	if blockLimit == nil {
+		blockLimit, err = c.transactor.GetBlockLimit(ensureContext(opts.Context))
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	var chainID *big.Int
-	chainID, err = c.transactor.GetChainID(ensureContext(opts.Context))
-	if err != nil {
-		return nil, err
+	chainID := opts.ChainID
+	if chainID == nil {
+		chainID, err = c.transactor.GetChainID(ensureContext(opts.Context))
+		if err != nil {
+			return nil, err
+		}
 	}
-	
-    var groupID *big.Int
-	groupID = c.transactor.GetGroupID()
+
+	groupID := opts.GroupID
 	if groupID == nil {
-		return nil, fmt.Errorf("failed to get the group ID")
+		groupID = c.transactor.GetGroupID()
+		if groupID == nil {
+			return nil, fmt.Errorf("failed to get the group ID")
+		}
 	}
 
-	// Create the transaction, sign it and schedule it for execution
-	var rawTx *types.RawTransaction
+	// Create the unsigned transaction
 	str := ""
 	extraData := []byte(str)
 	if contract == nil {
-		rawTx = types.NewRawContractCreation(nonce, value, gasLimit, gasPrice, blockLimit, input, chainID, groupID, extraData)
-	} else {
-		rawTx = types.NewRawTransaction(nonce, c.address, value, gasLimit, gasPrice, blockLimit, input, chainID, groupID, extraData)
-	}
-	if opts.Signer == nil {
-		return nil, errors.New("no signer to authorize the transaction with")
+		return types.NewRawContractCreation(nonce, value, gasLimit, gasPrice, blockLimit, input, chainID, groupID, extraData), nil
 	}
-	signedTx, err := opts.Signer(types.HomesteadRawSigner{}, opts.From, rawTx)
-	if err != nil {
-		return nil, err
-	}
-	if err := c.transactor.SendTransaction(ensureContext(opts.Context), signedTx); err != nil {
-		return nil, err
-	}
-	return signedTx, nil
+	return types.NewRawTransaction(nonce, c.address, value, gasLimit, gasPrice, blockLimit, input, chainID, groupID, extraData), nil
 }
 
 // FilterLogs filters contract logs for past blocks, returning the necessary
@@ -375,6 +500,22 @@ func (c *BoundContract) UnpackLog(out interface{}, event string, log types.Log)
 	return parseTopics(out, indexed, log.Topics[1:])
 }
 
+// UnpackNewLog unpacks a log carried by a FISCO BCOS receipt into the provided
+// output structure and returns it decoded. The log has to be emitted by event.
+func (c *BoundContract) UnpackNewLog(out interface{}, event string, log *types.NewLog) (types.Log, error) {
+	decoded, err := log.ToLog()
+	if err != nil {
+		return types.Log{}, err
+	}
+	if len(decoded.Topics) == 0 || decoded.Topics[0] != c.abi.Events[event].Id() {
+		return types.Log{}, fmt.Errorf("log is not a %s event", event)
+	}
+	if err := c.UnpackLog(out, event, decoded); err != nil {
+		return types.Log{}, err
+	}
+	return decoded, nil
+}
+
 // UnpackLogIntoMap unpacks a retrieved log into the provided map.
 func (c *BoundContract) UnpackLogIntoMap(out map[string]interface{}, event string, log types.Log) error {
 	if len(log.Data) > 0 {
@@ -0,0 +1,186 @@
+package bind_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/crypto"
+)
+
+const storeABI = `[
+	{"constant":false,"inputs":[{"name":"value","type":"uint256"}],"name":"set","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"},
+	{"constant":true,"inputs":[],"name":"get","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"}
+]`
+
+var storeBin = common.FromHex("0x6080604052")
+
+func newTestBackend(t *testing.T) (*clienttest.Server, *client.Client, *bind.TransactOpts) {
+	srv := clienttest.NewServer()
+	backend, err := client.Dial(srv.URL, 1)
+	if err != nil {
+		srv.Close()
+		t.Fatalf("init rpc client failed: %v", err)
+	}
+	key, err := crypto.HexToECDSA("145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b58")
+	if err != nil {
+		t.Fatalf("init privateKey failed: %v", err)
+	}
+	return srv, backend, bind.NewKeyedTransactor(key)
+}
+
+func TestDeployAndTransact(t *testing.T) {
+	srv, backend, auth := newTestBackend(t)
+	defer srv.Close()
+
+	parsed, err := abi.JSON(strings.NewReader(storeABI))
+	if err != nil {
+		t.Fatalf("parse ABI failed: %v", err)
+	}
+	// the mock contract stores the value of the last set call
+	stored := big.NewInt(0)
+	srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+		if from != auth.From {
+			t.Errorf("transaction sent from %s, want %s", from.Hex(), auth.From.Hex())
+		}
+		if tx.To() != nil {
+			if err := parsed.Methods["set"].Inputs.Unpack(&stored, tx.Data()[4:]); err != nil {
+				return &clienttest.Execution{Status: "0x1a"}
+			}
+		}
+		return nil
+	})
+	srv.HandleCall(func(from, to common.Address, data []byte) ([]byte, error) {
+		return parsed.Methods["get"].Outputs.Pack(stored)
+	})
+
+	address, tx, contract, err := bind.DeployContract(auth, parsed, storeBin, backend)
+	if err != nil {
+		t.Fatalf("deploy contract failed: %v", err)
+	}
+	deployed, err := bind.WaitDeployed(context.Background(), backend, tx)
+	if err != nil {
+		t.Fatalf("wait for the deployment failed: %v", err)
+	}
+	if deployed != address {
+		t.Fatalf("contract address mismatch: have %s, want %s", deployed.Hex(), address.Hex())
+	}
+
+	tx, err = contract.Transact(auth, "set", big.NewInt(42))
+	if err != nil {
+		t.Fatalf("transact failed: %v", err)
+	}
+	receipt, err := bind.WaitMined(context.Background(), backend, tx)
+	if err != nil {
+		t.Fatalf("wait for the transaction failed: %v", err)
+	}
+	if receipt.GetStatus() != "0x0" || receipt.GetContractAddress() != (common.Address{}) {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+
+	var value *big.Int
+	if err := contract.Call(&bind.CallOpts{From: auth.From}, &value, "get"); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if value.Cmp(big.NewInt(42)) != 0 {
+		t.Fatalf("stored value mismatch: have %v, want 42", value)
+	}
+
+	// the transaction must carry the FISCO BCOS chain and group ID
+	sent := srv.Transactions()
+	if len(sent) != 2 || sent[1].GroupId().Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("unexpected transactions sent to the node: %d", len(sent))
+	}
+}
+
+func TestBuildSignAndSend(t *testing.T) {
+	srv, backend, auth := newTestBackend(t)
+	defer srv.Close()
+
+	parsed, err := abi.JSON(strings.NewReader(storeABI))
+	if err != nil {
+		t.Fatalf("parse ABI failed: %v", err)
+	}
+	address := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	srv.SetCode(address, storeBin)
+	contract := bind.NewBoundContract(address, parsed, backend, backend, backend)
+
+	// build the unsigned transaction with a connected node
+	unsigned, err := contract.BuildTransaction(&bind.TransactOpts{From: auth.From}, "set", big.NewInt(42))
+	if err != nil {
+		t.Fatalf("build transaction failed: %v", err)
+	}
+	if v, r, s := unsigned.RawSignatureValues(); v.Sign() != 0 || r.Sign() != 0 || s.Sign() != 0 {
+		t.Fatalf("built transaction is signed")
+	}
+	if unsigned.BlockLimit().Cmp(big.NewInt(500)) != 0 || unsigned.FiscoChainId().Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("unexpected blockLimit %v or chain ID %v", unsigned.BlockLimit(), unsigned.FiscoChainId())
+	}
+	data, err := unsigned.MarshalJSON()
+	if err != nil {
+		t.Fatalf("marshal transaction failed: %v", err)
+	}
+
+	// sign it on a machine without access to the node
+	var offline types.RawTransaction
+	if err := offline.UnmarshalJSON(data); err != nil {
+		t.Fatalf("unmarshal transaction failed: %v", err)
+	}
+	if _, err := bind.Sign(&bind.TransactOpts{From: auth.From}, &offline); err == nil {
+		t.Fatalf("signing without a signer succeeded")
+	}
+	signed, err := bind.Sign(auth, &offline)
+	if err != nil {
+		t.Fatalf("sign transaction failed: %v", err)
+	}
+
+	if err := backend.SendTransaction(context.Background(), signed); err != nil {
+		t.Fatalf("send transaction failed: %v", err)
+	}
+	receipt, err := bind.WaitMined(context.Background(), backend, signed)
+	if err != nil {
+		t.Fatalf("wait for the transaction failed: %v", err)
+	}
+	if receipt.GetStatus() != "0x0" {
+		t.Fatalf("unexpected receipt status %s", receipt.GetStatus())
+	}
+
+	// fields set in the options are used without asking the node
+	srv.ClearRequests()
+	opts := &bind.TransactOpts{From: auth.From, Nonce: big.NewInt(1), GasLimit: big.NewInt(3000000), BlockLimit: big.NewInt(900), ChainID: big.NewInt(3), GroupID: big.NewInt(4)}
+	tx, err := contract.BuildTransaction(opts, "set", big.NewInt(1))
+	if err != nil {
+		t.Fatalf("build transaction failed: %v", err)
+	}
+	if tx.Nonce().Cmp(opts.Nonce) != 0 || tx.BlockLimit().Cmp(opts.BlockLimit) != 0 || tx.FiscoChainId().Cmp(opts.ChainID) != 0 || tx.GroupId().Cmp(opts.GroupID) != 0 {
+		t.Fatalf("transaction options ignored")
+	}
+	if reqs := srv.Requests(); len(reqs) != 0 {
+		t.Fatalf("unexpected requests to the node: %v", reqs)
+	}
+}
+
+func TestWithDefaultGasLimit(t *testing.T) {
+	if _, err := bind.WithDefaultGasLimit(nil, bind.PrecompileGasLimit); err == nil {
+		t.Fatal("nil options accepted")
+	}
+	auth := &bind.TransactOpts{}
+	opts, err := bind.WithDefaultGasLimit(auth, bind.PrecompileGasLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts == auth || auth.GasLimit != nil || opts.GasLimit.Uint64() != bind.PrecompileGasLimit {
+		t.Fatalf("default gas limit not set on a copy: %v, caller's %v", opts.GasLimit, auth.GasLimit)
+	}
+	auth.GasLimit = big.NewInt(1000)
+	if opts, _ = bind.WithDefaultGasLimit(auth, bind.PrecompileGasLimit); opts.GasLimit.Int64() != 1000 {
+		t.Fatalf("gas limit of the caller replaced by %v", opts.GasLimit)
+	}
+}
@@ -0,0 +1,559 @@
+package bind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/log"
+	"github.com/KasperLiu/gobcos/rpc"
+)
+
+// Default settings of a BatchSender.
+const (
+	DefaultBatchWorkers      = 8
+	DefaultBatchRetries      = 3
+	DefaultBatchPollInterval = 500 * time.Millisecond
+)
+
+// BatchBackend defines the methods needed by a BatchSender to send transactions
+// and follow their execution.
+type BatchBackend interface {
+	ContractTransactor
+	// PendingTxSize returns the number of transactions in the txpool.
+	PendingTxSize(ctx context.Context) (uint64, error)
+	// TxCountLimit returns the maximum number of transactions in a block.
+	TxCountLimit(ctx context.Context) (uint64, error)
+	// BlockNumber returns the current block height.
+	BlockNumber(ctx context.Context) (uint64, error)
+	// GetTransactionReceipts returns the receipts of several transactions at once,
+	// in order, along with the error of each one, common.NotFound for the ones that
+	// are not mined yet. The final error is set if not all receipts were requested.
+	GetTransactionReceipts(ctx context.Context, txHashes []common.Hash) ([]*types.Receipt, []error, error)
+}
+
+// BatchRequest is a contract method invocation sent by a BatchSender.
+type BatchRequest struct {
+	Contract *BoundContract
+	Method   string
+	Params   []interface{}
+}
+
+// BatchResult is the outcome of a BatchRequest.
+type BatchResult struct {
+	Tx       *types.RawTransaction // Last transaction sent for the request (nil = never signed)
+	Receipt  *types.Receipt        // Receipt of Tx (nil = not mined)
+	Attempts int                   // Number of times the request was sent to the node
+	Err      error                 // Reason of the failure (nil = executed successfully)
+}
+
+// BatchStats summarizes the execution of a batch.
+type BatchStats struct {
+	Total     int           // Number of requests
+	Succeeded int           // Requests executed successfully
+	Failed    int           // Requests rejected, reverted or abandoned
+	Retries   int           // Transactions sent again after a rejection
+	Elapsed   time.Duration // Time from the first signature to the last receipt
+	TPS       float64       // Mined transactions per second
+}
+
+// BatchSender sends a large number of contract transactions concurrently. The
+// transactions are signed ahead by a pool of workers and sent as long as the number
+// of pending transactions stays below MaxPending. Receipts are collected with batch
+// requests, and transactions rejected because of an expired blockLimit or a full
+// txpool are signed again and resent, as well as the ones dropped by the node,
+// which have no receipt once the chain passed their blockLimit.
+type BatchSender struct {
+	backend BatchBackend
+	opts    *TransactOpts
+
+	Workers      int           // Goroutines signing and sending transactions (0 = DefaultBatchWorkers)
+	MaxPending   int           // Limit of the sent transactions waiting for a receipt (0 = 2 * tx_count_limit)
+	MaxRetries   int           // Times a rejected transaction is resent (0 = DefaultBatchRetries, < 0 = never)
+	PollInterval time.Duration // Interval between receipt queries (0 = DefaultBatchPollInterval)
+
+	// OnResult is called, if set, when the outcome of a request is final. It is
+	// called from the worker goroutines and must be safe for concurrent use.
+	OnResult func(index int, result *BatchResult)
+}
+
+// NewBatchSender creates a BatchSender that signs the transactions with opts.
+func NewBatchSender(backend BatchBackend, opts *TransactOpts) *BatchSender {
+	return &BatchSender{backend: backend, opts: opts}
+}
+
+// errTxDropped fails a request whose last transaction was accepted by the node but
+// never mined before its blockLimit, e.g. because it was evicted from the txpool.
+var errTxDropped = errors.New("transaction dropped by the node before its blockLimit")
+
+// batchJob is a request waiting to be sent.
+type batchJob struct {
+	index   int
+	tx      *types.RawTransaction // nil = sign with a fresh blockLimit before sending
+	backoff bool                  // wait for the txpool to drain before sending
+}
+
+// batchRun holds the state of a single Send call.
+type batchRun struct {
+	*BatchSender
+	ctx        context.Context
+	opts       TransactOpts
+	blockLimit atomic.Value // *big.Int of the first signatures, refreshed by track unless set by opts
+	limitBlock uint64       // block number at which blockLimit was last refreshed
+	reqs       []BatchRequest
+	results    []*BatchResult
+	finished   []bool
+	limit      int
+	retries    int
+	interval   time.Duration
+	slots      chan struct{}
+	retry      chan *batchJob
+	done       chan struct{}
+	remaining  int32
+	poolSize   int64
+	resent     int32
+	mu         sync.Mutex
+	inflight   map[common.Hash]int
+	finishOnce sync.Once
+}
+
+// Send signs and sends a transaction for every request and waits for all of them
+// to be final. The results are in the order of reqs. An error is returned if the
+// batch cannot be started or ctx is canceled; the requests that are not final yet
+// then fail with the error of ctx.
+func (s *BatchSender) Send(ctx context.Context, reqs []BatchRequest) ([]*BatchResult, *BatchStats, error) {
+	if s.opts.Signer == nil {
+		return nil, nil, errors.New("no signer to authorize the transaction with")
+	}
+	run, err := s.newRun(ctx, reqs)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := time.Now()
+	if len(reqs) > 0 {
+		run.start()
+	}
+	stats := &BatchStats{Total: len(reqs), Retries: int(atomic.LoadInt32(&run.resent)), Elapsed: time.Since(start)}
+	mined := 0
+	for _, res := range run.results {
+		if res.Err == nil {
+			stats.Succeeded++
+		} else {
+			stats.Failed++
+		}
+		if res.Receipt != nil {
+			mined++
+		}
+	}
+	if seconds := stats.Elapsed.Seconds(); seconds > 0 {
+		stats.TPS = float64(mined) / seconds
+	}
+	return run.results, stats, ctx.Err()
+}
+
+// newRun resolves the settings shared by all transactions of the batch, so that
+// the node is not queried for every signature.
+func (s *BatchSender) newRun(ctx context.Context, reqs []BatchRequest) (*batchRun, error) {
+	run := &batchRun{
+		BatchSender: s,
+		ctx:         ctx,
+		opts:        *s.opts,
+		reqs:        reqs,
+		results:     make([]*BatchResult, len(reqs)),
+		finished:    make([]bool, len(reqs)),
+		limit:       s.MaxPending,
+		retries:     s.MaxRetries,
+		interval:    s.PollInterval,
+		retry:       make(chan *batchJob, len(reqs)),
+		done:        make(chan struct{}),
+		remaining:   int32(len(reqs)),
+		inflight:    make(map[common.Hash]int),
+	}
+	for i := range run.results {
+		run.results[i] = new(BatchResult)
+	}
+	if run.retries == 0 {
+		run.retries = DefaultBatchRetries
+	}
+	if run.interval == 0 {
+		run.interval = DefaultBatchPollInterval
+	}
+	if run.limit == 0 {
+		txCountLimit, err := s.backend.TxCountLimit(ctx)
+		if err != nil {
+			return nil, err
+		}
+		run.limit = 2 * int(txCountLimit)
+	}
+	if run.limit <= 0 {
+		return nil, fmt.Errorf("invalid pending transaction limit %d", run.limit)
+	}
+	run.slots = make(chan struct{}, run.limit)
+
+	// every transaction of the batch gets its own random nonce
+	run.opts.Nonce = nil
+	var err error
+	if run.opts.ChainID == nil {
+		if run.opts.ChainID, err = s.backend.GetChainID(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if run.opts.GroupID == nil {
+		if run.opts.GroupID = s.backend.GetGroupID(); run.opts.GroupID == nil {
+			return nil, fmt.Errorf("failed to get the group ID")
+		}
+	}
+	if run.opts.BlockLimit == nil {
+		blockLimit, err := s.backend.GetBlockLimit(ctx)
+		if err != nil {
+			return nil, err
+		}
+		run.blockLimit.Store(blockLimit)
+	}
+	if run.opts.GasLimit == nil {
+		// check the code of every contract once instead of once per transaction
+		checked := make(map[common.Address]bool)
+		for _, req := range reqs {
+			if checked[req.Contract.address] {
+				continue
+			}
+			if code, err := s.backend.PendingCodeAt(ctx, req.Contract.address); err != nil {
+				return nil, err
+			} else if len(code) == 0 {
+				return nil, ErrNoCode
+			}
+			checked[req.Contract.address] = true
+		}
+		run.opts.GasLimit = big.NewInt(30000000)
+	}
+	return run, nil
+}
+
+func (r *batchRun) start() {
+	ctx, cancel := context.WithCancel(r.ctx)
+	defer cancel()
+	r.ctx = ctx
+
+	workers := r.Workers
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+	// the signers run ahead of the senders as far as the buffer allows
+	signed := make(chan *batchJob, r.limit)
+	indexes := make(chan int)
+	var signers, senders sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		signers.Add(1)
+		go func() {
+			defer signers.Done()
+			for index := range indexes {
+				job := &batchJob{index: index}
+				if err := r.sign(job, false); err != nil {
+					r.finish(index, err)
+					continue
+				}
+				select {
+				case signed <- job:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			r.sendLoop(signed)
+		}()
+	}
+	go func() {
+		defer close(indexes)
+		for i := range r.reqs {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var tracker sync.WaitGroup
+	tracker.Add(1)
+	go func() {
+		defer tracker.Done()
+		r.track()
+	}()
+
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+	}
+	cancel()
+	signers.Wait()
+	senders.Wait()
+	tracker.Wait()
+	r.abandon(ctx.Err())
+}
+
+// sign builds the transaction of job and signs it. A fresh blockLimit is requested
+// from the node if refresh is set, otherwise the blockLimit of the batch is used,
+// which follows the chain unless it was set by the options of the sender.
+func (r *batchRun) sign(job *batchJob, refresh bool) error {
+	req := r.reqs[job.index]
+	opts := r.opts
+	if refresh {
+		blockLimit, err := r.backend.GetBlockLimit(r.ctx)
+		if err != nil {
+			return err
+		}
+		opts.BlockLimit = blockLimit
+	} else if opts.BlockLimit == nil {
+		opts.BlockLimit = r.blockLimit.Load().(*big.Int)
+	}
+	tx, err := req.Contract.BuildTransaction(&opts, req.Method, req.Params...)
+	if err != nil {
+		return err
+	}
+	if job.tx, err = Sign(&opts, tx); err != nil {
+		return err
+	}
+	r.results[job.index].Tx = job.tx
+	return nil
+}
+
+func (r *batchRun) sendLoop(signed <-chan *batchJob) {
+	for {
+		var job *batchJob
+		// the transactions waiting for a retry go first
+		select {
+		case job = <-r.retry:
+		default:
+			select {
+			case job = <-r.retry:
+			case job = <-signed:
+			case <-r.ctx.Done():
+				return
+			}
+		}
+		if job.backoff {
+			if !r.sleep() {
+				return
+			}
+			job.backoff = false
+		}
+		if job.tx == nil {
+			if err := r.sign(job, true); err != nil {
+				r.finish(job.index, err)
+				continue
+			}
+		}
+		if !r.acquire() {
+			return
+		}
+		r.send(job)
+	}
+}
+
+// acquire waits for a free slot in the pending window and for the txpool to have
+// room for the transaction.
+func (r *batchRun) acquire() bool {
+	select {
+	case r.slots <- struct{}{}:
+	case <-r.ctx.Done():
+		return false
+	}
+	for atomic.LoadInt64(&r.poolSize) >= int64(r.limit) {
+		if !r.sleep() {
+			<-r.slots
+			return false
+		}
+	}
+	return true
+}
+
+func (r *batchRun) release() {
+	<-r.slots
+}
+
+func (r *batchRun) sleep() bool {
+	select {
+	case <-time.After(r.interval):
+		return true
+	case <-r.ctx.Done():
+		return false
+	}
+}
+
+// send sends the transaction of job, which holds a slot of the pending window.
+func (r *batchRun) send(job *batchJob) {
+	res := r.results[job.index]
+	res.Attempts++
+	err := r.backend.SendTransaction(r.ctx, job.tx)
+	if err != nil {
+		if rpcErr, ok := err.(rpc.Error); ok {
+			switch rpcErr.ErrorCode() {
+			case common.RPCAlreadyInTxPool, common.RPCAlreadyInChain:
+				// an earlier attempt reached the node after all
+				err = nil
+			case common.RPCBlockLimitCheckFail:
+				job.tx = nil
+				r.release()
+				r.resend(job, err)
+				return
+			case common.RPCTxPoolIsFull:
+				job.backoff = true
+				r.release()
+				r.resend(job, err)
+				return
+			}
+		}
+	}
+	if err != nil {
+		log.Warn("Failed to send the transaction", "hash", job.tx.Hash(), "index", job.index, "err", err)
+		r.release()
+		r.finish(job.index, err)
+		return
+	}
+	log.Debug("Transaction sent", "hash", job.tx.Hash(), "index", job.index, "attempt", res.Attempts)
+	r.mu.Lock()
+	r.inflight[job.tx.Hash()] = job.index
+	r.mu.Unlock()
+}
+
+// resend schedules job for another attempt, or fails it with err when it ran out
+// of retries.
+func (r *batchRun) resend(job *batchJob, err error) {
+	if r.retries < 0 || r.results[job.index].Attempts > r.retries {
+		r.finish(job.index, err)
+		return
+	}
+	log.Debug("Resending the transaction", "index", job.index, "err", err)
+	atomic.AddInt32(&r.resent, 1)
+	r.retry <- job
+}
+
+// track polls the receipts of the sent transactions and the size of the txpool,
+// and refreshes the blockLimit of the batch when the chain moved on. A transaction
+// still without a receipt once the chain reached its blockLimit cannot be mined
+// anymore, so its slot is released and it is sent again.
+func (r *batchRun) track() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.ctx.Done():
+			return
+		}
+		if size, err := r.backend.PendingTxSize(r.ctx); err == nil {
+			atomic.StoreInt64(&r.poolSize, int64(size))
+		}
+		// the block number is read before the receipts, so that a transaction
+		// mined before its blockLimit has a receipt by then
+		number, numberErr := r.backend.BlockNumber(r.ctx)
+		if numberErr == nil && r.opts.BlockLimit == nil && number > r.limitBlock {
+			if blockLimit, err := r.backend.GetBlockLimit(r.ctx); err == nil {
+				r.blockLimit.Store(blockLimit)
+				r.limitBlock = number
+			}
+		}
+		var missing []common.Hash
+		r.mu.Lock()
+		hashes := make([]common.Hash, 0, len(r.inflight))
+		for hash := range r.inflight {
+			hashes = append(hashes, hash)
+		}
+		r.mu.Unlock()
+
+		if len(hashes) == 0 {
+			continue
+		}
+		// the receipts failing to be read are read again at the next tick
+		receipts, errs, _ := r.backend.GetTransactionReceipts(r.ctx, hashes)
+		for i, receipt := range receipts {
+			switch {
+			case receipt != nil:
+				r.mined(hashes[i], receipt)
+			case errs[i] == common.NotFound:
+				missing = append(missing, hashes[i])
+			}
+		}
+		if numberErr == nil {
+			for _, hash := range missing {
+				r.expire(hash, number)
+			}
+		}
+	}
+}
+
+// expire resends a sent transaction that has no receipt if its blockLimit is not
+// above the current block number.
+func (r *batchRun) expire(hash common.Hash, number uint64) {
+	r.mu.Lock()
+	index, ok := r.inflight[hash]
+	if ok && r.results[index].Tx.BlockLimit().Cmp(new(big.Int).SetUint64(number)) <= 0 {
+		delete(r.inflight, hash)
+	} else {
+		ok = false
+	}
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	log.Warn("Transaction dropped before its blockLimit", "hash", hash, "index", index, "block", number)
+	r.release()
+	r.resend(&batchJob{index: index}, errTxDropped)
+}
+
+// mined handles the receipt of a sent transaction.
+func (r *batchRun) mined(hash common.Hash, receipt *types.Receipt) {
+	r.mu.Lock()
+	index, ok := r.inflight[hash]
+	delete(r.inflight, hash)
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+	r.release()
+	r.results[index].Receipt = receipt
+	switch status := receipt.GetStatus(); status {
+	case common.Success:
+		log.Debug("Transaction mined", "hash", hash, "index", index, "block", receipt.GetBlockNumber())
+		r.finish(index, nil)
+	case common.BlockLimitCheckFail:
+		r.resend(&batchJob{index: index}, fmt.Errorf("transaction failed: %s", common.GetStatusMessage(status)))
+	default:
+		log.Warn("Transaction failed", "hash", hash, "index", index, "block", receipt.GetBlockNumber(), "status", common.GetStatusMessage(status))
+		r.finish(index, fmt.Errorf("transaction failed: %s", common.GetStatusMessage(status)))
+	}
+}
+
+// finish records the final outcome of a request.
+func (r *batchRun) finish(index int, err error) {
+	res := r.results[index]
+	res.Err = err
+	r.finished[index] = true
+	if r.OnResult != nil {
+		r.OnResult(index, res)
+	}
+	if atomic.AddInt32(&r.remaining, -1) == 0 {
+		r.finishOnce.Do(func() { close(r.done) })
+	}
+}
+
+// abandon fails the requests that are not final when the batch is canceled. It
+// must be called once all workers stopped.
+func (r *batchRun) abandon(err error) {
+	if err == nil {
+		err = context.Canceled
+	}
+	for i, res := range r.results {
+		if !r.finished[i] {
+			res.Err = err
+		}
+	}
+}
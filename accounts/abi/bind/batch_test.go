@@ -0,0 +1,296 @@
+package bind_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
+)
+
+// sealPeriodically seals a block every interval until the returned function is called.
+func sealPeriodically(srv *clienttest.Server, interval time.Duration) func() {
+	srv.SetAutoSeal(false)
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-time.After(interval):
+				srv.Seal()
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		wg.Wait()
+	}
+}
+
+func TestBatchSender(t *testing.T) {
+	srv, backend, auth := newTestBackend(t)
+	defer srv.Close()
+
+	parsed, err := abi.JSON(strings.NewReader(storeABI))
+	if err != nil {
+		t.Fatalf("parse ABI failed: %v", err)
+	}
+	address := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	srv.SetCode(address, storeBin)
+	contract := bind.NewBoundContract(address, parsed, backend, backend, backend)
+
+	// set(13) reverts and the first set(7) expires in the txpool
+	var (
+		mu      sync.Mutex
+		expired bool
+	)
+	srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+		value := new(big.Int)
+		if err := parsed.Methods["set"].Inputs.Unpack(&value, tx.Data()[4:]); err != nil {
+			return &clienttest.Execution{Status: common.BadInstruction}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case value.Int64() == 13:
+			return &clienttest.Execution{Status: common.RevertInstruction}
+		case value.Int64() == 7 && !expired:
+			expired = true
+			return &clienttest.Execution{Status: common.BlockLimitCheckFail}
+		}
+		return nil
+	})
+
+	const count = 300
+	reqs := make([]bind.BatchRequest, count)
+	for i := range reqs {
+		reqs[i] = bind.BatchRequest{Contract: contract, Method: "set", Params: []interface{}{big.NewInt(int64(i))}}
+	}
+	for i := 0; i < 5; i++ {
+		srv.Seal()
+	}
+	srv.SetTxPoolLimit(20)
+	stop := sealPeriodically(srv, 10*time.Millisecond)
+	defer stop()
+
+	// the first transactions are signed with an expired blockLimit
+	opts := *auth
+	opts.BlockLimit = big.NewInt(3)
+	sender := bind.NewBatchSender(backend, &opts)
+	sender.Workers = 4
+	sender.MaxPending = 50
+	sender.MaxRetries = 1000
+	sender.PollInterval = 10 * time.Millisecond
+	backend.SetBatchSize(16)
+	var reported int
+	sender.OnResult = func(index int, result *bind.BatchResult) {
+		mu.Lock()
+		reported++
+		mu.Unlock()
+	}
+
+	results, stats, err := sender.Send(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("send batch failed: %v", err)
+	}
+	if reported != count || len(results) != count {
+		t.Fatalf("reported %d results, returned %d, want %d", reported, len(results), count)
+	}
+	for i, res := range results {
+		switch {
+		case i == 13:
+			if res.Err == nil || res.Receipt == nil || res.Receipt.GetStatus() != common.RevertInstruction {
+				t.Fatalf("reverted transaction not reported: %+v", res)
+			}
+		case res.Err != nil:
+			t.Fatalf("request %d failed after %d attempts: %v", i, res.Attempts, res.Err)
+		case res.Receipt == nil || res.Receipt.TransactionHash != res.Tx.Hash().Hex():
+			t.Fatalf("request %d has no receipt of its last transaction", i)
+		case i == 7 && res.Attempts < 2:
+			t.Fatalf("expired transaction sent %d times, want at least 2", res.Attempts)
+		}
+	}
+	if stats.Total != count || stats.Succeeded != count-1 || stats.Failed != 1 || stats.TPS <= 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	// every transaction signed with the expired blockLimit and every transaction
+	// rejected by the full txpool was sent again
+	if stats.Retries < sender.Workers+1 {
+		t.Fatalf("too few retries: %+v", stats)
+	}
+	if got := len(srv.RequestsFor("getTransactionReceipt")); got == 0 {
+		t.Fatalf("receipts not requested")
+	}
+}
+
+func TestBatchSenderCancel(t *testing.T) {
+	srv, backend, auth := newTestBackend(t)
+	defer srv.Close()
+
+	parsed, err := abi.JSON(strings.NewReader(storeABI))
+	if err != nil {
+		t.Fatalf("parse ABI failed: %v", err)
+	}
+	address := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	srv.SetCode(address, storeBin)
+	contract := bind.NewBoundContract(address, parsed, backend, backend, backend)
+	// nothing is ever sealed
+	srv.SetAutoSeal(false)
+
+	reqs := make([]bind.BatchRequest, 10)
+	for i := range reqs {
+		reqs[i] = bind.BatchRequest{Contract: contract, Method: "set", Params: []interface{}{big.NewInt(int64(i))}}
+	}
+	sender := bind.NewBatchSender(backend, auth)
+	sender.PollInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	results, stats, err := sender.Send(ctx, reqs)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, res := range results {
+		if res.Err != context.DeadlineExceeded || res.Attempts != 1 {
+			t.Fatalf("request %d: unexpected result %+v", i, res)
+		}
+	}
+	if stats.Failed != len(reqs) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestBatchSenderDropped(t *testing.T) {
+	srv, backend, auth := newTestBackend(t)
+	defer srv.Close()
+
+	parsed, err := abi.JSON(strings.NewReader(storeABI))
+	if err != nil {
+		t.Fatalf("parse ABI failed: %v", err)
+	}
+	address := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	srv.SetCode(address, storeBin)
+	contract := bind.NewBoundContract(address, parsed, backend, backend, backend)
+	srv.SetAutoSeal(false)
+	srv.Seal()
+	srv.Seal()
+
+	reqs := make([]bind.BatchRequest, 3)
+	for i := range reqs {
+		reqs[i] = bind.BatchRequest{Contract: contract, Method: "set", Params: []interface{}{big.NewInt(int64(i))}}
+	}
+	// the transactions are accepted, then dropped by the node before being sealed
+	opts := *auth
+	opts.BlockLimit = big.NewInt(5)
+	sender := bind.NewBatchSender(backend, &opts)
+	sender.MaxPending = len(reqs)
+	sender.PollInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for len(srv.Transactions()) < len(reqs) && ctx.Err() == nil {
+			time.Sleep(5 * time.Millisecond)
+		}
+		srv.DropPending()
+		for {
+			select {
+			case <-time.After(10 * time.Millisecond):
+				srv.Seal()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	defer wg.Wait()
+	defer cancel()
+	results, stats, err := sender.Send(ctx, reqs)
+	if err != nil {
+		t.Fatalf("send batch failed: %v", err)
+	}
+	for i, res := range results {
+		if res.Err != nil || res.Receipt == nil || res.Attempts != 2 {
+			t.Fatalf("request %d: unexpected result %+v", i, res)
+		}
+		if res.Tx.BlockLimit().Cmp(opts.BlockLimit) <= 0 {
+			t.Fatalf("request %d resent with the expired blockLimit %v", i, res.Tx.BlockLimit())
+		}
+	}
+	if stats.Succeeded != len(reqs) || stats.Retries != len(reqs) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestBatchSenderRefreshesBlockLimit(t *testing.T) {
+	srv, backend, auth := newTestBackend(t)
+	defer srv.Close()
+
+	parsed, err := abi.JSON(strings.NewReader(storeABI))
+	if err != nil {
+		t.Fatalf("parse ABI failed: %v", err)
+	}
+	address := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	srv.SetCode(address, storeBin)
+	contract := bind.NewBoundContract(address, parsed, backend, backend, backend)
+
+	// the chain grows by 10 blocks every 2ms, past the first blockLimit in 100ms
+	srv.SetAutoSeal(false)
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-time.After(2 * time.Millisecond):
+				for i := 0; i < 10; i++ {
+					srv.Seal()
+				}
+			case <-quit:
+				return
+			}
+		}
+	}()
+	defer wg.Wait()
+	defer close(quit)
+
+	const count = 40
+	reqs := make([]bind.BatchRequest, count)
+	for i := range reqs {
+		reqs[i] = bind.BatchRequest{Contract: contract, Method: "set", Params: []interface{}{big.NewInt(int64(i))}}
+	}
+	// a transaction signed with a stale blockLimit would fail without a retry
+	sender := bind.NewBatchSender(backend, auth)
+	sender.Workers = 1
+	sender.MaxPending = 2
+	sender.MaxRetries = -1
+	sender.PollInterval = 10 * time.Millisecond
+	results, stats, err := sender.Send(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("send batch failed: %v", err)
+	}
+	for i, res := range results {
+		if res.Err != nil || res.Attempts != 1 {
+			t.Fatalf("request %d: unexpected result %+v", i, res)
+		}
+	}
+	first, last := results[0].Tx.BlockLimit(), results[count-1].Tx.BlockLimit()
+	if new(big.Int).Sub(last, first).Cmp(big.NewInt(500)) <= 0 {
+		t.Fatalf("batch ended before the chain passed the first blockLimit %v, last blockLimit %v", first, last)
+	}
+	if stats.Succeeded != count || stats.Retries != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
@@ -40,6 +40,7 @@ const (
 	LangGo Lang = iota
 	LangJava
 	LangObjC
+	LangTypeScript
 )
 
 // Bind generates a Go wrapper around a contract ABI. This wrapper isn't meant
@@ -53,6 +54,12 @@ func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]
 	// Map used to flag each encountered library as such
 	isLib := make(map[string]struct{})
 
+	// Types bound in this run, libraries can only be deployed along if bound
+	bound := make(map[string]bool)
+	for _, kind := range types {
+		bound[kind] = true
+	}
+
 	for i := 0; i < len(types); i++ {
 		// Parse the actual ABI to generate the binding for
 		evmABI, err := abi.JSON(strings.NewReader(abis[i]))
@@ -132,10 +139,15 @@ func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]
 			events[original.Name] = &tmplEvent{Original: original, Normalized: normalized}
 		}
 
-		// There is no easy way to pass arbitrary java objects to the Go side.
+		// The Java SDK doesn't support tuples nor nested arrays.
 		if len(structs) > 0 && lang == LangJava {
 			return "", errors.New("java binding for tuple arguments is not supported yet")
 		}
+		if lang == LangJava {
+			if err := checkJavaTypes(evmABI); err != nil {
+				return "", err
+			}
+		}
 
 		contracts[types[i]] = &tmplContract{
 			Type:        capitalise(types[i]),
@@ -153,19 +165,17 @@ func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]
 		if len(fsigs) > i {
 			contracts[types[i]].FuncSigs = fsigs[i]
 		}
-		// Parse library references.
+		// Parse library references, the ones of libraries bound along are deployed
+		// automatically, the others have to be linked explicitly.
+		contracts[types[i]].Linked = strings.Contains(contracts[types[i]].InputBin, "__")
 		for pattern, name := range libs {
-			matched, err := regexp.Match("__\\$"+pattern+"\\$__", []byte(contracts[types[i]].InputBin))
-			if err != nil {
-				// log.Error("Could not search for pattern", "pattern", pattern, "contract", contracts[types[i]], "err", err)
-				fmt.Errorf("Could not search for pattern pattern: %+v, contract: %+v, err: %+v", pattern, contracts[types[i]], err)
+			if !strings.Contains(contracts[types[i]].InputBin, "__$"+pattern+"$__") || !bound[name] {
+				continue
 			}
-			if matched {
-				contracts[types[i]].Libraries[pattern] = name
-				// keep track that this type is a library
-				if _, ok := isLib[name]; !ok {
-					isLib[name] = struct{}{}
-				}
+			contracts[types[i]].Libraries[pattern] = name
+			// keep track that this type is a library
+			if _, ok := isLib[name]; !ok {
+				isLib[name] = struct{}{}
 			}
 		}
 	}
@@ -190,6 +200,10 @@ func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]
 		"formatevent":   formatEvent,
 		"capitalise":    capitalise,
 		"decapitalise":  decapitalise,
+		"resultname":    resultName,
+	}
+	for name, fn := range langFuncs[lang] {
+		funcs[name] = fn
 	}
 	tmpl := template.Must(template.New("").Funcs(funcs).Parse(tmplSource[lang]))
 	if err := tmpl.Execute(buffer, data); err != nil {
@@ -210,8 +224,9 @@ func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]
 // bindType is a set of type binders that convert Solidity types to some supported
 // programming language types.
 var bindType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
-	LangGo:   bindTypeGo,
-	LangJava: bindTypeJava,
+	LangGo:         bindTypeGo,
+	LangJava:       bindTypeJava,
+	LangTypeScript: bindTypeTypeScript,
 }
 
 // bindBasicTypeGo converts basic solidity types(except array, slice and tuple) to Go one.
@@ -254,86 +269,194 @@ func bindTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
 	}
 }
 
-// bindBasicTypeJava converts basic solidity types(except array, slice and tuple) to Java one.
+// bindBasicTypeJava converts basic solidity types(except array, slice and tuple)
+// to the Java types of the FISCO BCOS Java SDK.
 func bindBasicTypeJava(kind abi.Type) string {
 	switch kind.T {
-	case abi.AddressTy:
-		return "Address"
+	case abi.AddressTy, abi.StringTy:
+		return "String"
 	case abi.IntTy, abi.UintTy:
-		// Note that uint and int (without digits) are also matched,
-		// these are size 256, and will translate to BigInt (the default).
-		parts := regexp.MustCompile(`(u)?int([0-9]*)`).FindStringSubmatch(kind.String())
-		if len(parts) != 3 {
-			return kind.String()
-		}
-		// All unsigned integers should be translated to BigInt since gomobile doesn't
-		// support them.
-		if parts[1] == "u" {
-			return "BigInt"
-		}
+		return "BigInteger"
+	case abi.FixedBytesTy, abi.BytesTy, abi.FunctionTy:
+		return "byte[]"
+	case abi.BoolTy:
+		return "Boolean"
+	default:
+		return kind.String()
+	}
+}
 
-		namedSize := map[string]string{
-			"8":  "byte",
-			"16": "short",
-			"32": "int",
-			"64": "long",
-		}[parts[2]]
+// bindTypeJava converts a Solidity type to a Java one of the FISCO BCOS Java SDK.
+// Integers of every size are mapped to BigInteger and arrays to lists.
+func bindTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		return structs[kind.String()].Name
+	case abi.ArrayTy, abi.SliceTy:
+		return "List<" + bindTypeJava(*kind.Elem, structs) + ">"
+	default:
+		return bindBasicTypeJava(kind)
+	}
+}
 
-		// default to BigInt
-		if namedSize == "" {
-			namedSize = "BigInt"
-		}
-		return namedSize
-	case abi.FixedBytesTy, abi.BytesTy:
-		return "byte[]"
+// abiTypeJava converts a Solidity type to the ABI datatype class of the FISCO
+// BCOS Java SDK (org.fisco.bcos.web3j.abi.datatypes) that encodes it.
+func abiTypeJava(kind abi.Type) string {
+	switch kind.T {
+	case abi.AddressTy:
+		return "Address"
+	case abi.IntTy:
+		return fmt.Sprintf("Int%d", kind.Size)
+	case abi.UintTy:
+		return fmt.Sprintf("Uint%d", kind.Size)
 	case abi.BoolTy:
-		return "boolean"
+		return "Bool"
 	case abi.StringTy:
-		return "String"
+		return "Utf8String"
+	case abi.BytesTy:
+		return "DynamicBytes"
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("Bytes%d", kind.Size)
 	case abi.FunctionTy:
-		return "byte[24]"
+		return "Bytes24"
+	case abi.ArrayTy:
+		return fmt.Sprintf("StaticArray%d<%s>", kind.Size, abiTypeJava(*kind.Elem))
+	case abi.SliceTy:
+		return fmt.Sprintf("DynamicArray<%s>", abiTypeJava(*kind.Elem))
 	default:
 		return kind.String()
 	}
 }
 
-// pluralizeJavaType explicitly converts multidimensional types to predefined
-// type in go side.
-func pluralizeJavaType(typ string) string {
-	switch typ {
-	case "boolean":
-		return "Bools"
-	case "String":
-		return "Strings"
-	case "Address":
-		return "Addresses"
-	case "byte[]":
-		return "Binaries"
-	case "BigInt":
-		return "BigInts"
+// abiTopicTypeJava converts the Solidity type of an indexed event field to the
+// ABI datatype class of its topic, dynamic types are only available as hashes.
+func abiTopicTypeJava(kind abi.Type) string {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.ArrayTy, abi.SliceTy, abi.TupleTy:
+		return "Bytes32"
 	}
-	return typ + "[]"
+	return abiTypeJava(kind)
 }
 
-// bindTypeJava converts a Solidity type to a Java one. Since there is no clear mapping
-// from all Solidity types to Java ones (e.g. uint17), those that cannot be exactly
-// mapped will use an upscaled type (e.g. BigDecimal).
-func bindTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+// encodeJava returns the Java expression wrapping the value of the named variable
+// into its ABI datatype for encoding.
+func encodeJava(kind abi.Type, name string) string {
+	switch kind.T {
+	case abi.ArrayTy, abi.SliceTy:
+		elem := abiTypeJava(*kind.Elem)
+		return fmt.Sprintf("new %s(org.fisco.bcos.web3j.abi.Utils.typeMap(%s, %s.class))", abiTypeJava(kind), name, elem)
+	default:
+		return fmt.Sprintf("new %s(%s)", abiTypeJava(kind), name)
+	}
+}
+
+// decodeJava returns the Java expression converting the decoded ABI datatype of
+// the expression value to its Java type.
+func decodeJava(kind abi.Type, value string) string {
+	switch kind.T {
+	case abi.ArrayTy, abi.SliceTy:
+		return fmt.Sprintf("convertToNative((List<%s>) %s.getValue())", abiTypeJava(*kind.Elem), value)
+	default:
+		return fmt.Sprintf("(%s) %s.getValue()", bindBasicTypeJava(kind), value)
+	}
+}
+
+// checkJavaTypes reports the types of the ABI the Java SDK can't encode.
+func checkJavaTypes(evmABI abi.ABI) error {
+	check := func(context string, args abi.Arguments) error {
+		for _, arg := range args {
+			if (arg.Type.T == abi.ArrayTy || arg.Type.T == abi.SliceTy) && (arg.Type.Elem.T == abi.ArrayTy || arg.Type.Elem.T == abi.SliceTy) {
+				return fmt.Errorf("java binding for nested arrays is not supported yet (%s)", context)
+			}
+		}
+		return nil
+	}
+	for name, method := range evmABI.Methods {
+		if err := check(name, method.Inputs); err != nil {
+			return err
+		}
+		if err := check(name, method.Outputs); err != nil {
+			return err
+		}
+	}
+	for name, event := range evmABI.Events {
+		if err := check(name, event.Inputs); err != nil {
+			return err
+		}
+	}
+	return check("constructor", evmABI.Constructor.Inputs)
+}
+
+// tupleTypeJava returns the Java SDK tuple class holding the values of args.
+func tupleTypeJava(args abi.Arguments) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = bindTypeJava(arg.Type, nil)
+	}
+	return fmt.Sprintf("Tuple%d<%s>", len(args), strings.Join(types, ", "))
+}
+
+// tupleDecodeJava returns the Java expression building the tuple of args from
+// the decoded ABI datatypes in the list results.
+func tupleDecodeJava(args abi.Arguments) string {
+	values := make([]string, len(args))
+	for i, arg := range args {
+		values[i] = decodeJava(arg.Type, fmt.Sprintf("results.get(%d)", i))
+	}
+	return fmt.Sprintf("new %s(%s)", tupleTypeJava(args), strings.Join(values, ", "))
+}
+
+// isArray reports whether kind is a fixed size or dynamic array.
+func isArray(kind abi.Type) bool {
+	return kind.T == abi.ArrayTy || kind.T == abi.SliceTy
+}
+
+// fieldName returns the name of the i-th event field in lower camel case,
+// falling back to argN for unnamed ones.
+func fieldName(name string, i int) string {
+	if name == "" {
+		return fmt.Sprintf("arg%d", i)
+	}
+	return decapitalise(name)
+}
+
+// bindBasicTypeTypeScript converts basic solidity types(except array, slice and
+// tuple) to TypeScript ones.
+func bindBasicTypeTypeScript(kind abi.Type) string {
+	switch kind.T {
+	case abi.AddressTy:
+		return "Address"
+	case abi.IntTy, abi.UintTy:
+		return "bigint"
+	case abi.FixedBytesTy, abi.BytesTy, abi.FunctionTy:
+		return "BytesLike"
+	case abi.BoolTy:
+		return "boolean"
+	default:
+		// string type
+		return kind.String()
+	}
+}
+
+// bindTypeTypeScript converts a Solidity type to a TypeScript one. Integers of
+// every size are mapped to bigint and byte strings to hex strings.
+func bindTypeTypeScript(kind abi.Type, structs map[string]*tmplStruct) string {
 	switch kind.T {
 	case abi.TupleTy:
 		return structs[kind.String()].Name
 	case abi.ArrayTy, abi.SliceTy:
-		return pluralizeJavaType(bindTypeJava(*kind.Elem, structs))
+		return bindTypeTypeScript(*kind.Elem, structs) + "[]"
 	default:
-		return bindBasicTypeJava(kind)
+		return bindBasicTypeTypeScript(kind)
 	}
 }
 
 // bindTopicType is a set of type binders that convert Solidity types to some
 // supported programming language topic types.
 var bindTopicType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
-	LangGo:   bindTopicTypeGo,
-	LangJava: bindTopicTypeJava,
+	LangGo:         bindTopicTypeGo,
+	LangJava:       bindTopicTypeJava,
+	LangTypeScript: bindTopicTypeTypeScript,
 }
 
 // bindTopicTypeGo converts a Solidity topic type to a Go one. It is almost the same
@@ -349,18 +472,29 @@ func bindTopicTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
 // bindTopicTypeJava converts a Solidity topic type to a Java one. It is almost the same
 // funcionality as for simple types, but dynamic types get converted to hashes.
 func bindTopicTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
-	bound := bindTypeJava(kind, structs)
-	if bound == "String" || bound == "byte[]" {
-		bound = "Hash"
+	if abiTopicTypeJava(kind) == "Bytes32" {
+		return "byte[]"
 	}
-	return bound
+	return bindTypeJava(kind, structs)
+}
+
+// bindTopicTypeTypeScript converts a Solidity topic type to a TypeScript one. It is
+// almost the same funcionality as for simple types, but dynamic types get converted
+// to hashes.
+func bindTopicTypeTypeScript(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.ArrayTy, abi.SliceTy, abi.TupleTy:
+		return "Hash"
+	}
+	return bindTypeTypeScript(kind, structs)
 }
 
 // bindStructType is a set of type binders that convert Solidity tuple types to some supported
 // programming language struct definition.
 var bindStructType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
-	LangGo:   bindStructTypeGo,
-	LangJava: bindStructTypeJava,
+	LangGo:         bindStructTypeGo,
+	LangJava:       bindStructTypeJava,
+	LangTypeScript: bindStructTypeTypeScript,
 }
 
 // bindStructTypeGo converts a Solidity tuple type to a Go one and records the mapping
@@ -413,50 +547,76 @@ func bindStructTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
 		}
 		return name
 	case abi.ArrayTy, abi.SliceTy:
-		return pluralizeJavaType(bindStructTypeJava(*kind.Elem, structs))
+		return "List<" + bindStructTypeJava(*kind.Elem, structs) + ">"
 	default:
 		return bindBasicTypeJava(kind)
 	}
 }
 
-// namedType is a set of functions that transform language specific types to
-// named versions that my be used inside method names.
-var namedType = map[Lang]func(string, abi.Type) string{
-	LangGo:   func(string, abi.Type) string { panic("this shouldn't be needed") },
-	LangJava: namedTypeJava,
-}
-
-// namedTypeJava converts some primitive data types to named variants that can
-// be used as parts of method names.
-func namedTypeJava(javaKind string, solKind abi.Type) string {
-	switch javaKind {
-	case "byte[]":
-		return "Binary"
-	case "boolean":
-		return "Bool"
-	default:
-		parts := regexp.MustCompile(`(u)?int([0-9]*)(\[[0-9]*\])?`).FindStringSubmatch(solKind.String())
-		if len(parts) != 4 {
-			return javaKind
+// bindStructTypeTypeScript converts a Solidity tuple type to a TypeScript interface
+// and records the mapping in the given map.
+// Notably, this function will resolve and record nested struct recursively.
+func bindStructTypeTypeScript(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		if s, exist := structs[kind.String()]; exist {
+			return s.Name
 		}
-		switch parts[2] {
-		case "8", "16", "32", "64":
-			if parts[3] == "" {
-				return capitalise(fmt.Sprintf("%sint%s", parts[1], parts[2]))
+		var fields []*tmplField
+		for i, elem := range kind.TupleElems {
+			field := bindStructTypeTypeScript(*elem, structs)
+			name := kind.TupleRawNames[i]
+			if name == "" {
+				name = fmt.Sprintf("field%d", i)
 			}
-			return capitalise(fmt.Sprintf("%sint%ss", parts[1], parts[2]))
-
-		default:
-			return javaKind
+			fields = append(fields, &tmplField{Type: field, Name: name, SolKind: *elem})
+		}
+		name := fmt.Sprintf("Struct%d", len(structs))
+		structs[kind.String()] = &tmplStruct{
+			Name:   name,
+			Fields: fields,
 		}
+		return name
+	case abi.ArrayTy, abi.SliceTy:
+		return bindStructTypeTypeScript(*kind.Elem, structs) + "[]"
+	default:
+		return bindBasicTypeTypeScript(kind)
 	}
 }
 
+// langFuncs are the template functions specific to a binding language.
+var langFuncs = map[Lang]map[string]interface{}{
+	LangJava: {
+		"abitype":      abiTypeJava,
+		"abitopictype": abiTopicTypeJava,
+		"encode":       encodeJava,
+		"decode":       decodeJava,
+		"tupletype":    tupleTypeJava,
+		"tupledecode":  tupleDecodeJava,
+		"isarray":      isArray,
+		"fieldname":    fieldName,
+		"upper":        strings.ToUpper,
+		"inc":          func(i int) int { return i + 1 },
+	},
+	LangTypeScript: {
+		"fieldname": fieldName,
+	},
+}
+
+// namedType is a set of functions that transform language specific types to
+// named versions that my be used inside method names.
+var namedType = map[Lang]func(string, abi.Type) string{
+	LangGo:         func(string, abi.Type) string { panic("this shouldn't be needed") },
+	LangJava:       func(string, abi.Type) string { panic("this shouldn't be needed") },
+	LangTypeScript: func(string, abi.Type) string { panic("this shouldn't be needed") },
+}
+
 // methodNormalizer is a name transformer that modifies Solidity method names to
 // conform to target language naming concentions.
 var methodNormalizer = map[Lang]func(string) string{
-	LangGo:   abi.ToCamelCase,
-	LangJava: decapitalise,
+	LangGo:         abi.ToCamelCase,
+	LangJava:       decapitalise,
+	LangTypeScript: decapitalise,
 }
 
 // capitalise makes a camel-case string which starts with an upper case character.
@@ -474,6 +634,32 @@ func decapitalise(input string) string {
 	return strings.ToLower(goForm[:1]) + goForm[1:]
 }
 
+// resultName returns the name of the field holding the i-th return value of a
+// method in its generated result struct. Names taken by the Receipt and Events
+// fields of the struct, or by a previous return value, get an Output suffix.
+func resultName(outputs []abi.Argument, i int) string {
+	taken := map[string]bool{"Receipt": true, "Events": true}
+	var name string
+	for j := 0; j <= i; j++ {
+		switch {
+		case outputs[j].Name != "":
+			name = capitalise(outputs[j].Name)
+		case len(outputs) == 1:
+			name = "Output"
+		default:
+			name = fmt.Sprintf("Output%d", j)
+		}
+		for base, n := name, 0; taken[name]; n++ {
+			name = base + "Output"
+			if n > 0 {
+				name = fmt.Sprintf("%sOutput%d", base, n)
+			}
+		}
+		taken[name] = true
+	}
+	return name
+}
+
 // structured checks whether a list of ABI data types has enough information to
 // operate through a proper Go struct or if flat returns are needed.
 func structured(args abi.Arguments) bool {
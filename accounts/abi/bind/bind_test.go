@@ -0,0 +1,499 @@
+package bind
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// bindTests are generated into Go bindings and run against the mock node of the
+// clienttest package.
+var bindTests = []struct {
+	name     string
+	types    []string // Types to bind (default = name)
+	bytecode []string
+	abi      []string
+	libs     map[string]string
+	imports  string
+	tester   string
+}{
+	// Transactions waited for by the AndWait methods, with outputs and events decoded from the receipts
+	{
+		`Store`,
+		nil,
+		[]string{`6080604052`},
+		[]string{`[
+			{"inputs":[{"name":"initial","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"constructor"},
+			{"constant":false,"inputs":[{"name":"value","type":"uint256"}],"name":"set","outputs":[{"name":"old","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+			{"constant":false,"inputs":[{"name":"a","type":"uint256"},{"name":"b","type":"uint256"}],"name":"swap","outputs":[{"name":"","type":"uint256"},{"name":"","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+			{"constant":false,"inputs":[],"name":"touch","outputs":[{"name":"value","type":"uint256"},{"name":"by","type":"address"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+			{"constant":false,"inputs":[],"name":"reset","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"},
+			{"anonymous":false,"inputs":[{"indexed":true,"name":"who","type":"address"},{"indexed":false,"name":"value","type":"uint256"}],"name":"Stored","type":"event"}
+		]`},
+		nil,
+		`
+			"context"
+			"math/big"
+			"strings"
+
+			"github.com/KasperLiu/gobcos/accounts/abi"
+			"github.com/KasperLiu/gobcos/accounts/abi/bind"
+			"github.com/KasperLiu/gobcos/client"
+			"github.com/KasperLiu/gobcos/client/clienttest"
+			"github.com/KasperLiu/gobcos/common"
+			"github.com/KasperLiu/gobcos/core/types"
+			"github.com/KasperLiu/gobcos/crypto"
+		`,
+		`
+			srv := clienttest.NewServer()
+			defer srv.Close()
+			backend, err := client.Dial(srv.URL, 1)
+			if err != nil {
+				t.Fatalf("init rpc client failed: %v", err)
+			}
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+
+			parsed, _ := abi.JSON(strings.NewReader(StoreABI))
+			stored := func(who common.Address, value *big.Int) *types.NewLog {
+				data, _ := parsed.Events["Stored"].Inputs.NonIndexed().Pack(value)
+				return &types.NewLog{
+					Topics: []interface{}{parsed.Events["Stored"].Id().Hex(), common.BytesToHash(who.Bytes()).Hex()},
+					Data:   common.ToHex(data),
+				}
+			}
+			// the mock contract stores a value and emits Stored when it changes
+			value := new(big.Int)
+			srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+				if tx.To() == nil {
+					value.SetBytes(tx.Data()[len(tx.Data())-32:])
+					return &clienttest.Execution{Logs: []*types.NewLog{stored(from, value)}}
+				}
+				method, err := parsed.MethodById(tx.Data())
+				if err != nil {
+					return &clienttest.Execution{Status: common.CallAddressError}
+				}
+				args, _ := method.Inputs.UnpackValues(tx.Data()[4:])
+				var out []byte
+				switch method.Name {
+				case "set":
+					out, _ = method.Outputs.Pack(new(big.Int).Set(value))
+					value.Set(args[0].(*big.Int))
+					return &clienttest.Execution{Output: out, Logs: []*types.NewLog{stored(from, value)}}
+				case "swap":
+					out, _ = method.Outputs.Pack(args[1], args[0])
+					return &clienttest.Execution{Output: out}
+				case "touch":
+					out, _ = method.Outputs.Pack(value, from)
+					return &clienttest.Execution{Output: out}
+				}
+				return &clienttest.Execution{Status: common.RevertInstruction}
+			})
+
+			deployment, err := DeployStoreAndWait(context.Background(), auth, backend, big.NewInt(1))
+			if err != nil {
+				t.Fatalf("deploy contract failed: %v", err)
+			}
+			if deployment.Receipt.GetContractAddress() != deployment.Address || len(deployment.Events) != 1 {
+				t.Fatalf("unexpected deployment: %+v", deployment)
+			}
+			if event := deployment.Events[0].(*StoreStored); event.Who != auth.From || event.Value.Cmp(big.NewInt(1)) != 0 {
+				t.Fatalf("unexpected constructor event: %+v", event)
+			}
+
+			set, err := deployment.Contract.SetAndWait(context.Background(), auth, big.NewInt(5))
+			if err != nil {
+				t.Fatalf("set failed: %v", err)
+			}
+			if set.Old.Cmp(big.NewInt(1)) != 0 || len(set.Events) != 1 {
+				t.Fatalf("unexpected set result: %+v", set)
+			}
+			if event := set.Events[0].(*StoreStored); event.Who != auth.From || event.Value.Cmp(big.NewInt(5)) != 0 || event.Raw.TxHash.Hex() != set.Receipt.TransactionHash {
+				t.Fatalf("unexpected set event: %+v", event)
+			}
+
+			session := &StoreSession{Contract: deployment.Contract, TransactOpts: *auth}
+			swap, err := session.SwapAndWait(context.Background(), big.NewInt(2), big.NewInt(3))
+			if err != nil {
+				t.Fatalf("swap failed: %v", err)
+			}
+			if swap.Output0.Cmp(big.NewInt(3)) != 0 || swap.Output1.Cmp(big.NewInt(2)) != 0 || len(swap.Events) != 0 {
+				t.Fatalf("unexpected swap result: %+v", swap)
+			}
+
+			reset, err := deployment.Contract.ResetAndWait(context.Background(), auth)
+			if _, ok := err.(*bind.TransactionError); !ok || reset == nil || reset.Receipt.GetStatus() != common.RevertInstruction {
+				t.Fatalf("failed transaction not reported: %v", err)
+			}
+
+			// return values decoded from the receipts alone
+			old, err := deployment.Contract.ParseSetOutput(set.Receipt)
+			if err != nil || old.Cmp(big.NewInt(1)) != 0 {
+				t.Fatalf("unexpected set output: %v, %v", old, err)
+			}
+			a, b, err := session.ParseSwapOutput(swap.Receipt)
+			if err != nil || a.Cmp(big.NewInt(3)) != 0 || b.Cmp(big.NewInt(2)) != 0 {
+				t.Fatalf("unexpected swap output: %v, %v, %v", a, b, err)
+			}
+			touch, err := deployment.Contract.TouchAndWait(context.Background(), auth)
+			if err != nil {
+				t.Fatalf("touch failed: %v", err)
+			}
+			if out, err := deployment.Contract.ParseTouchOutput(touch.Receipt); err != nil || out.Value.Cmp(big.NewInt(5)) != 0 || out.By != auth.From {
+				t.Fatalf("unexpected touch output: %+v, %v", out, err)
+			}
+			if _, err := deployment.Contract.ParseSetOutput(reset.Receipt); err == nil {
+				t.Fatalf("output of a failed transaction decoded")
+			}
+
+			// events decoded from the logs of the receipts
+			events, err := deployment.Contract.ParseReceiptEvents(set.Receipt)
+			if err != nil || len(events) != 1 {
+				t.Fatalf("unexpected receipt events: %v, %v", events, err)
+			}
+			if event, ok := events[0].(*StoreStored); !ok || event.Value.Cmp(big.NewInt(5)) != 0 {
+				t.Fatalf("unexpected receipt event: %+v", events[0])
+			}
+			event, err := deployment.Contract.ParseStored(*set.Receipt.Logs[0])
+			if err != nil || event.Who != auth.From || event.Value.Cmp(big.NewInt(5)) != 0 || event.Raw.Address != deployment.Address {
+				t.Fatalf("unexpected parsed event: %+v, %v", event, err)
+			}
+			if events, err := deployment.Contract.ParseReceiptEvents(swap.Receipt); err != nil || len(events) != 0 {
+				t.Fatalf("unexpected receipt events: %v, %v", events, err)
+			}
+			other := *set.Receipt.Logs[0]
+			other.Topics = []interface{}{parsed.Methods["set"].Id()}
+			if _, err := deployment.Contract.ParseStored(other); err == nil {
+				t.Fatalf("log of another event parsed")
+			}
+		`,
+	},
+	// Libraries bound along deployed automatically, others linked explicitly
+	{
+		`Linked`,
+		[]string{`Math`, `User`},
+		[]string{
+			`6001`,
+			`6080__$` + LibraryPattern("lib/Math.sol:Math") + `$__6000__$` + LibraryPattern("lib/Ext.sol:Ext") + `$__`,
+		},
+		[]string{`[]`, `[{"inputs":[{"name":"seed","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"constructor"}]`},
+		map[string]string{
+			LibraryPattern("lib/Math.sol:Math"): "Math",
+			LibraryPattern("lib/Ext.sol:Ext"):   "Ext",
+		},
+		`
+			"bytes"
+			"context"
+			"math/big"
+
+			"github.com/KasperLiu/gobcos/accounts/abi/bind"
+			"github.com/KasperLiu/gobcos/client"
+			"github.com/KasperLiu/gobcos/client/clienttest"
+			"github.com/KasperLiu/gobcos/common"
+			"github.com/KasperLiu/gobcos/crypto"
+		`,
+		`
+			srv := clienttest.NewServer()
+			defer srv.Close()
+			backend, err := client.Dial(srv.URL, 1)
+			if err != nil {
+				t.Fatalf("init rpc client failed: %v", err)
+			}
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+
+			// Ext is not bound along, so it has to be linked
+			if _, _, _, err := DeployUser(auth, backend, nil, big.NewInt(1)); err == nil {
+				t.Fatalf("deployed with an unresolved library")
+			}
+			if len(srv.Transactions()) != 0 {
+				t.Fatalf("transactions sent for a failed deployment")
+			}
+			ext := common.HexToAddress("0x3333333333333333333333333333333333333333")
+			if _, _, _, err := DeployUser(auth, backend, map[string]common.Address{"lib/Ext.sol:Ext": ext}, big.NewInt(1)); err != nil {
+				t.Fatalf("deploy contract failed: %v", err)
+			}
+			// Math was deployed first and both libraries are linked
+			sent := srv.Transactions()
+			if len(sent) != 2 || !bytes.Equal(sent[0].Data(), common.FromHex(MathBin)) {
+				t.Fatalf("library not deployed: %d transactions", len(sent))
+			}
+			math, err := backend.GetContractAddress(context.Background(), sent[0].Hash().Hex())
+			if err != nil {
+				t.Fatalf("library address not found: %v", err)
+			}
+			code := append(append(append(append(common.FromHex("6080"), math.Bytes()...), 0x60, 0x00), ext.Bytes()...), common.LeftPadBytes([]byte{1}, 32)...)
+			if !bytes.Equal(sent[1].Data(), code) {
+				t.Fatalf("unexpected deployment code: %x", sent[1].Data())
+			}
+		`,
+	},
+	// Libraries shared by several dependencies deployed once
+	{
+		`Shared`,
+		[]string{`Base`, `Left`, `Right`, `Top`},
+		[]string{
+			`6001`,
+			`6002__$` + LibraryPattern("lib/Base.sol:Base") + `$__`,
+			`6003__$` + LibraryPattern("lib/Base.sol:Base") + `$__`,
+			`6080__$` + LibraryPattern("lib/Left.sol:Left") + `$__6000__$` + LibraryPattern("lib/Right.sol:Right") + `$__`,
+		},
+		[]string{`[]`, `[]`, `[]`, `[]`},
+		map[string]string{
+			LibraryPattern("lib/Base.sol:Base"):   "Base",
+			LibraryPattern("lib/Left.sol:Left"):   "Left",
+			LibraryPattern("lib/Right.sol:Right"): "Right",
+		},
+		`
+			"bytes"
+			"context"
+
+			"github.com/KasperLiu/gobcos/accounts/abi/bind"
+			"github.com/KasperLiu/gobcos/client"
+			"github.com/KasperLiu/gobcos/client/clienttest"
+			"github.com/KasperLiu/gobcos/common"
+			"github.com/KasperLiu/gobcos/crypto"
+		`,
+		`
+			srv := clienttest.NewServer()
+			defer srv.Close()
+			backend, err := client.Dial(srv.URL, 1)
+			if err != nil {
+				t.Fatalf("init rpc client failed: %v", err)
+			}
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+
+			if _, _, _, err := DeployTop(auth, backend, nil); err != nil {
+				t.Fatalf("deploy contract failed: %v", err)
+			}
+			// Base, then Left and Right linked against the same Base, then Top
+			sent := srv.Transactions()
+			if len(sent) != 4 || !bytes.Equal(sent[0].Data(), common.FromHex(BaseBin)) {
+				t.Fatalf("unexpected deployments: %d transactions", len(sent))
+			}
+			base, err := backend.GetContractAddress(context.Background(), sent[0].Hash().Hex())
+			if err != nil {
+				t.Fatalf("library address not found: %v", err)
+			}
+			for _, tx := range sent[1:3] {
+				if code := tx.Data(); !bytes.Equal(code[2:], base.Bytes()) {
+					t.Fatalf("library not linked against the shared Base: %x", code)
+				}
+			}
+		`,
+	},
+	// Return values named after the fields of the AndWait result structs
+	{
+		`Reserved`,
+		nil,
+		[]string{`6080604052`},
+		[]string{`[
+			{"constant":false,"inputs":[],"name":"settle","outputs":[{"name":"receipt","type":"uint256"},{"name":"events","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+			{"constant":false,"inputs":[],"name":"pair","outputs":[{"name":"","type":"uint256"},{"name":"output0","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"}
+		]`},
+		nil,
+		`
+			"context"
+			"math/big"
+			"strings"
+
+			"github.com/KasperLiu/gobcos/accounts/abi"
+			"github.com/KasperLiu/gobcos/accounts/abi/bind"
+			"github.com/KasperLiu/gobcos/client"
+			"github.com/KasperLiu/gobcos/client/clienttest"
+			"github.com/KasperLiu/gobcos/common"
+			"github.com/KasperLiu/gobcos/core/types"
+			"github.com/KasperLiu/gobcos/crypto"
+		`,
+		`
+			srv := clienttest.NewServer()
+			defer srv.Close()
+			backend, err := client.Dial(srv.URL, 1)
+			if err != nil {
+				t.Fatalf("init rpc client failed: %v", err)
+			}
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+
+			parsed, _ := abi.JSON(strings.NewReader(ReservedABI))
+			srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+				method, err := parsed.MethodById(tx.Data())
+				if err != nil {
+					return &clienttest.Execution{Status: common.CallAddressError}
+				}
+				out, _ := method.Outputs.Pack(big.NewInt(1), big.NewInt(2))
+				return &clienttest.Execution{Output: out}
+			})
+			address := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+			srv.SetCode(address, common.FromHex(ReservedBin))
+			reserved, err := NewReserved(address, backend)
+			if err != nil {
+				t.Fatalf("bind contract failed: %v", err)
+			}
+			settle, err := reserved.SettleAndWait(context.Background(), auth)
+			if err != nil {
+				t.Fatalf("settle failed: %v", err)
+			}
+			if settle.Receipt == nil || settle.ReceiptOutput.Cmp(big.NewInt(1)) != 0 || settle.EventsOutput.Cmp(big.NewInt(2)) != 0 || len(settle.Events) != 0 {
+				t.Fatalf("unexpected settle result: %+v", settle)
+			}
+			pair, err := reserved.PairAndWait(context.Background(), auth)
+			if err != nil {
+				t.Fatalf("pair failed: %v", err)
+			}
+			if pair.Output0.Cmp(big.NewInt(1)) != 0 || pair.Output0Output.Cmp(big.NewInt(2)) != 0 {
+				t.Fatalf("unexpected pair result: %+v", pair)
+			}
+		`,
+	},
+}
+
+// Tests that packages generated by the binder can be successfully compiled and
+// the requested tester run against it.
+func TestGolangBindings(t *testing.T) {
+	// Skip the test if no Go command can be found
+	gocmd := runtime.GOROOT() + "/bin/go"
+	if _, err := os.Stat(gocmd); err != nil {
+		t.Skip("go sdk not found for testing")
+	}
+	root, err := filepath.Abs("../../..")
+	if err != nil {
+		t.Fatalf("failed to resolve the repository root: %v", err)
+	}
+	// Create a temporary workspace for the test suite
+	ws, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary workspace: %v", err)
+	}
+	defer os.RemoveAll(ws)
+
+	pkg := filepath.Join(ws, "bindtest")
+	if err = os.MkdirAll(pkg, 0700); err != nil {
+		t.Fatalf("failed to create package: %v", err)
+	}
+	// Generate the test suite for all the contracts
+	for i, tt := range bindTests {
+		// Generate the binding and create a Go source file in the workspace
+		types := tt.types
+		if types == nil {
+			types = []string{tt.name}
+		}
+		bind, err := Bind(types, tt.abi, tt.bytecode, nil, "bindtest", LangGo, tt.libs)
+		if err != nil {
+			t.Fatalf("test %d: failed to generate binding: %v", i, err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(pkg, strings.ToLower(tt.name)+".go"), []byte(bind), 0600); err != nil {
+			t.Fatalf("test %d: failed to write binding: %v", i, err)
+		}
+		// Generate the test file with the injected test code
+		code := fmt.Sprintf(`
+			package bindtest
+
+			import (
+				"testing"
+				%s
+			)
+
+			func Test%s(t *testing.T) {
+				%s
+			}
+		`, tt.imports, tt.name, tt.tester)
+		if err := ioutil.WriteFile(filepath.Join(pkg, strings.ToLower(tt.name)+"_test.go"), []byte(code), 0600); err != nil {
+			t.Fatalf("test %d: failed to write tests: %v", i, err)
+		}
+	}
+	// Point the generated module to this repository and reuse its checksums
+	mod := "module bindtest\n\ngo 1.12\n\nrequire github.com/KasperLiu/gobcos v0.0.0\n\nreplace github.com/KasperLiu/gobcos => " + filepath.ToSlash(root) + "\n"
+	if err := ioutil.WriteFile(filepath.Join(pkg, "go.mod"), []byte(mod), 0600); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	sum, err := ioutil.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatalf("failed to read go.sum: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(pkg, "go.sum"), sum, 0600); err != nil {
+		t.Fatalf("failed to write go.sum: %v", err)
+	}
+	// Test the entire package and report any failures
+	cmd := exec.Command(gocmd, "test", "-v", "-count", "1")
+	cmd.Dir = pkg
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to run binding test: %v\n%s", err, out)
+	}
+}
+
+// Tests that the Java and TypeScript bindings declare the expected types and
+// methods of the contracts.
+func TestLanguageBindings(t *testing.T) {
+	store := bindTests[0].abi[0]
+	tuples := `[
+		{"constant":true,"inputs":[{"components":[{"name":"owner","type":"address"},{"name":"tags","type":"bytes32[]"}],"name":"item","type":"tuple"}],"name":"check","outputs":[{"name":"","type":"bool"}],"payable":false,"stateMutability":"view","type":"function"},
+		{"constant":true,"inputs":[],"name":"pair","outputs":[{"name":"","type":"uint8"},{"name":"","type":"string"}],"payable":false,"stateMutability":"view","type":"function"},
+		{"anonymous":false,"inputs":[{"indexed":true,"name":"Name","type":"string"},{"indexed":false,"name":"","type":"int64[2]"}],"name":"Tagged","type":"event"}
+	]`
+	tests := []struct {
+		lang Lang
+		abi  string
+		want []string
+	}{
+		{LangJava, store, []string{
+			`package bindtest;`,
+			`public class Store extends Contract {`,
+			`public static RemoteCall<Store> deploy(Web3j web3j, Credentials credentials, ContractGasProvider contractGasProvider, BigInteger initial) {`,
+			`FunctionEncoder.encodeConstructor(Arrays.<Type>asList(new Uint256(initial)));`,
+			`public RemoteCall<TransactionReceipt> set(BigInteger value) {`,
+			`public void set(BigInteger value, TransactionSucCallback callback) {`,
+			`public Tuple1<BigInteger> getSetOutput(TransactionReceipt transactionReceipt) {`,
+			`public Tuple2<BigInteger, String> getTouchOutput(TransactionReceipt transactionReceipt) {`,
+			`return new Tuple2<BigInteger, String>((BigInteger) results.get(0).getValue(), (String) results.get(1).getValue());`,
+			`public static final Event STORED_EVENT = new Event("Stored",`,
+			`Arrays.<TypeReference<?>>asList(new TypeReference<Address>(true) {}, new TypeReference<Uint256>() {}));`,
+			`typedResponse.who = (String) eventValues.getIndexedValues().get(0).getValue();`,
+			`typedResponse.value = (BigInteger) eventValues.getNonIndexedValues().get(0).getValue();`,
+		}},
+		{LangTypeScript, store, []string{
+			`export const StoreBin = "0x6080604052";`,
+			`export interface StoreTransactor {`,
+			`set(value: bigint): Promise<TransactionReceipt>;`,
+			`export type StoreSetOutput = bigint;`,
+			`export type StoreSwapOutput = [bigint, bigint];`,
+			`export type StoreTouchOutput = { value: bigint; by: Address; };`,
+			`export interface Store extends StoreCaller, StoreTransactor {`,
+			`export interface StoreStoredEvent {`,
+			`event: "Stored";`,
+			`who: Address;`,
+			`export type StoreEvent = StoreStoredEvent | never;`,
+		}},
+		{LangTypeScript, tuples, []string{
+			`export interface Struct0 {`,
+			`owner: Address;`,
+			`tags: BytesLike[];`,
+			`check(item: Struct0): Promise<boolean>;`,
+			`pair(): Promise<[bigint, string]>;`,
+			`name: Hash;`,
+			`arg1: bigint[];`,
+		}},
+	}
+	for i, tt := range tests {
+		code, err := Bind([]string{"Store"}, []string{tt.abi}, []string{"6080604052"}, nil, "bindtest", tt.lang, nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to generate binding: %v", i, err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(code, want) {
+				t.Errorf("test %d: binding is missing %q:\n%s", i, want, code)
+			}
+		}
+	}
+	// Tuples can't be bound to Java yet
+	if _, err := Bind([]string{"Store"}, []string{tuples}, []string{""}, nil, "bindtest", LangJava, nil); err == nil {
+		t.Errorf("expected error binding tuples to java")
+	}
+}
@@ -0,0 +1,188 @@
+package bind
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+	"github.com/KasperLiu/gobcos/core/types"
+)
+
+// DynamicContract is a contract client driven by an ABI that is only known at
+// runtime, e.g. the one registered in CNS. Methods are invoked by name with their
+// arguments given as strings or decoded JSON values, which are coerced to the
+// Go types the ABI packer expects, and the results are returned as JSON friendly
+// values:
+//
+//   - integers of any width as decimal strings
+//   - addresses as checksummed hex strings
+//   - bytes, bytesN and function values as 0x prefixed hex strings
+//   - booleans and strings as is
+//   - arrays as slices and tuples as maps keyed by their field names
+type DynamicContract struct {
+	*BoundContract
+}
+
+// NewDynamicContract creates a client of the contract deployed at address with
+// the given ABI.
+func NewDynamicContract(address common.Address, abi abi.ABI, backend ContractBackend) *DynamicContract {
+	return &DynamicContract{NewBoundContract(address, abi, backend, backend, backend)}
+}
+
+// Pack coerces the arguments to the inputs of method and packs them into the
+// input of a call or transaction.
+//
+// An argument may be a string in the format of abi.ParseArgument, i.e. a plain
+// elementary value or the JSON encoding of an array or a tuple, or a value
+// decoded from JSON.
+func (c *DynamicContract) Pack(method string, args ...interface{}) ([]byte, error) {
+	m, ok := c.abi.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("method '%s' not found", method)
+	}
+	if len(args) != len(m.Inputs) {
+		return nil, fmt.Errorf("argument count mismatch for %s: %d for %d", method, len(args), len(m.Inputs))
+	}
+	params := make([]interface{}, len(args))
+	for i, arg := range args {
+		value, err := parseArgument(m.Inputs[i].Type, arg)
+		if err != nil {
+			name := m.Inputs[i].Name
+			if name == "" {
+				name = fmt.Sprintf("%d", i)
+			}
+			return nil, fmt.Errorf("invalid argument %s of %s (%s): %v", name, method, m.Inputs[i].Type, err)
+		}
+		params[i] = value
+	}
+	return c.abi.Pack(method, params...)
+}
+
+// Call invokes the (constant) contract method with the arguments and returns
+// its return values.
+func (c *DynamicContract) Call(opts *CallOpts, method string, args ...interface{}) ([]interface{}, error) {
+	input, err := c.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.call(opts, input)
+	if err != nil {
+		return nil, err
+	}
+	return c.unpackOutput(method, output)
+}
+
+// Transact invokes the (paid) contract method with the arguments.
+func (c *DynamicContract) Transact(opts *TransactOpts, method string, args ...interface{}) (*types.RawTransaction, error) {
+	input, err := c.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return c.transact(opts, &c.address, input)
+}
+
+// TransactAndWait invokes the (paid) contract method with the arguments, waits
+// for the transaction to be mined and returns its receipt along with the return
+// values carried by it. If the transaction was not executed successfully, the
+// receipt is returned with a *TransactionError.
+func (c *DynamicContract) TransactAndWait(ctx context.Context, opts *TransactOpts, method string, args ...interface{}) (*types.Receipt, []interface{}, error) {
+	tx, err := c.Transact(opts, method, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	receipt, err := c.WaitMined(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, err := c.ReceiptOutput(method, receipt)
+	return receipt, results, err
+}
+
+// ReceiptOutput returns the return values of method carried by the Output of a
+// FISCO BCOS receipt. A receipt of a failed transaction is reported as a
+// *TransactionError.
+func (c *DynamicContract) ReceiptOutput(method string, receipt *types.Receipt) ([]interface{}, error) {
+	if receipt.GetStatus() != common.Success {
+		return nil, &TransactionError{Receipt: receipt}
+	}
+	return c.unpackOutput(method, common.FromHex(receipt.GetOutput()))
+}
+
+// unpackOutput decodes the output of method into JSON friendly values.
+func (c *DynamicContract) unpackOutput(method string, output []byte) ([]interface{}, error) {
+	m, ok := c.abi.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("method '%s' not found", method)
+	}
+	if len(m.Outputs) == 0 {
+		return []interface{}{}, nil
+	}
+	values, err := m.Outputs.UnpackValues(output)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]interface{}, len(values))
+	for i, value := range values {
+		results[i] = jsonValue(m.Outputs[i].Type, reflect.ValueOf(value))
+	}
+	return results, nil
+}
+
+// parseArgument converts a string or decoded JSON argument to the Go value of
+// typ. Values already of that type are passed as is.
+func parseArgument(typ abi.Type, arg interface{}) (interface{}, error) {
+	if v := reflect.ValueOf(arg); v.IsValid() && v.Type() == typ.Type {
+		return arg, nil
+	}
+	input, ok := arg.(string)
+	if !ok {
+		blob, err := json.Marshal(arg)
+		if err != nil {
+			return nil, err
+		}
+		input = string(blob)
+	}
+	return abi.ParseArgument(typ, input)
+}
+
+// jsonValue converts an unpacked value of the ABI type to a JSON friendly one.
+func jsonValue(typ abi.Type, v reflect.Value) interface{} {
+	switch typ.T {
+	case abi.IntTy, abi.UintTy:
+		switch n := v.Interface().(type) {
+		case *big.Int:
+			return n.String()
+		default:
+			if typ.T == abi.UintTy {
+				return new(big.Int).SetUint64(v.Uint()).String()
+			}
+			return big.NewInt(v.Int()).String()
+		}
+	case abi.AddressTy:
+		return v.Interface().(common.Address).Hex()
+	case abi.BytesTy:
+		return hexutil.Encode(v.Bytes())
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hexutil.Encode(b)
+	case abi.SliceTy, abi.ArrayTy:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = jsonValue(*typ.Elem, v.Index(i))
+		}
+		return list
+	case abi.TupleTy:
+		fields := make(map[string]interface{}, len(typ.TupleElems))
+		for i, name := range typ.TupleRawNames {
+			fields[name] = jsonValue(*typ.TupleElems[i], v.Field(i))
+		}
+		return fields
+	}
+	return v.Interface()
+}
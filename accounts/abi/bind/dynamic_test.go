@@ -0,0 +1,150 @@
+package bind_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
+)
+
+const registryABI = `[
+	{"constant":false,"inputs":[{"name":"id","type":"uint64"},{"name":"owner","type":"address"},{"name":"hash","type":"bytes32"},{"name":"shares","type":"int16[2]"}],"name":"register","outputs":[{"name":"count","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+	{"constant":false,"inputs":[{"components":[{"name":"name","type":"string"},{"name":"tags","type":"uint8[]"},{"name":"active","type":"bool"}],"name":"item","type":"tuple"}],"name":"add","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"},
+	{"constant":true,"inputs":[{"name":"id","type":"uint64"}],"name":"get","outputs":[{"name":"owner","type":"address"},{"name":"data","type":"bytes"},{"components":[{"name":"name","type":"string"},{"name":"tags","type":"uint8[]"},{"name":"active","type":"bool"}],"name":"item","type":"tuple"}],"payable":false,"stateMutability":"view","type":"function"}
+]`
+
+func TestDynamicContractPack(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(registryABI))
+	if err != nil {
+		t.Fatalf("parse ABI failed: %v", err)
+	}
+	contract := bind.NewDynamicContract(common.Address{}, parsed, nil)
+
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	hash := common.HexToHash("0x0102")
+	want, err := parsed.Pack("register", uint64(7), owner, [32]byte(hash), [2]int16{-1, 300})
+	if err != nil {
+		t.Fatalf("pack failed: %v", err)
+	}
+	inputs := [][]interface{}{
+		{"7", owner.Hex(), hash.Hex(), "[-1, 300]"},
+		{"0x7", strings.ToLower(owner.Hex()[2:]), hash.Hex(), []interface{}{"-1", json.Number("300")}},
+		{float64(7), owner.Hex(), hash.Hex(), []interface{}{-1, 300}},
+		{uint64(7), owner, hash.Hex(), [2]int16{-1, 300}},
+	}
+	for i, args := range inputs {
+		input, err := contract.Pack("register", args...)
+		if err != nil {
+			t.Errorf("input %d: pack failed: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(input, want) {
+			t.Errorf("input %d: packed input mismatch:\nhave %x\nwant %x", i, input, want)
+		}
+	}
+	// Tuples are given as objects or arrays of their fields
+	item := struct {
+		Name   string  `json:"name"`
+		Tags   []uint8 `json:"tags"`
+		Active bool    `json:"active"`
+	}{"store", []uint8{1, 2}, true}
+	want, err = parsed.Pack("add", item)
+	if err != nil {
+		t.Fatalf("pack failed: %v", err)
+	}
+	for i, arg := range []interface{}{`{"name": "store", "tags": [1, 2], "active": true}`, `["store", ["1", "0x2"], "true"]`} {
+		input, err := contract.Pack("add", arg)
+		if err != nil {
+			t.Errorf("tuple %d: pack failed: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(input, want) {
+			t.Errorf("tuple %d: packed input mismatch:\nhave %x\nwant %x", i, input, want)
+		}
+	}
+	// Invalid arguments are reported by name
+	failures := []struct {
+		method string
+		args   []interface{}
+		err    string
+	}{
+		{"register", []interface{}{"7", owner.Hex(), hash.Hex()}, "argument count mismatch"},
+		{"register", []interface{}{"-7", owner.Hex(), hash.Hex(), "[1, 2]"}, "argument id"},
+		{"register", []interface{}{"18446744073709551616", owner.Hex(), hash.Hex(), "[1, 2]"}, "out of range"},
+		{"register", []interface{}{"7", "0x1234", hash.Hex(), "[1, 2]"}, "invalid address"},
+		{"register", []interface{}{"7", owner.Hex(), "0x" + strings.Repeat("00", 33), "[1, 2]"}, "33 bytes long"},
+		{"register", []interface{}{"7", owner.Hex(), hash.Hex(), "[1, 32768]"}, "argument shares of register (int16[2]): [1]: 32768 out of range for int16"},
+		{"register", []interface{}{"7", owner.Hex(), hash.Hex(), "[1]"}, "requires 2 elements"},
+		{"add", []interface{}{`{"name": "store", "tags": [256], "active": true}`}, "tags[0]: 256 out of range"},
+		{"add", []interface{}{`{"name": "store", "tags": []}`}, "missing field active"},
+		{"unknown", nil, "not found"},
+	}
+	for i, tt := range failures {
+		if _, err := contract.Pack(tt.method, tt.args...); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("failure %d: error mismatch: have %v, want %q", i, err, tt.err)
+		}
+	}
+}
+
+func TestDynamicContractCallAndTransact(t *testing.T) {
+	srv, backend, auth := newTestBackend(t)
+	defer srv.Close()
+
+	parsed, err := abi.JSON(strings.NewReader(registryABI))
+	if err != nil {
+		t.Fatalf("parse ABI failed: %v", err)
+	}
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	item := struct {
+		Name   string
+		Tags   []uint8
+		Active bool
+	}{"store", []uint8{1, 2}, true}
+	srv.HandleCall(func(from, to common.Address, data []byte) ([]byte, error) {
+		return parsed.Methods["get"].Outputs.Pack(owner, []byte{0xca, 0xfe}, item)
+	})
+	srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+		output, _ := parsed.Methods["register"].Outputs.Pack(big.NewInt(3))
+		return &clienttest.Execution{Output: output}
+	})
+	address, tx, _, err := bind.DeployContract(auth, parsed, storeBin, backend)
+	if err != nil {
+		t.Fatalf("deploy contract failed: %v", err)
+	}
+	if _, err := bind.WaitDeployed(context.Background(), backend, tx); err != nil {
+		t.Fatalf("wait for the deployment failed: %v", err)
+	}
+	contract := bind.NewDynamicContract(address, parsed, backend)
+
+	results, err := contract.Call(nil, "get", "7")
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	want := []interface{}{
+		owner.Hex(),
+		"0xcafe",
+		map[string]interface{}{"name": "store", "tags": []interface{}{"1", "2"}, "active": true},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("call results mismatch:\nhave %v\nwant %v", results, want)
+	}
+	if _, err := json.Marshal(results); err != nil {
+		t.Fatalf("results are not JSON friendly: %v", err)
+	}
+
+	_, results, err = contract.TransactAndWait(context.Background(), auth, "register", "7", owner.Hex(), common.Hash{}.Hex(), "[1, 2]")
+	if err != nil {
+		t.Fatalf("transact failed: %v", err)
+	}
+	if !reflect.DeepEqual(results, []interface{}{"3"}) {
+		t.Fatalf("transaction results mismatch: have %v, want [3]", results)
+	}
+}
@@ -0,0 +1,114 @@
+package bind
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/crypto"
+)
+
+// LibraryPattern returns the link pattern solc places into bytecode, as in
+// __$pattern$__, to reference the library of the fully qualified name
+// (e.g. lib/Math.sol:Math). A name that already is a link pattern is returned
+// as is.
+func LibraryPattern(name string) string {
+	if len(name) == 34 && isHex(name) {
+		return name
+	}
+	return crypto.Keccak256Hash([]byte(name)).String()[2:36]
+}
+
+// LinkBytecode replaces the library link references of the hex encoded bytecode
+// bin with the addresses of libs, keyed by the link pattern or the fully qualified
+// name of the library. Both the __$pattern$__ references of solc 0.5 and later and
+// the __name__ references of earlier versions are linked. An error is returned if
+// any reference remains unresolved.
+func LinkBytecode(bin string, libs map[string]common.Address) (string, error) {
+	patterns := make(map[string]common.Address, len(libs))
+	names := make([]string, 0, len(libs))
+	for name, address := range libs {
+		patterns[LibraryPattern(name)] = address
+		names = append(names, name)
+	}
+	var unresolved []string
+	linked, err := replaceReferences(bin, func(ref string) string {
+		address, ok := patterns[strings.Trim(ref, "_$")]
+		if !ok {
+			var name string
+			if name, ok = legacyReference(ref, names); ok {
+				address = libs[name]
+			}
+		}
+		if !ok {
+			unresolved = append(unresolved, ref)
+			return ref
+		}
+		return strings.TrimPrefix(address.Hex(), "0x")
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(unresolved) > 0 {
+		return "", fmt.Errorf("unresolved library link references: %s", strings.Join(unresolved, ", "))
+	}
+	return linked, nil
+}
+
+// LinkPatterns rewrites the __name__ library link references of solc before 0.5
+// in the hex encoded bytecode bin into the __$pattern$__ references of later
+// versions, matching them against the fully qualified names of the libraries.
+// The references of unknown libraries are left as is.
+func LinkPatterns(bin string, names []string) (string, error) {
+	return replaceReferences(bin, func(ref string) string {
+		if name, ok := legacyReference(ref, names); ok {
+			return "__$" + LibraryPattern(name) + "$__"
+		}
+		return ref
+	})
+}
+
+// replaceReferences replaces every 40 characters long library link reference of
+// bin with the result of replace.
+func replaceReferences(bin string, replace func(ref string) string) (string, error) {
+	var out strings.Builder
+	for {
+		i := strings.Index(bin, "__")
+		if i < 0 {
+			out.WriteString(bin)
+			return out.String(), nil
+		}
+		if i+40 > len(bin) {
+			return "", fmt.Errorf("invalid library link reference %s", bin[i:])
+		}
+		out.WriteString(bin[:i])
+		out.WriteString(replace(bin[i : i+40]))
+		bin = bin[i+40:]
+	}
+}
+
+// legacyReference returns the fully qualified name among names of the library
+// referenced by ref in the format of solc before 0.5, which holds the name cut
+// to 36 characters.
+func legacyReference(ref string, names []string) (string, bool) {
+	if ref[2] == '$' {
+		return "", false
+	}
+	name := strings.TrimRight(ref[2:38], "_")
+	for _, fqn := range names {
+		if fqn == name || (len(name) == 36 && strings.HasPrefix(fqn, name)) {
+			return fqn, true
+		}
+	}
+	return "", false
+}
+
+// isHex reports whether s consists of hex digits only.
+func isHex(s string) bool {
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
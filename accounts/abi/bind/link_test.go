@@ -0,0 +1,66 @@
+package bind_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/common"
+)
+
+func TestLinkBytecode(t *testing.T) {
+	math := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	strs := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	mathRef := "__$" + bind.LibraryPattern("lib/Math.sol:Math") + "$__"
+	legacyRef := "__lib/Strings.sol:Strings" + strings.Repeat("_", 36-23) + "__"
+
+	tests := []struct {
+		bin  string
+		libs map[string]common.Address
+		want string
+		err  string
+	}{
+		// no references
+		{"0x6080", nil, "0x6080", ""},
+		// references by fully qualified name and by pattern
+		{"6080" + mathRef + "60" + mathRef, map[string]common.Address{"lib/Math.sol:Math": math}, "6080" + math.Hex()[2:] + "60" + math.Hex()[2:], ""},
+		{"6080" + mathRef, map[string]common.Address{bind.LibraryPattern("lib/Math.sol:Math"): math}, "6080" + math.Hex()[2:], ""},
+		// references of solc before 0.5
+		{"6080" + legacyRef + mathRef, map[string]common.Address{"lib/Strings.sol:Strings": strs, "lib/Math.sol:Math": math}, "6080" + strs.Hex()[2:] + math.Hex()[2:], ""},
+		// unresolved references
+		{"6080" + mathRef + legacyRef, map[string]common.Address{"lib/Math.sol:Math": math}, "", legacyRef},
+		{"6080" + mathRef, map[string]common.Address{"Math": math}, "", mathRef},
+		{"6080__$1234", nil, "", "invalid"},
+	}
+	for i, tt := range tests {
+		linked, err := bind.LinkBytecode(tt.bin, tt.libs)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("test %d: error mismatch: have %v, want %q", i, err, tt.err)
+		case tt.err == "" && linked != tt.want:
+			t.Errorf("test %d: linked bytecode mismatch:\nhave %s\nwant %s", i, linked, tt.want)
+		}
+	}
+}
+
+func TestLinkPatterns(t *testing.T) {
+	names := []string{"lib/Math.sol:Math", "lib/Strings.sol:Strings"}
+	mathRef := "__$" + bind.LibraryPattern("lib/Math.sol:Math") + "$__"
+	strsRef := "__$" + bind.LibraryPattern("lib/Strings.sol:Strings") + "$__"
+	legacyRef := "__lib/Strings.sol:Strings" + strings.Repeat("_", 36-23) + "__"
+	unknownRef := "__lib/Ext.sol:Ext" + strings.Repeat("_", 36-15) + "__"
+
+	bin := "6080" + legacyRef + mathRef + unknownRef
+	linked, err := bind.LinkPatterns(bin, names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "6080" + strsRef + mathRef + unknownRef; linked != want {
+		t.Fatalf("rewritten bytecode mismatch:\nhave %s\nwant %s", linked, want)
+	}
+	if _, err := bind.LinkPatterns("6080__$1234", names); err == nil {
+		t.Fatal("truncated reference accepted")
+	}
+}
@@ -35,7 +35,8 @@ type tmplContract struct {
 	Calls       map[string]*tmplMethod // Contract calls that only read state data
 	Transacts   map[string]*tmplMethod // Contract calls that write state data
 	Events      map[string]*tmplEvent  // Contract events accessors
-	Libraries   map[string]string      // Same as tmplData, but filtered to only keep what the contract needs
+	Libraries   map[string]string      // Same as tmplData, but filtered to only keep what the contract needs and is bound along
+	Linked      bool                   // Whether the bytecode holds library link references
 	Structs     map[string]*tmplStruct // Contract struct type definitions
 	Library     bool
 }
@@ -72,8 +73,9 @@ type tmplStruct struct {
 // tmplSource is language to template mapping containing all the supported
 // programming languages the package can generate to.
 var tmplSource = map[Lang]string{
-	LangGo:   tmplSourceGo,
-	LangJava: tmplSourceJava,
+	LangGo:         tmplSourceGo,
+	LangJava:       tmplSourceJava,
+	LangTypeScript: tmplSourceTypeScript,
 }
 
 // tmplSourceGo is the Go source template use to generate the contract binding
@@ -85,6 +87,7 @@ const tmplSourceGo = `
 package {{.Package}}
 
 import (
+	"context"
 	"math/big"
 	"strings"
 	
@@ -97,6 +100,7 @@ import (
 
 // Reference imports to suppress errors if they are not otherwise used.
 var (
+	_ = context.Background
 	_ = big.NewInt
 	_ = strings.NewReader
 	_ = common.NotFound
@@ -124,22 +128,102 @@ var (
 		// {{.Type}}Bin is the compiled bytecode used for deploying new contracts.
 		var {{.Type}}Bin = "0x{{.InputBin}}"
 
-		// Deploy{{.Type}} deploys a new Ethereum contract, binding an instance of {{.Type}} to it.
-		func Deploy{{.Type}}(auth *bind.TransactOpts, backend bind.ContractBackend {{range .Constructor.Inputs}}, {{.Name}} {{bindtype .Type $structs}}{{end}}) (common.Address, *types.RawTransaction, *{{.Type}}, error) {
+		// Deploy{{.Type}} deploys a new Ethereum contract, binding an instance of {{.Type}} to it.{{if .Linked}}
+		// The bytecode is linked against the libraries, keyed by their link pattern or fully
+		// qualified name. Libraries bound in this package and missing from them are deployed first.{{end}}
+		func Deploy{{.Type}}(auth *bind.TransactOpts, backend bind.ContractBackend {{if .Linked}}, libraries map[string]common.Address{{end}} {{range .Constructor.Inputs}}, {{.Name}} {{bindtype .Type $structs}}{{end}}) (common.Address, *types.RawTransaction, *{{.Type}}, error) {
 		  parsed, err := abi.JSON(strings.NewReader({{.Type}}ABI))
 		  if err != nil {
 		    return common.Address{}, nil, nil, err
 		  }
-		  {{range $pattern, $name := .Libraries}}
-			{{decapitalise $name}}Addr, _, _, _ := Deploy{{capitalise $name}}(auth, backend)
-			{{$contract.Type}}Bin = strings.Replace({{$contract.Type}}Bin, "__${{$pattern}}$__", {{decapitalise $name}}Addr.String()[2:], -1)
+		  {{if .Linked}}
+		  libs := make(map[string]common.Address, len(libraries))
+		  for name, address := range libraries {
+		    libs[bind.LibraryPattern(name)] = address
+		  }
+		  return deploy{{.Type}}(auth, backend, parsed, libs {{range .Constructor.Inputs}}, {{.Name}}{{end}})
+		}
+
+		// deploy{{.Type}} deploys {{.Type}} linked against libs, keyed by link pattern, after
+		// the libraries bound in this package and missing from libs, which are added to libs
+		// so that a library shared by several dependencies is deployed once.
+		func deploy{{.Type}}(auth *bind.TransactOpts, backend bind.ContractBackend, parsed abi.ABI, libs map[string]common.Address {{range .Constructor.Inputs}}, {{.Name}} {{bindtype .Type $structs}}{{end}}) (common.Address, *types.RawTransaction, *{{.Type}}, error) {
+		  // Libraries bound in this package are deployed once all references are known to resolve
+		  var missing []string
+		  check := make(map[string]common.Address, len(libs))
+		  for pattern, address := range libs {
+		    check[pattern] = address
+		  }
+		  {{range $pattern, $name := .Libraries}}if _, ok := libs["{{$pattern}}"]; !ok {
+		    missing = append(missing, "{{$pattern}}")
+		    check["{{$pattern}}"] = common.Address{}
+		  }
 		  {{end}}
-		  address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex({{.Type}}Bin), backend {{range .Constructor.Inputs}}, {{.Name}}{{end}})
+		  if _, err := bind.LinkBytecode({{.Type}}Bin, check); err != nil {
+		    return common.Address{}, nil, nil, err
+		  }
+		  for _, pattern := range missing {
+		    if _, ok := libs[pattern]; ok {
+		      continue // deployed along with another library
+		    }
+		    var (
+		      address common.Address
+		      err     error
+		    )
+		    switch pattern {
+		    {{range $pattern, $name := .Libraries}}case "{{$pattern}}":
+		      {{if (index $.Contracts $name).Linked}}var parsed abi.ABI
+		      if parsed, err = abi.JSON(strings.NewReader({{capitalise $name}}ABI)); err == nil {
+		        address, _, _, err = deploy{{capitalise $name}}(auth, backend, parsed, libs)
+		      }{{else}}address, _, _, err = Deploy{{capitalise $name}}(auth, backend){{end}}
+		    {{end}}
+		    }
+		    if err != nil {
+		      return common.Address{}, nil, nil, err
+		    }
+		    libs[pattern] = address
+		  }
+		  bin, err := bind.LinkBytecode({{.Type}}Bin, libs)
+		  if err != nil {
+		    return common.Address{}, nil, nil, err
+		  }
+		  {{else}}
+		  bin := {{.Type}}Bin
+		  {{end}}
+		  address, tx, contract, err := bind.DeployContract(auth, parsed, common.FromHex(bin), backend {{range .Constructor.Inputs}}, {{.Name}}{{end}})
 		  if err != nil {
 		    return common.Address{}, nil, nil, err
 		  }
 		  return address, tx, &{{.Type}}{ {{.Type}}Caller: {{.Type}}Caller{contract: contract}, {{.Type}}Transactor: {{.Type}}Transactor{contract: contract}, {{.Type}}Filterer: {{.Type}}Filterer{contract: contract} }, nil
 		}
+
+		// {{.Type}}Deployment is the outcome of a deployment waited for by Deploy{{.Type}}AndWait.
+		type {{.Type}}Deployment struct {
+		  Address  common.Address // Address of the deployed contract
+		  Receipt  *types.Receipt // Receipt of the deployment transaction
+		  Contract *{{.Type}}     // Binding to the deployed contract
+		  Events   []{{.Type}}Event   // Events emitted by the constructor
+		}
+
+		// Deploy{{.Type}}AndWait deploys a new Ethereum contract, waits for the deployment
+		// to be mined and decodes the receipt.
+		func Deploy{{.Type}}AndWait(ctx context.Context, auth *bind.TransactOpts, backend bind.ContractBackend {{if .Linked}}, libraries map[string]common.Address{{end}} {{range .Constructor.Inputs}}, {{.Name}} {{bindtype .Type $structs}}{{end}}) (*{{.Type}}Deployment, error) {
+		  address, tx, contract, err := Deploy{{.Type}}(auth, backend {{if .Linked}}, libraries{{end}} {{range .Constructor.Inputs}}, {{.Name}}{{end}})
+		  if err != nil {
+		    return nil, err
+		  }
+		  deployment := &{{.Type}}Deployment{Address: address, Contract: contract}
+		  if deployment.Receipt, err = contract.{{.Type}}Transactor.contract.WaitMined(ctx, tx); err != nil {
+		    return nil, err
+		  }
+		  if deployment.Receipt.GetStatus() != common.Success {
+		    return deployment, &bind.TransactionError{Receipt: deployment.Receipt}
+		  }
+		  if deployment.Events, err = parse{{.Type}}ReceiptEvents(contract.{{.Type}}Transactor.contract, deployment.Receipt); err != nil {
+		    return deployment, err
+		  }
+		  return deployment, nil
+		}
 	{{end}}
 
 	// {{.Type}} is an auto generated Go binding around an Ethereum contract.
@@ -348,8 +432,129 @@ var (
 		func (_{{$contract.Type}} *{{$contract.Type}}TransactorSession) {{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if ne $i 0}},{{end}} {{.Name}} {{bindtype .Type $structs}} {{end}}) (*types.RawTransaction, error) {
 		  return _{{$contract.Type}}.Contract.{{.Normalized.Name}}(&_{{$contract.Type}}.TransactOpts {{range $i, $_ := .Normalized.Inputs}}, {{.Name}}{{end}})
 		}
+
+		// {{$contract.Type}}{{.Normalized.Name}}Result is the outcome of a {{.Original.Name}} transaction waited for by {{.Normalized.Name}}AndWait.
+		type {{$contract.Type}}{{.Normalized.Name}}Result struct {
+			Receipt *types.Receipt // Receipt of the mined transaction
+			{{$outputs := .Normalized.Outputs}}{{range $i, $_ := .Normalized.Outputs}}{{resultname $outputs $i}} {{bindtype .Type $structs}}
+			{{end}}Events []{{$contract.Type}}Event // Events emitted by the transaction
+		}
+
+		// {{.Normalized.Name}}AndWait is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}},
+		// it waits for the transaction to be mined and decodes the return values and the events from the receipt.
+		//
+		// Solidity: {{formatmethod .Original $structs}}
+		func (_{{$contract.Type}} *{{$contract.Type}}Transactor) {{.Normalized.Name}}AndWait(ctx context.Context, opts *bind.TransactOpts {{range .Normalized.Inputs}}, {{.Name}} {{bindtype .Type $structs}} {{end}}) (*{{$contract.Type}}{{.Normalized.Name}}Result, error) {
+			result := new({{$contract.Type}}{{.Normalized.Name}}Result)
+			{{$outputs := .Normalized.Outputs}}out := {{if eq (len $outputs) 0}}interface{}(nil){{else if eq (len $outputs) 1}}interface{}(&result.{{resultname $outputs 0}}){{else}}&[]interface{}{
+				{{range $i, $_ := $outputs}}&result.{{resultname $outputs $i}},
+				{{end}}
+			}{{end}}
+			receipt, err := _{{$contract.Type}}.contract.TransactAndWait(ctx, opts, out, "{{.Original.Name}}" {{range .Normalized.Inputs}}, {{.Name}}{{end}})
+			if receipt == nil {
+				return nil, err
+			}
+			result.Receipt = receipt
+			if err != nil {
+				return result, err
+			}
+			if result.Events, err = parse{{$contract.Type}}ReceiptEvents(_{{$contract.Type}}.contract, receipt); err != nil {
+				return result, err
+			}
+			return result, nil
+		}
+
+		// {{.Normalized.Name}}AndWait is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}},
+		// it waits for the transaction to be mined and decodes the return values and the events from the receipt.
+		//
+		// Solidity: {{formatmethod .Original $structs}}
+		func (_{{$contract.Type}} *{{$contract.Type}}Session) {{.Normalized.Name}}AndWait(ctx context.Context {{range .Normalized.Inputs}}, {{.Name}} {{bindtype .Type $structs}} {{end}}) (*{{$contract.Type}}{{.Normalized.Name}}Result, error) {
+		  return _{{$contract.Type}}.Contract.{{.Normalized.Name}}AndWait(ctx, &_{{$contract.Type}}.TransactOpts {{range $i, $_ := .Normalized.Inputs}}, {{.Name}}{{end}})
+		}
+
+		// {{.Normalized.Name}}AndWait is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}},
+		// it waits for the transaction to be mined and decodes the return values and the events from the receipt.
+		//
+		// Solidity: {{formatmethod .Original $structs}}
+		func (_{{$contract.Type}} *{{$contract.Type}}TransactorSession) {{.Normalized.Name}}AndWait(ctx context.Context {{range .Normalized.Inputs}}, {{.Name}} {{bindtype .Type $structs}} {{end}}) (*{{$contract.Type}}{{.Normalized.Name}}Result, error) {
+		  return _{{$contract.Type}}.Contract.{{.Normalized.Name}}AndWait(ctx, &_{{$contract.Type}}.TransactOpts {{range $i, $_ := .Normalized.Inputs}}, {{.Name}}{{end}})
+		}
+
+		{{if .Normalized.Outputs}}
+		// Parse{{.Normalized.Name}}Output decodes the return values of a {{.Original.Name}} transaction
+		// binding the contract method 0x{{printf "%x" .Original.Id}} from its receipt.
+		//
+		// Solidity: {{formatmethod .Original $structs}}
+		func (_{{$contract.Type}} *{{$contract.Type}}Transactor) Parse{{.Normalized.Name}}Output(receipt *types.Receipt) ({{if .Structured}}struct{ {{range .Normalized.Outputs}}{{.Name}} {{bindtype .Type $structs}};{{end}} },{{else}}{{range .Normalized.Outputs}}{{bindtype .Type $structs}},{{end}}{{end}} error) {
+			{{if .Structured}}ret := new(struct{
+				{{range .Normalized.Outputs}}{{.Name}} {{bindtype .Type $structs}}
+				{{end}}
+			}){{else}}var (
+				{{range $i, $_ := .Normalized.Outputs}}ret{{$i}} = new({{bindtype .Type $structs}})
+				{{end}}
+			){{end}}
+			out := {{if .Structured}}ret{{else}}{{if eq (len .Normalized.Outputs) 1}}ret0{{else}}&[]interface{}{
+				{{range $i, $_ := .Normalized.Outputs}}ret{{$i}},
+				{{end}}
+			}{{end}}{{end}}
+			err := _{{$contract.Type}}.contract.UnpackReceiptOutput(out, "{{.Original.Name}}", receipt)
+			return {{if .Structured}}*ret,{{else}}{{range $i, $_ := .Normalized.Outputs}}*ret{{$i}},{{end}}{{end}} err
+		}
+
+		// Parse{{.Normalized.Name}}Output decodes the return values of a {{.Original.Name}} transaction
+		// binding the contract method 0x{{printf "%x" .Original.Id}} from its receipt.
+		//
+		// Solidity: {{formatmethod .Original $structs}}
+		func (_{{$contract.Type}} *{{$contract.Type}}Session) Parse{{.Normalized.Name}}Output(receipt *types.Receipt) ({{if .Structured}}struct{ {{range .Normalized.Outputs}}{{.Name}} {{bindtype .Type $structs}};{{end}} },{{else}}{{range .Normalized.Outputs}}{{bindtype .Type $structs}},{{end}}{{end}} error) {
+		  return _{{$contract.Type}}.Contract.Parse{{.Normalized.Name}}Output(receipt)
+		}
+
+		// Parse{{.Normalized.Name}}Output decodes the return values of a {{.Original.Name}} transaction
+		// binding the contract method 0x{{printf "%x" .Original.Id}} from its receipt.
+		//
+		// Solidity: {{formatmethod .Original $structs}}
+		func (_{{$contract.Type}} *{{$contract.Type}}TransactorSession) Parse{{.Normalized.Name}}Output(receipt *types.Receipt) ({{if .Structured}}struct{ {{range .Normalized.Outputs}}{{.Name}} {{bindtype .Type $structs}};{{end}} },{{else}}{{range .Normalized.Outputs}}{{bindtype .Type $structs}},{{end}}{{end}} error) {
+		  return _{{$contract.Type}}.Contract.Parse{{.Normalized.Name}}Output(receipt)
+		}
+		{{end}}
 	{{end}}
 
+	// {{.Type}}Event is one of the events of the {{.Type}} contract, as returned by ParseReceiptEvents.
+	type {{.Type}}Event interface {
+		is{{.Type}}Event()
+	}
+
+	// ParseReceiptEvents decodes the events the {{.Type}} contract emitted in a transaction from its receipt.
+	// Logs of other contracts and of unknown events are skipped.
+	func (_{{.Type}} *{{.Type}}Filterer) ParseReceiptEvents(receipt *types.Receipt) ([]{{.Type}}Event, error) {
+		return parse{{.Type}}ReceiptEvents(_{{.Type}}.contract, receipt)
+	}
+
+	// parse{{.Type}}ReceiptEvents decodes the events of the {{.Type}} contract found in a receipt.
+	func parse{{.Type}}ReceiptEvents(contract *bind.BoundContract, receipt *types.Receipt) ([]{{.Type}}Event, error) {
+		logs, err := contract.ReceiptLogs(receipt)
+		if err != nil {
+			return nil, err
+		}
+		var events []{{.Type}}Event
+		for _, log := range logs {
+			if len(log.Topics) == 0 {
+				continue
+			}
+			switch log.Topics[0] {
+			{{range .Events}}case common.HexToHash("0x{{printf "%x" .Original.Id}}"):
+				event := new({{$contract.Type}}{{.Normalized.Name}})
+				if err := contract.UnpackLog(event, "{{.Original.Name}}", log); err != nil {
+					return nil, err
+				}
+				event.Raw = log
+				events = append(events, event)
+			{{end}}
+			}
+		}
+		return events, nil
+	}
+
 	{{range .Events}}
 		// {{$contract.Type}}{{.Normalized.Name}}Iterator is returned from Filter{{.Normalized.Name}} and is used to iterate over the raw logs and unpacked data for {{.Normalized.Name}} events raised by the {{$contract.Type}} contract.
 		type {{$contract.Type}}{{.Normalized.Name}}Iterator struct {
@@ -480,121 +685,157 @@ var (
 			}), nil
 		}
 
-		// Parse{{.Normalized.Name}} is a log parse operation binding the contract event 0x{{printf "%x" .Original.Id}}.
+		// Parse{{.Normalized.Name}} is a log parse operation binding the contract event 0x{{printf "%x" .Original.Id}},
+		// decoding a log of a transaction receipt.
 		//
 		// Solidity: {{.Original.String}}
-		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Parse{{.Normalized.Name}}(log types.Log) (*{{$contract.Type}}{{.Normalized.Name}}, error) {
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Parse{{.Normalized.Name}}(log types.NewLog) (*{{$contract.Type}}{{.Normalized.Name}}, error) {
 			event := new({{$contract.Type}}{{.Normalized.Name}})
-			if err := _{{$contract.Type}}.contract.UnpackLog(event, "{{.Original.Name}}", log); err != nil {
+			raw, err := _{{$contract.Type}}.contract.UnpackNewLog(event, "{{.Original.Name}}", &log)
+			if err != nil {
 				return nil, err
 			}
+			event.Raw = raw
 			return event, nil
 		}
 
+		func (*{{$contract.Type}}{{.Normalized.Name}}) is{{$contract.Type}}Event() {}
+
  	{{end}}
 {{end}}
 `
 
 // tmplSourceJava is the Java source template use to generate the contract binding
-// based on.
+// based on. The bindings extend the Contract class of the FISCO BCOS Java SDK.
 const tmplSourceJava = `
 // This file is an automatically generated Java binding. Do not modify as any
 // change will likely be lost upon the next re-generation!
 
 package {{.Package}};
 
-import org.ethereum.geth.*;
-import java.util.*;
+import java.math.BigInteger;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.Collections;
+import java.util.HashMap;
+import java.util.List;
+import java.util.Map;
+import java.util.concurrent.Callable;
+import org.fisco.bcos.channel.client.TransactionSucCallback;
+import org.fisco.bcos.web3j.abi.FunctionEncoder;
+import org.fisco.bcos.web3j.abi.FunctionReturnDecoder;
+import org.fisco.bcos.web3j.abi.TypeReference;
+import org.fisco.bcos.web3j.abi.datatypes.*;
+import org.fisco.bcos.web3j.abi.datatypes.generated.*;
+import org.fisco.bcos.web3j.crypto.Credentials;
+import org.fisco.bcos.web3j.crypto.Hash;
+import org.fisco.bcos.web3j.protocol.Web3j;
+import org.fisco.bcos.web3j.protocol.core.RemoteCall;
+import org.fisco.bcos.web3j.protocol.core.methods.response.Log;
+import org.fisco.bcos.web3j.protocol.core.methods.response.TransactionReceipt;
+import org.fisco.bcos.web3j.tuples.generated.*;
+import org.fisco.bcos.web3j.tx.Contract;
+import org.fisco.bcos.web3j.tx.TransactionManager;
+import org.fisco.bcos.web3j.tx.gas.ContractGasProvider;
+import org.fisco.bcos.web3j.utils.Numeric;
 
 {{range $contract := .Contracts}}
 {{$structs := $contract.Structs}}
-{{if not .Library}}public {{end}}class {{.Type}} {
+{{if not .Library}}public {{end}}class {{.Type}} extends Contract {
+	// BINARY is the compiled bytecode used for deploying new contracts.
+	public static final String BINARY = "{{if .InputBin}}0x{{.InputBin}}{{end}}";
+
 	// ABI is the input ABI used to generate the binding from.
-	public final static String ABI = "{{.InputABI}}";
-	{{if $contract.FuncSigs}}
-		// {{.Type}}FuncSigs maps the 4-byte function signature to its string representation.
-		public final static Map<String, String> {{.Type}}FuncSigs;
-		static {
-			Hashtable<String, String> temp = new Hashtable<String, String>();
-			{{range $strsig, $binsig := .FuncSigs}}temp.put("{{$binsig}}", "{{$strsig}}");
-			{{end}}
-			{{.Type}}FuncSigs = Collections.unmodifiableMap(temp);
-		}
-	{{end}}
-	{{if .InputBin}}
-	// BYTECODE is the compiled bytecode used for deploying new contracts.
-	public final static String BYTECODE = "0x{{.InputBin}}";
-
-	// deploy deploys a new Ethereum contract, binding an instance of {{.Type}} to it.
-	public static {{.Type}} deploy(TransactOpts auth, EthereumClient client{{range .Constructor.Inputs}}, {{bindtype .Type $structs}} {{.Name}}{{end}}) throws Exception {
-		Interfaces args = Geth.newInterfaces({{(len .Constructor.Inputs)}});
-		String bytecode = BYTECODE;
-		{{if .Libraries}}
-
-		// "link" contract to dependent libraries by deploying them first.
-		{{range $pattern, $name := .Libraries}}
-		{{capitalise $name}} {{decapitalise $name}}Inst = {{capitalise $name}}.deploy(auth, client);
-		bytecode = bytecode.replace("__${{$pattern}}$__", {{decapitalise $name}}Inst.Address.getHex().substring(2));
-		{{end}}
-		{{end}}
-		{{range $index, $element := .Constructor.Inputs}}Interface arg{{$index}} = Geth.newInterface();arg{{$index}}.set{{namedtype (bindtype .Type $structs) .Type}}({{.Name}});args.set({{$index}},arg{{$index}});
+	public static final String ABI = "{{.InputABI}}";
+
+	{{if .FuncSigs}}
+	// FUNC_SIGS maps the 4-byte function signature to its string representation.
+	public static final Map<String, String> FUNC_SIGS;
+	static {
+		Map<String, String> sigs = new HashMap<String, String>();
+		{{range $strsig, $binsig := .FuncSigs}}sigs.put("{{$binsig}}", "{{$strsig}}");
 		{{end}}
-		return new {{.Type}}(Geth.deployContract(auth, ABI, Geth.decodeFromHex(bytecode), client, args));
+		FUNC_SIGS = Collections.unmodifiableMap(sigs);
 	}
+	{{end}}
 
-	// Internal constructor used by contract deployment.
-	private {{.Type}}(BoundContract deployment) {
-		this.Address  = deployment.getAddress();
-		this.Deployer = deployment.getDeployer();
-		this.Contract = deployment;
-	}
+	{{range .Events}}
+	public static final Event {{upper .Original.Name}}_EVENT = new Event("{{.Original.Name}}",
+			Arrays.<TypeReference<?>>asList({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{if .Indexed}}new TypeReference<{{abitopictype .Type}}>(true) {}{{else}}new TypeReference<{{abitype .Type}}>() {}{{end}}{{end}}));
 	{{end}}
 
-	// Ethereum address where this contract is located at.
-	public final Address Address;
+	protected {{.Type}}(String contractAddress, Web3j web3j, Credentials credentials, ContractGasProvider contractGasProvider) {
+		super(BINARY, contractAddress, web3j, credentials, contractGasProvider);
+	}
 
-	// Ethereum transaction in which this contract was deployed (if known!).
-	public final Transaction Deployer;
+	protected {{.Type}}(String contractAddress, Web3j web3j, TransactionManager transactionManager, ContractGasProvider contractGasProvider) {
+		super(BINARY, contractAddress, web3j, transactionManager, contractGasProvider);
+	}
 
-	// Contract instance bound to a blockchain address.
-	private final BoundContract Contract;
+	// load binds an instance of {{.Type}} to the contract deployed at the address.
+	public static {{.Type}} load(String contractAddress, Web3j web3j, Credentials credentials, ContractGasProvider contractGasProvider) {
+		return new {{.Type}}(contractAddress, web3j, credentials, contractGasProvider);
+	}
 
-	// Creates a new instance of {{.Type}}, bound to a specific deployed contract.
-	public {{.Type}}(Address address, EthereumClient client) throws Exception {
-		this(Geth.bindContract(address, ABI, client));
+	// load binds an instance of {{.Type}} to the contract deployed at the address.
+	public static {{.Type}} load(String contractAddress, Web3j web3j, TransactionManager transactionManager, ContractGasProvider contractGasProvider) {
+		return new {{.Type}}(contractAddress, web3j, transactionManager, contractGasProvider);
+	}
+	{{if .InputBin}}
+	// deploy deploys a new contract, binding an instance of {{.Type}} to it.{{if .Linked}} The
+	// libraries map the link pattern or fully qualified name of every library the
+	// bytecode references to its address.{{end}}
+	public static RemoteCall<{{.Type}}> deploy(Web3j web3j, Credentials credentials, ContractGasProvider contractGasProvider{{if .Linked}}, Map<String, String> libraries{{end}}{{range .Constructor.Inputs}}, {{bindtype .Type $structs}} {{.Name}}{{end}}) {
+		String encodedConstructor = FunctionEncoder.encodeConstructor(Arrays.<Type>asList({{range $i, $_ := .Constructor.Inputs}}{{if $i}}, {{end}}{{encode .Type .Name}}{{end}}));
+		return deployRemoteCall({{.Type}}.class, web3j, credentials, contractGasProvider, {{if .Linked}}linkBinary(libraries){{else}}BINARY{{end}}, encodedConstructor);
 	}
 
-	{{range .Calls}}
-	{{if gt (len .Normalized.Outputs) 1}}
-	// {{capitalise .Normalized.Name}}Results is the output of a call to {{.Normalized.Name}}.
-	public class {{capitalise .Normalized.Name}}Results {
-		{{range $index, $item := .Normalized.Outputs}}public {{bindtype .Type $structs}} {{if ne .Name ""}}{{.Name}}{{else}}Return{{$index}}{{end}};
-		{{end}}
+	// deploy deploys a new contract, binding an instance of {{.Type}} to it.{{if .Linked}} The
+	// libraries map the link pattern or fully qualified name of every library the
+	// bytecode references to its address.{{end}}
+	public static RemoteCall<{{.Type}}> deploy(Web3j web3j, TransactionManager transactionManager, ContractGasProvider contractGasProvider{{if .Linked}}, Map<String, String> libraries{{end}}{{range .Constructor.Inputs}}, {{bindtype .Type $structs}} {{.Name}}{{end}}) {
+		String encodedConstructor = FunctionEncoder.encodeConstructor(Arrays.<Type>asList({{range $i, $_ := .Constructor.Inputs}}{{if $i}}, {{end}}{{encode .Type .Name}}{{end}}));
+		return deployRemoteCall({{.Type}}.class, web3j, transactionManager, contractGasProvider, {{if .Linked}}linkBinary(libraries){{else}}BINARY{{end}}, encodedConstructor);
+	}
+	{{if .Linked}}
+	// linkBinary links the bytecode against the libraries, keyed by their link
+	// pattern or fully qualified name.
+	public static String linkBinary(Map<String, String> libraries) {
+		String binary = BINARY;
+		for (Map.Entry<String, String> library : libraries.entrySet()) {
+			String pattern = library.getKey();
+			if (pattern.length() != 34 || !pattern.matches("[0-9a-fA-F]+")) {
+				pattern = Hash.sha3String(pattern).substring(2, 36);
+			}
+			binary = binary.replace("__$" + pattern + "$__", Numeric.cleanHexPrefix(library.getValue()));
+		}
+		if (binary.contains("__")) {
+			throw new IllegalArgumentException("unresolved library link references in the bytecode of {{.Type}}");
+		}
+		return binary;
 	}
 	{{end}}
+	{{end}}
 
+	{{range .Calls}}
 	// {{.Normalized.Name}} is a free data retrieval call binding the contract method 0x{{printf "%x" .Original.Id}}.
 	//
-	// Solidity: {{.Original.String}}
-	public {{if gt (len .Normalized.Outputs) 1}}{{capitalise .Normalized.Name}}Results{{else}}{{range .Normalized.Outputs}}{{bindtype .Type $structs}}{{end}}{{end}} {{.Normalized.Name}}(CallOpts opts{{range .Normalized.Inputs}}, {{bindtype .Type $structs}} {{.Name}}{{end}}) throws Exception {
-		Interfaces args = Geth.newInterfaces({{(len .Normalized.Inputs)}});
-		{{range $index, $item := .Normalized.Inputs}}Interface arg{{$index}} = Geth.newInterface();arg{{$index}}.set{{namedtype (bindtype .Type $structs) .Type}}({{.Name}});args.set({{$index}},arg{{$index}});
-		{{end}}
-
-		Interfaces results = Geth.newInterfaces({{(len .Normalized.Outputs)}});
-		{{range $index, $item := .Normalized.Outputs}}Interface result{{$index}} = Geth.newInterface(); result{{$index}}.setDefault{{namedtype (bindtype .Type $structs) .Type}}(); results.set({{$index}}, result{{$index}});
-		{{end}}
-
-		if (opts == null) {
-			opts = Geth.newCallOpts();
-		}
-		this.Contract.call(opts, results, "{{.Original.Name}}", args);
-		{{if gt (len .Normalized.Outputs) 1}}
-			{{capitalise .Normalized.Name}}Results result = new {{capitalise .Normalized.Name}}Results();
-			{{range $index, $item := .Normalized.Outputs}}result.{{if ne .Name ""}}{{.Name}}{{else}}Return{{$index}}{{end}} = results.get({{$index}}).get{{namedtype (bindtype .Type $structs) .Type}}();
-			{{end}}
-			return result;
-		{{else}}{{range .Normalized.Outputs}}return results.get(0).get{{namedtype (bindtype .Type $structs) .Type}}();{{end}}
+	// Solidity: {{formatmethod .Original $structs}}
+	public RemoteCall<{{if eq (len .Normalized.Outputs) 0}}List<Type>{{else if eq (len .Normalized.Outputs) 1}}{{bindtype (index .Normalized.Outputs 0).Type $structs}}{{else}}{{tupletype .Normalized.Outputs}}{{end}}> {{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{bindtype .Type $structs}} {{.Name}}{{end}}) {
+		final Function function = new Function("{{.Original.Name}}",
+				Arrays.<Type>asList({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{encode .Type .Name}}{{end}}),
+				Arrays.<TypeReference<?>>asList({{range $i, $_ := .Normalized.Outputs}}{{if $i}}, {{end}}new TypeReference<{{abitype .Type}}>() {}{{end}}));
+		{{if eq (len .Normalized.Outputs) 0}}return executeRemoteCallMultipleValueReturn(function);
+		{{else if and (eq (len .Normalized.Outputs) 1) (not (isarray (index .Normalized.Outputs 0).Type))}}return executeRemoteCallSingleValueReturn(function, {{bindtype (index .Normalized.Outputs 0).Type $structs}}.class);
+		{{else}}{{$ret := tupletype .Normalized.Outputs}}{{if eq (len .Normalized.Outputs) 1}}{{$ret = bindtype (index .Normalized.Outputs 0).Type $structs}}{{end}}return new RemoteCall<{{$ret}}>(
+				new Callable<{{$ret}}>() {
+					@Override
+					@SuppressWarnings("unchecked")
+					public {{$ret}} call() throws Exception {
+						List<Type> results = executeCallMultipleValueReturn(function);
+						return {{if eq (len .Normalized.Outputs) 1}}{{decode (index .Normalized.Outputs 0).Type "results.get(0)"}}{{else}}{{tupledecode .Normalized.Outputs}}{{end}};
+					}
+				});
 		{{end}}
 	}
 	{{end}}
@@ -602,14 +843,170 @@ import java.util.*;
 	{{range .Transacts}}
 	// {{.Normalized.Name}} is a paid mutator transaction binding the contract method 0x{{printf "%x" .Original.Id}}.
 	//
-	// Solidity: {{.Original.String}}
-	public Transaction {{.Normalized.Name}}(TransactOpts opts{{range .Normalized.Inputs}}, {{bindtype .Type $structs}} {{.Name}}{{end}}) throws Exception {
-		Interfaces args = Geth.newInterfaces({{(len .Normalized.Inputs)}});
-		{{range $index, $item := .Normalized.Inputs}}Interface arg{{$index}} = Geth.newInterface();arg{{$index}}.set{{namedtype (bindtype .Type $structs) .Type}}({{.Name}});args.set({{$index}},arg{{$index}});
+	// Solidity: {{formatmethod .Original $structs}}
+	public RemoteCall<TransactionReceipt> {{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{bindtype .Type $structs}} {{.Name}}{{end}}) {
+		final Function function = new Function("{{.Original.Name}}",
+				Arrays.<Type>asList({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{encode .Type .Name}}{{end}}),
+				Collections.<TypeReference<?>>emptyList());
+		return executeRemoteCallTransaction(function);
+	}
+
+	// {{.Normalized.Name}} sends the transaction binding the contract method 0x{{printf "%x" .Original.Id}}
+	// asynchronously, invoking the callback with its receipt once it is executed.
+	public void {{.Normalized.Name}}({{range .Normalized.Inputs}}{{bindtype .Type $structs}} {{.Name}}, {{end}}TransactionSucCallback callback) {
+		final Function function = new Function("{{.Original.Name}}",
+				Arrays.<Type>asList({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{encode .Type .Name}}{{end}}),
+				Collections.<TypeReference<?>>emptyList());
+		asyncExecuteTransaction(function, callback);
+	}
+	{{if .Normalized.Outputs}}
+	// get{{capitalise .Normalized.Name}}Output decodes the return values of the {{.Original.Name}} transaction
+	// from its receipt.
+	@SuppressWarnings("unchecked")
+	public {{tupletype .Normalized.Outputs}} get{{capitalise .Normalized.Name}}Output(TransactionReceipt transactionReceipt) {
+		final Function function = new Function("{{.Original.Name}}",
+				Arrays.<Type>asList(),
+				Arrays.<TypeReference<?>>asList({{range $i, $_ := .Normalized.Outputs}}{{if $i}}, {{end}}new TypeReference<{{abitype .Type}}>() {}{{end}}));
+		List<Type> results = FunctionReturnDecoder.decode(transactionReceipt.getOutput(), function.getOutputParameters());
+		return {{tupledecode .Normalized.Outputs}};
+	}
+	{{end}}
+	{{end}}
+
+	{{range .Events}}
+	// {{capitalise .Normalized.Name}}EventResponse represents a {{.Original.Name}} event raised by the {{$contract.Type}} contract.
+	public static class {{capitalise .Normalized.Name}}EventResponse {
+		public Log log;
+		{{range $i, $_ := .Normalized.Inputs}}
+		public {{if .Indexed}}{{bindtopictype .Type $structs}}{{else}}{{bindtype .Type $structs}}{{end}} {{fieldname .Name $i}};
 		{{end}}
-		return this.Contract.transact(opts, "{{.Original.Name}}"	, args);
+	}
+
+	// get{{capitalise .Normalized.Name}}Events decodes the {{.Original.Name}} events emitted by the transaction
+	// from its receipt.
+	//
+	// Solidity: {{formatevent .Original $structs}}
+	@SuppressWarnings("unchecked")
+	public List<{{capitalise .Normalized.Name}}EventResponse> get{{capitalise .Normalized.Name}}Events(TransactionReceipt transactionReceipt) {
+		List<Contract.EventValuesWithLog> valueList = extractEventParametersWithLog({{upper .Original.Name}}_EVENT, transactionReceipt);
+		ArrayList<{{capitalise .Normalized.Name}}EventResponse> responses = new ArrayList<{{capitalise .Normalized.Name}}EventResponse>(valueList.size());
+		for (Contract.EventValuesWithLog eventValues : valueList) {
+			{{capitalise .Normalized.Name}}EventResponse typedResponse = new {{capitalise .Normalized.Name}}EventResponse();
+			typedResponse.log = eventValues.getLog();
+			{{$indexed := 0}}{{$data := 0}}{{range $i, $_ := .Normalized.Inputs}}{{if .Indexed}}typedResponse.{{fieldname .Name $i}} = {{if eq (abitopictype .Type) "Bytes32"}}(byte[]) eventValues.getIndexedValues().get({{$indexed}}).getValue(){{else}}{{decode .Type (printf "eventValues.getIndexedValues().get(%d)" $indexed)}}{{end}};{{$indexed = inc $indexed}}{{else}}typedResponse.{{fieldname .Name $i}} = {{decode .Type (printf "eventValues.getNonIndexedValues().get(%d)" $data)}};{{$data = inc $data}}{{end}}
+			{{end}}responses.add(typedResponse);
+		}
+		return responses;
 	}
 	{{end}}
 }
 {{end}}
 `
+
+// tmplSourceTypeScript is the TypeScript source template used to generate the
+// typed declarations of the contracts.
+const tmplSourceTypeScript = `// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+// Address is a hex encoded 20 byte account address.
+export type Address = string;
+
+// BytesLike is a hex encoded byte string.
+export type BytesLike = string;
+
+// Hash is a hex encoded 32 byte hash.
+export type Hash = string;
+
+// Log is an event log of a FISCO BCOS transaction receipt.
+export interface Log {
+	logIndex: string;
+	transactionIndex: string;
+	transactionHash: Hash;
+	blockHash: Hash;
+	blockNumber: string;
+	address: Address;
+	data: BytesLike;
+	type: string;
+	topics: Hash[];
+}
+
+// TransactionReceipt is the receipt of a FISCO BCOS transaction.
+export interface TransactionReceipt {
+	transactionHash: Hash;
+	transactionIndex: string;
+	blockHash: Hash;
+	blockNumber: string;
+	gasUsed: string;
+	contractAddress: Address;
+	root: Hash;
+	status: string;
+	from: Address;
+	to: Address;
+	input: BytesLike;
+	output: BytesLike;
+	logs: Log[];
+	logsBloom: BytesLike;
+}
+{{range $contract := .Contracts}}
+{{$structs := $contract.Structs}}
+// {{.Type}}ABI is the input ABI used to generate the binding from.
+export const {{.Type}}ABI = "{{.InputABI}}";
+{{if .InputBin}}
+// {{.Type}}Bin is the compiled bytecode used for deploying new contracts.
+export const {{.Type}}Bin = "0x{{.InputBin}}";
+{{end}}{{if .FuncSigs}}
+// {{.Type}}FuncSigs maps the 4-byte function signature to its string representation.
+export const {{.Type}}FuncSigs: { readonly [sig: string]: string } = {
+	{{range $strsig, $binsig := .FuncSigs}}"{{$binsig}}": "{{$strsig}}",
+	{{end}}
+};
+{{end}}{{range $structs}}
+// {{.Name}} is an auto generated low-level TypeScript interface around an user-defined struct.
+export interface {{.Name}} {
+	{{range $field := .Fields}}{{$field.Name}}: {{$field.Type}};
+	{{end}}
+}
+{{end}}
+// {{.Type}}Caller declares the read-only methods of the {{.Type}} contract.
+export interface {{.Type}}Caller {
+	{{range .Calls}}// Solidity: {{formatmethod .Original $structs}}
+	{{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{.Name}}: {{bindtype .Type $structs}}{{end}}): Promise<{{if .Structured}}{ {{range .Normalized.Outputs}}{{decapitalise .Name}}: {{bindtype .Type $structs}}; {{end}}}{{else if eq (len .Normalized.Outputs) 0}}void{{else if eq (len .Normalized.Outputs) 1}}{{bindtype (index .Normalized.Outputs 0).Type $structs}}{{else}}[{{range $i, $_ := .Normalized.Outputs}}{{if $i}}, {{end}}{{bindtype .Type $structs}}{{end}}]{{end}}>;
+	{{end}}
+}
+
+// {{.Type}}Transactor declares the state changing methods of the {{.Type}} contract,
+// resolving to the receipts of their transactions.
+export interface {{.Type}}Transactor {
+	{{range .Transacts}}// Solidity: {{formatmethod .Original $structs}}
+	{{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if $i}}, {{end}}{{.Name}}: {{bindtype .Type $structs}}{{end}}): Promise<TransactionReceipt>;
+	{{end}}
+}
+{{range .Transacts}}{{if .Normalized.Outputs}}
+// {{$contract.Type}}{{capitalise .Normalized.Name}}Output is the return value of the {{.Original.Name}} transaction,
+// decoded from the output of its receipt.
+export type {{$contract.Type}}{{capitalise .Normalized.Name}}Output = {{if .Structured}}{ {{range .Normalized.Outputs}}{{decapitalise .Name}}: {{bindtype .Type $structs}}; {{end}}}{{else if eq (len .Normalized.Outputs) 1}}{{bindtype (index .Normalized.Outputs 0).Type $structs}}{{else}}[{{range $i, $_ := .Normalized.Outputs}}{{if $i}}, {{end}}{{bindtype .Type $structs}}{{end}}]{{end}};
+{{end}}{{end}}
+// {{.Type}} is the typed interface of a deployed {{.Type}} contract.
+export interface {{.Type}} extends {{.Type}}Caller, {{.Type}}Transactor {
+	readonly address: Address;
+}
+{{range .Events}}
+// {{$contract.Type}}{{capitalise .Normalized.Name}}Event represents a {{.Original.Name}} event raised by the {{$contract.Type}} contract.
+//
+// Solidity: {{formatevent .Original $structs}}
+export interface {{$contract.Type}}{{capitalise .Normalized.Name}}Event {
+	event: "{{.Original.Name}}";
+	{{range $i, $_ := .Normalized.Inputs}}{{fieldname .Name $i}}: {{if .Indexed}}{{bindtopictype .Type $structs}}{{else}}{{bindtype .Type $structs}}{{end}};
+	{{end}}log: Log;
+}
+{{end}}
+// {{.Type}}Event is any of the events raised by the {{.Type}} contract.
+export type {{.Type}}Event = {{range .Events}}{{$contract.Type}}{{capitalise .Normalized.Name}}Event | {{end}}never;
+
+// {{.Type}}EventTopics maps the event names to the topic identifying their logs.
+export const {{.Type}}EventTopics = {
+	{{range .Events}}{{.Original.Name}}: "{{.Original.Id.Hex}}",
+	{{end}}
+};
+{{end}}
+`
@@ -23,6 +23,7 @@ import (
 
 	"github.com/KasperLiu/gobcos/common"
 	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/log"
 )
 
 // WaitMined waits for tx to be mined on the blockchain.
@@ -31,17 +32,22 @@ func WaitMined(ctx context.Context, b DeployBackend, tx *types.RawTransaction) (
 	queryTicker := time.NewTicker(time.Second)
 	defer queryTicker.Stop()
 
-	// logger := log.New("hash", tx.Hash())
+	logger := log.New("hash", tx.Hash())
 	for {
-		receipt, _ := b.TransactionReceipt(ctx, tx.Hash())
+		receipt, err := b.TransactionReceipt(ctx, tx.Hash())
 		if receipt != nil {
+			if status := receipt.GetStatus(); status != common.Success {
+				logger.Warn("Transaction failed", "block", receipt.GetBlockNumber(), "status", common.GetStatusMessage(status))
+			} else {
+				logger.Debug("Transaction mined", "block", receipt.GetBlockNumber())
+			}
 			return receipt, nil
 		}
-		// if err != nil {
-		// 	logger.Trace("Receipt retrieval failed", "err", err)
-		// } else {
-		// 	logger.Trace("Transaction not yet mined")
-		// }
+		if err != nil {
+			logger.Trace("Receipt retrieval failed", "err", err)
+		} else {
+			logger.Trace("Transaction not yet mined")
+		}
 		// Wait for the next round.
 		select {
 		case <-ctx.Done():
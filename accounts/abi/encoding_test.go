@@ -0,0 +1,370 @@
+package abi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/common"
+)
+
+// encodingTests are ABIEncoderV2 vectors, each a list of arguments along with
+// their values, in the format of ParseArgument, and their encoding. The first two
+// are the examples of the Solidity ABI specification. The others were derived by
+// hand from the specification and have not been checked against the output of
+// solc yet, so both TestPackV2 and TestUnpackV2 would agree with a misreading of
+// it; they should be regenerated with abi.encode of solc 0.5 or later.
+var encodingTests = []struct {
+	def     string
+	values  []string
+	encoded string
+}{
+	// the first example of the Solidity ABI specification
+	{
+		`[{"name":"a","type":"uint256"},{"name":"b","type":"uint32[]"},{"name":"c","type":"bytes10"},{"name":"d","type":"bytes"}]`,
+		[]string{`0x123`, `[1110,1929]`, `0x31323334353637383930`, `0x48656c6c6f2c20776f726c6421`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000123" +
+			"0000000000000000000000000000000000000000000000000000000000000080" +
+			"3132333435363738393000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000e0" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000456" +
+			"0000000000000000000000000000000000000000000000000000000000000789" +
+			"000000000000000000000000000000000000000000000000000000000000000d" +
+			"48656c6c6f2c20776f726c642100000000000000000000000000000000000000",
+	},
+	// the second example of the Solidity ABI specification, nested dynamic arrays
+	{
+		`[{"name":"a","type":"uint256[][]"},{"name":"b","type":"string[]"}]`,
+		[]string{`[[1,2],[3]]`, `["one","two","three"]`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000140" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"00000000000000000000000000000000000000000000000000000000000000a0" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000000000000000000060" +
+			"00000000000000000000000000000000000000000000000000000000000000a0" +
+			"00000000000000000000000000000000000000000000000000000000000000e0" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"6f6e650000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"74776f0000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000005" +
+			"7468726565000000000000000000000000000000000000000000000000000000",
+	},
+	// dynamic tuples in a dynamic array
+	{
+		`[{"name":"a","type":"tuple[]","components":[{"name":"a","type":"uint256"},{"name":"b","type":"string"}]}]`,
+		[]string{`[{"a":1,"b":"one"},{"a":2,"b":"two"}]`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"00000000000000000000000000000000000000000000000000000000000000c0" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"6f6e650000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"74776f0000000000000000000000000000000000000000000000000000000000",
+	},
+	// static tuples in a fixed array, followed by another argument
+	{
+		`[{"name":"a","type":"tuple[2]","components":[{"name":"a","type":"uint256"},{"name":"b","type":"uint256"}]},{"name":"b","type":"uint256"}]`,
+		[]string{`[[1,2],[3,4]]`, `5`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000000000000000000004" +
+			"0000000000000000000000000000000000000000000000000000000000000005",
+	},
+	// static tuples in a dynamic array
+	{
+		`[{"name":"a","type":"tuple[]","components":[{"name":"a","type":"uint256"},{"name":"b","type":"bool"}]}]`,
+		[]string{`[[1,true],[2,false]]`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+	},
+	// a tuple with string, bytes and array members
+	{
+		`[{"name":"a","type":"tuple","components":[{"name":"s","type":"string"},{"name":"b","type":"bytes"},{"name":"c","type":"uint8[]"}]},{"name":"b","type":"uint256"}]`,
+		[]string{`{"s":"hi","b":"0x0102","c":[7,8]}`, `9`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000009" +
+			"0000000000000000000000000000000000000000000000000000000000000060" +
+			"00000000000000000000000000000000000000000000000000000000000000a0" +
+			"00000000000000000000000000000000000000000000000000000000000000e0" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"6869000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0102000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000007" +
+			"0000000000000000000000000000000000000000000000000000000000000008",
+	},
+	// a fixed array of a dynamic type
+	{
+		`[{"name":"a","type":"string[2]"},{"name":"b","type":"bytes"}]`,
+		[]string{`["a","bc"]`, `0xff`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000100" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000080" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"6100000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"6263000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"ff00000000000000000000000000000000000000000000000000000000000000",
+	},
+	// a dynamic array of fixed arrays of a dynamic type
+	{
+		`[{"name":"a","type":"bytes[2][]"}]`,
+		[]string{`[["0x01","0x0203"],["0x","0x04"]]`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000100" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000080" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0100000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0203000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000060" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0400000000000000000000000000000000000000000000000000000000000000",
+	},
+	// a tuple holding a dynamic array of dynamic tuples
+	{
+		`[{"name":"a","type":"tuple","components":[{"name":"inner","type":"tuple[]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"string"}]},{"name":"ok","type":"bool"}]}]`,
+		[]string{`{"inner":[{"x":1,"y":"a"},{"x":2,"y":"b"}],"ok":true}`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"00000000000000000000000000000000000000000000000000000000000000c0" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"6100000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"6200000000000000000000000000000000000000000000000000000000000000",
+	},
+	// a dynamic array of static arrays
+	{
+		`[{"name":"a","type":"uint256[2][]"},{"name":"b","type":"int8"}]`,
+		[]string{`[[1,2],[3,4]]`, `-1`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000000000000000000004",
+	},
+	// an empty dynamic array of tuples
+	{
+		`[{"name":"a","type":"tuple[]","components":[{"name":"a","type":"uint256"},{"name":"b","type":"string"}]}]`,
+		[]string{`[]`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+	},
+	// a fixed array of dynamic tuples holding fixed arrays of static tuples
+	{
+		`[{"name":"a","type":"tuple[2]","components":[{"name":"owner","type":"address"},{"name":"items","type":"tuple[2]","components":[{"name":"id","type":"uint64"},{"name":"tag","type":"bytes32"}]},{"name":"note","type":"string"}]}]`,
+		[]string{`[{"owner":"0x1111111111111111111111111111111111111111","items":[[1,"0xaa"],[2,"0xbb"]],"note":"x"},{"owner":"0x1111111111111111111111111111111111111111","items":[[3,"0xcc"],[4,"0xdd"]],"note":"yz"}]`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000140" +
+			"0000000000000000000000001111111111111111111111111111111111111111" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"aa00000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"bb00000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000c0" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"7800000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000001111111111111111111111111111111111111111" +
+			"0000000000000000000000000000000000000000000000000000000000000003" +
+			"cc00000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000004" +
+			"dd00000000000000000000000000000000000000000000000000000000000000" +
+			"00000000000000000000000000000000000000000000000000000000000000c0" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"797a000000000000000000000000000000000000000000000000000000000000",
+	},
+	// an empty dynamic array of static arrays
+	{
+		`[{"name":"a","type":"uint256[2][]"}]`,
+		[]string{`[]`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+	},
+	// a fixed array of dynamic arrays
+	{
+		`[{"name":"a","type":"uint256[][2]"}]`,
+		[]string{`[[1],[2,3]]`},
+		"" +
+			"0000000000000000000000000000000000000000000000000000000000000020" +
+			"0000000000000000000000000000000000000000000000000000000000000040" +
+			"0000000000000000000000000000000000000000000000000000000000000080" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000000000000000003",
+	},
+}
+
+// parseEncodingTest returns the arguments and the values of an encoding test.
+func parseEncodingTest(t *testing.T, i int, def string, inputs []string) (Arguments, []interface{}) {
+	var args Arguments
+	if err := json.Unmarshal([]byte(def), &args); err != nil {
+		t.Fatalf("test %d: invalid arguments: %v", i, err)
+	}
+	values := make([]interface{}, len(inputs))
+	for j, input := range inputs {
+		value, err := ParseArgument(args[j].Type, input)
+		if err != nil {
+			t.Fatalf("test %d: invalid value of argument %d: %v", i, j, err)
+		}
+		values[j] = value
+	}
+	return args, values
+}
+
+func TestPackV2(t *testing.T) {
+	for i, tt := range encodingTests {
+		args, values := parseEncodingTest(t, i, tt.def, tt.values)
+		packed, err := args.Pack(values...)
+		if err != nil {
+			t.Errorf("test %d: failed to pack: %v", i, err)
+			continue
+		}
+		if have := hex.EncodeToString(packed); have != tt.encoded {
+			t.Errorf("test %d: encoding mismatch:\nhave %s\nwant %s", i, have, tt.encoded)
+		}
+	}
+}
+
+func TestUnpackV2(t *testing.T) {
+	for i, tt := range encodingTests {
+		args, values := parseEncodingTest(t, i, tt.def, tt.values)
+		unpacked, err := args.UnpackValues(common.Hex2Bytes(tt.encoded))
+		if err != nil {
+			t.Errorf("test %d: failed to unpack: %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(unpacked, values) {
+			t.Errorf("test %d: value mismatch:\nhave %v\nwant %v", i, unpacked, values)
+		}
+	}
+}
+
+// Tests that arrays of structs and nested tuples returned from calls unpack
+// into user defined Go types.
+func TestUnpackV2IntoStructs(t *testing.T) {
+	type item struct {
+		A *big.Int
+		B string
+	}
+	var items []item
+	args, _ := parseEncodingTest(t, 2, encodingTests[2].def, encodingTests[2].values)
+	if err := args.Unpack(&items, common.Hex2Bytes(encodingTests[2].encoded)); err != nil {
+		t.Fatalf("failed to unpack tuple[]: %v", err)
+	}
+	if want := []item{{big.NewInt(1), "one"}, {big.NewInt(2), "two"}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("tuple[] mismatch: have %v, want %v", items, want)
+	}
+
+	var nested struct {
+		Inner []struct {
+			X *big.Int
+			Y string
+		}
+		Ok bool
+	}
+	args, _ = parseEncodingTest(t, 8, encodingTests[8].def, encodingTests[8].values)
+	if err := args.Unpack(&nested, common.Hex2Bytes(encodingTests[8].encoded)); err != nil {
+		t.Fatalf("failed to unpack nested tuple: %v", err)
+	}
+	if len(nested.Inner) != 2 || nested.Inner[1].X.Int64() != 2 || nested.Inner[1].Y != "b" || !nested.Ok {
+		t.Errorf("nested tuple mismatch: have %+v", nested)
+	}
+
+	var multi struct {
+		A [2]struct {
+			A *big.Int
+			B *big.Int
+		}
+		B *big.Int
+	}
+	args, _ = parseEncodingTest(t, 3, encodingTests[3].def, encodingTests[3].values)
+	if err := args.Unpack(&multi, common.Hex2Bytes(encodingTests[3].encoded)); err != nil {
+		t.Fatalf("failed to unpack static tuples: %v", err)
+	}
+	if multi.A[1].B.Int64() != 4 || multi.B.Int64() != 5 {
+		t.Errorf("static tuples mismatch: have %+v", multi)
+	}
+}
+
+// Tests that malformed encodings are reported as errors instead of crashing.
+func TestUnpackV2Malformed(t *testing.T) {
+	for i, tt := range encodingTests {
+		args, _ := parseEncodingTest(t, i, tt.def, tt.values)
+		encoded := common.Hex2Bytes(tt.encoded)
+		for _, size := range []int{len(encoded) - 32, len(encoded) / 2, 31} {
+			if size < 0 || size >= len(encoded) {
+				continue
+			}
+			if _, err := args.UnpackValues(encoded[:size]); err == nil && strings.Contains(tt.def, "[]") {
+				t.Errorf("test %d: no error unpacking %d of %d bytes", i, size, len(encoded))
+			}
+		}
+		// Offsets pointing beyond the data, the first word is only an offset if
+		// the first argument is dynamic
+		if !isDynamicType(args[0].Type) {
+			continue
+		}
+		corrupt := append([]byte{}, encoded...)
+		for j := 0; j < 32; j++ {
+			corrupt[j] = 0xff
+		}
+		if _, err := args.UnpackValues(corrupt); err == nil {
+			t.Errorf("test %d: no error unpacking a corrupt offset", i)
+		}
+	}
+}
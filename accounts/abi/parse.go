@@ -0,0 +1,384 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+)
+
+// ParseArgument converts user input to the Go value of typ that Pack expects.
+//
+// Elementary values are given as plain text: integers in decimal or 0x prefixed
+// hex, addresses and byte strings in hex, booleans as true or false and strings
+// as is. A bytesN value shorter than N bytes is padded on the right. Arrays are
+// given as JSON arrays, e.g. [1,2,3], and tuples as JSON objects keyed by their
+// field names, e.g. {"a":1,"b":"x"}, or as JSON arrays of their fields. Within
+// JSON, elementary values may be JSON strings or numbers and booleans.
+//
+// Errors name the offending element of arrays and tuples, e.g. b[1].
+func ParseArgument(typ Type, input string) (interface{}, error) {
+	value, err := parseValue(typ, input, "")
+	if err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+// parseError returns the error of parsing the element at path of an argument.
+func parseError(path string, format string, args ...interface{}) error {
+	if path == "" {
+		return fmt.Errorf(format, args...)
+	}
+	return fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...))
+}
+
+// parseValue converts the plain text or decoded JSON value v to the Go type of
+// typ. The path locates the value within the argument for error reporting.
+func parseValue(typ Type, v interface{}, path string) (reflect.Value, error) {
+	switch typ.T {
+	case IntTy, UintTy:
+		n, ok := parseInteger(v)
+		if !ok {
+			return reflect.Value{}, parseError(path, "invalid %s %s", typ, formatInput(v))
+		}
+		if typ.T == UintTy && (n.Sign() < 0 || n.BitLen() > typ.Size) ||
+			typ.T == IntTy && n.Sign() >= 0 && n.BitLen() > typ.Size-1 ||
+			typ.T == IntTy && n.Sign() < 0 && new(big.Int).Add(n, common.Big1).BitLen() > typ.Size-1 {
+			return reflect.Value{}, parseError(path, "%s out of range for %s", n, typ)
+		}
+		if typ.Kind == reflect.Ptr {
+			return reflect.ValueOf(n), nil
+		}
+		if typ.T == UintTy {
+			return reflect.ValueOf(n.Uint64()).Convert(typ.Type), nil
+		}
+		return reflect.ValueOf(n.Int64()).Convert(typ.Type), nil
+
+	case BoolTy:
+		switch v := v.(type) {
+		case bool:
+			return reflect.ValueOf(v), nil
+		case string:
+			switch strings.TrimSpace(v) {
+			case "true":
+				return reflect.ValueOf(true), nil
+			case "false":
+				return reflect.ValueOf(false), nil
+			}
+		}
+		return reflect.Value{}, parseError(path, "invalid bool %s", formatInput(v))
+
+	case StringTy:
+		if s, ok := v.(string); ok {
+			return reflect.ValueOf(s), nil
+		}
+		return reflect.Value{}, parseError(path, "invalid string %s", formatInput(v))
+
+	case AddressTy:
+		if s, ok := v.(string); ok && common.IsHexAddress(strings.TrimSpace(s)) {
+			return reflect.ValueOf(common.HexToAddress(strings.TrimSpace(s))), nil
+		}
+		return reflect.Value{}, parseError(path, "invalid address %s", formatInput(v))
+
+	case BytesTy, FixedBytesTy, FunctionTy:
+		s, ok := v.(string)
+		if !ok {
+			return reflect.Value{}, parseError(path, "invalid %s %s", typ, formatInput(v))
+		}
+		s = strings.TrimSpace(s)
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			s = s[2:]
+		}
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return reflect.Value{}, parseError(path, "invalid %s %s: not hex encoded", typ, formatInput(v))
+		}
+		if typ.T == BytesTy {
+			return reflect.ValueOf(b), nil
+		}
+		if len(b) > typ.Size || typ.T == FunctionTy && len(b) != typ.Size {
+			return reflect.Value{}, parseError(path, "invalid %s %s: %d bytes long", typ, formatInput(v), len(b))
+		}
+		array := reflect.New(typ.Type).Elem()
+		reflect.Copy(array, reflect.ValueOf(b))
+		return array, nil
+
+	case SliceTy, ArrayTy:
+		elems, err := parseList(v, path)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		var list reflect.Value
+		if typ.T == SliceTy {
+			list = reflect.MakeSlice(typ.Type, len(elems), len(elems))
+		} else {
+			if len(elems) != typ.Size {
+				return reflect.Value{}, parseError(path, "%s requires %d elements, got %d", typ, typ.Size, len(elems))
+			}
+			list = reflect.New(typ.Type).Elem()
+		}
+		for i, elem := range elems {
+			value, err := parseValue(*typ.Elem, elem, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			list.Index(i).Set(value)
+		}
+		return list, nil
+
+	case TupleTy:
+		if s, ok := v.(string); ok {
+			var err error
+			if v, err = decodeInput(s, path); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+		fieldPath := func(i int) string {
+			if path == "" {
+				return typ.TupleRawNames[i]
+			}
+			return path + "." + typ.TupleRawNames[i]
+		}
+		tuple := reflect.New(typ.Type).Elem()
+		switch fields := v.(type) {
+		case map[string]interface{}:
+			for name := range fields {
+				if !containsString(typ.TupleRawNames, name) {
+					return reflect.Value{}, parseError(path, "unknown field %s of %s", name, typ)
+				}
+			}
+			for i, name := range typ.TupleRawNames {
+				field, ok := fields[name]
+				if !ok {
+					return reflect.Value{}, parseError(path, "missing field %s of %s", name, typ)
+				}
+				value, err := parseValue(*typ.TupleElems[i], field, fieldPath(i))
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				tuple.Field(i).Set(value)
+			}
+		case []interface{}:
+			if len(fields) != len(typ.TupleElems) {
+				return reflect.Value{}, parseError(path, "%s requires %d fields, got %d", typ, len(typ.TupleElems), len(fields))
+			}
+			for i, field := range fields {
+				value, err := parseValue(*typ.TupleElems[i], field, fieldPath(i))
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				tuple.Field(i).Set(value)
+			}
+		default:
+			return reflect.Value{}, parseError(path, "invalid %s %s", typ, formatInput(v))
+		}
+		return tuple, nil
+	}
+	return reflect.Value{}, parseError(path, "unsupported type %s", typ)
+}
+
+// parseInteger converts a decimal or hex string or a JSON number to a big integer.
+func parseInteger(v interface{}) (*big.Int, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = strings.TrimSpace(v)
+	case json.Number:
+		s = v.String()
+	default:
+		return nil, false
+	}
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	var (
+		n  *big.Int
+		ok bool
+	)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		n, ok = new(big.Int).SetString(s[2:], 16)
+	} else {
+		n, ok = new(big.Int).SetString(s, 10)
+	}
+	if !ok {
+		return nil, false
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, true
+}
+
+// parseList converts the JSON encoding of an array, or a decoded one, to its
+// elements.
+func parseList(v interface{}, path string) ([]interface{}, error) {
+	if s, ok := v.(string); ok {
+		var err error
+		if v, err = decodeInput(s, path); err != nil {
+			return nil, err
+		}
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, parseError(path, "invalid array %s", formatInput(v))
+	}
+	return list, nil
+}
+
+// decodeInput decodes JSON input, keeping its numbers exact.
+func decodeInput(s string, path string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, parseError(path, "invalid JSON %q: %v", s, err)
+	}
+	if dec.More() {
+		return nil, parseError(path, "invalid JSON %q: trailing data", s)
+	}
+	return v, nil
+}
+
+// formatInput formats a plain text or decoded JSON input for error messages.
+func formatInput(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(blob)
+}
+
+// containsString reports whether the list holds s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatValue formats a value of typ, as passed to Pack or returned by Unpack,
+// for display. Elementary values are formatted the way ParseArgument accepts
+// them, while arrays and tuples are formatted as JSON, so that the output can
+// be parsed back into the value.
+func FormatValue(typ Type, value interface{}) string {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr && typ.Kind != reflect.Ptr) {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return fmt.Sprint(value)
+	}
+	formatted := formatValue(typ, v)
+	switch typ.T {
+	case SliceTy, ArrayTy, TupleTy:
+		buf := new(bytes.Buffer)
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(formatted); err != nil {
+			return fmt.Sprint(value)
+		}
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+	return fmt.Sprint(formatted)
+}
+
+// formatValue converts a value of typ into the JSON friendly form FormatValue
+// prints, with integers as exact JSON numbers.
+func formatValue(typ Type, v reflect.Value) interface{} {
+	for v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch typ.T {
+	case IntTy, UintTy:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return json.Number(big.NewInt(v.Int()).String())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return json.Number(new(big.Int).SetUint64(v.Uint()).String())
+		case reflect.Ptr:
+			if n, ok := v.Interface().(*big.Int); ok && n != nil {
+				return json.Number(n.String())
+			}
+		}
+	case BoolTy:
+		if v.Kind() == reflect.Bool {
+			return v.Bool()
+		}
+	case StringTy:
+		if v.Kind() == reflect.String {
+			return v.String()
+		}
+	case AddressTy:
+		if address, ok := v.Interface().(common.Address); ok {
+			return address.Hex()
+		}
+	case BytesTy, FixedBytesTy, FunctionTy:
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return hexutil.Encode(b)
+		}
+	case SliceTy, ArrayTy:
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			list := make([]interface{}, v.Len())
+			for i := range list {
+				list[i] = formatValue(*typ.Elem, v.Index(i))
+			}
+			return list
+		}
+	case TupleTy:
+		if v.Kind() == reflect.Struct && v.NumField() == len(typ.TupleElems) {
+			fields := &tupleFields{names: typ.TupleRawNames, values: make([]interface{}, len(typ.TupleElems))}
+			for i := range typ.TupleElems {
+				fields.values[i] = formatValue(*typ.TupleElems[i], v.Field(i))
+			}
+			return fields
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// tupleFields is a formatted tuple, encoded as a JSON object that keeps the order
+// of its fields.
+type tupleFields struct {
+	names  []string
+	values []interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t *tupleFields) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, name := range t.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(t.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,131 @@
+package abi
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/common"
+)
+
+func mustNewType(t *testing.T, typ string, components []ArgumentMarshaling) Type {
+	parsed, err := NewType(typ, components)
+	if err != nil {
+		t.Fatalf("failed to create type %s: %v", typ, err)
+	}
+	return parsed
+}
+
+var itemComponents = []ArgumentMarshaling{
+	{Name: "name", Type: "string"},
+	{Name: "tags", Type: "uint8[]"},
+	{Name: "owner", Type: "tuple", Components: []ArgumentMarshaling{{Name: "id", Type: "int32"}, {Name: "account", Type: "address"}}},
+}
+
+func TestParseArgument(t *testing.T) {
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tests := []struct {
+		typ        string
+		components []ArgumentMarshaling
+		input      string
+		want       interface{}
+		formatted  string // expected FormatValue output (default = input)
+	}{
+		{"uint8", nil, "255", uint8(255), ""},
+		{"uint64", nil, "0xff", uint64(255), "255"},
+		{"int8", nil, "-128", int8(-128), ""},
+		{"int24", nil, "-8388608", big.NewInt(-8388608), ""},
+		{"uint256", nil, "115792089237316195423570985008687907853269984665640564039457584007913129639935", new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1), ""},
+		{"bool", nil, "true", true, ""},
+		{"string", nil, "hello, world", "hello, world", ""},
+		{"address", nil, account.Hex(), account, ""},
+		{"address", nil, "1111111111111111111111111111111111111111", account, account.Hex()},
+		{"bytes", nil, "0xcafe", []byte{0xca, 0xfe}, ""},
+		{"bytes", nil, "0x", []byte{}, ""},
+		{"bytes4", nil, "0xcafe", [4]byte{0xca, 0xfe}, "0xcafe0000"},
+		{"function", nil, "0x" + strings.Repeat("ab", 24), func() (f [24]byte) { copy(f[:], common.FromHex(strings.Repeat("ab", 24))); return }(), ""},
+		{"uint16[]", nil, "[1,2,3]", []uint16{1, 2, 3}, ""},
+		{"uint16[]", nil, "[]", []uint16{}, ""},
+		{"int256[2]", nil, `["-1", "0x10"]`, [2]*big.Int{big.NewInt(-1), big.NewInt(16)}, "[-1,16]"},
+		{"bool[2][]", nil, "[[true,false],[false,true]]", [][2]bool{{true, false}, {false, true}}, ""},
+		{"string[]", nil, `["a","b,c"]`, []string{"a", "b,c"}, ""},
+		{"bytes2[]", nil, `["0x0102"]`, [][2]byte{{1, 2}}, ""},
+		{"address[1]", nil, `["` + account.Hex() + `"]`, [1]common.Address{account}, ""},
+		{"tuple", itemComponents, `{"name":"store","tags":[1,2],"owner":{"id":-1,"account":"` + account.Hex() + `"}}`, nil, ""},
+		{"tuple", itemComponents, `["store",["1","0x2"],[-1,"` + account.Hex() + `"]]`, nil, `{"name":"store","tags":[1,2],"owner":{"id":-1,"account":"` + account.Hex() + `"}}`},
+		{"tuple[]", itemComponents, `[{"name":"a","tags":[],"owner":{"id":1,"account":"` + account.Hex() + `"}}]`, nil, ""},
+	}
+	for i, tt := range tests {
+		typ := mustNewType(t, tt.typ, tt.components)
+		value, err := ParseArgument(typ, tt.input)
+		if err != nil {
+			t.Errorf("test %d (%s %s): failed to parse: %v", i, tt.typ, tt.input, err)
+			continue
+		}
+		if reflect.TypeOf(value) != typ.Type {
+			t.Errorf("test %d (%s %s): type mismatch: have %T, want %v", i, tt.typ, tt.input, value, typ.Type)
+		}
+		if tt.want != nil && !reflect.DeepEqual(value, tt.want) {
+			t.Errorf("test %d (%s %s): value mismatch: have %v, want %v", i, tt.typ, tt.input, value, tt.want)
+		}
+		// The value must be packable and format back to the input
+		args := Arguments{{Type: typ}}
+		packed, err := args.Pack(value)
+		if err != nil {
+			t.Errorf("test %d (%s %s): failed to pack: %v", i, tt.typ, tt.input, err)
+			continue
+		}
+		unpacked, err := args.UnpackValues(packed)
+		if err != nil {
+			t.Errorf("test %d (%s %s): failed to unpack: %v", i, tt.typ, tt.input, err)
+			continue
+		}
+		want := tt.formatted
+		if want == "" {
+			want = tt.input
+		}
+		if have := FormatValue(typ, unpacked[0]); have != want {
+			t.Errorf("test %d (%s %s): formatted value mismatch: have %s, want %s", i, tt.typ, tt.input, have, want)
+		}
+	}
+}
+
+func TestParseArgumentErrors(t *testing.T) {
+	tests := []struct {
+		typ        string
+		components []ArgumentMarshaling
+		input      string
+		err        string
+	}{
+		{"uint8", nil, "256", "256 out of range for uint8"},
+		{"uint8", nil, "-1", "-1 out of range for uint8"},
+		{"int8", nil, "128", "128 out of range for int8"},
+		{"int8", nil, "-129", "-129 out of range for int8"},
+		{"uint256", nil, "1.5", `invalid uint256 "1.5"`},
+		{"uint256", nil, "abc", `invalid uint256 "abc"`},
+		{"bool", nil, "yes", `invalid bool "yes"`},
+		{"address", nil, "0x1234", `invalid address "0x1234"`},
+		{"bytes", nil, "0xzz", `invalid bytes "0xzz": not hex encoded`},
+		{"bytes2", nil, "0x010203", `invalid bytes2 "0x010203": 3 bytes long`},
+		{"function", nil, "0x01", "1 bytes long"},
+		{"uint8[]", nil, "1,2", `invalid JSON "1,2"`},
+		{"uint8[]", nil, `{"a":1}`, `invalid array {"a":1}`},
+		{"uint8[2]", nil, "[1]", "uint8[2] requires 2 elements, got 1"},
+		{"uint8[]", nil, "[1,256]", "[1]: 256 out of range for uint8"},
+		{"bool[2][]", nil, `[[true,false],[false,"no"]]`, `[1][1]: invalid bool "no"`},
+		{"tuple", itemComponents, `{"name":"store","tags":[1]}`, "missing field owner"},
+		{"tuple", itemComponents, `{"name":"store","tags":[1],"owner":[1,"0x1"],"extra":1}`, "unknown field extra"},
+		{"tuple", itemComponents, `["store",[1]]`, "requires 3 fields, got 2"},
+		{"tuple", itemComponents, `{"name":"store","tags":[1,"x"],"owner":[1,"0x1"]}`, `tags[1]: invalid uint8 "x"`},
+		{"tuple", itemComponents, `{"name":"store","tags":[],"owner":{"id":1,"account":"0x1"}}`, `owner.account: invalid address "0x1"`},
+		{"tuple", itemComponents, `{"name":7,"tags":[],"owner":[1,"0x1"]}`, "name: invalid string 7"},
+		{"tuple[]", itemComponents, `[{"name":"a","tags":[],"owner":[1e40,"0x1"]}]`, `[0].owner.id: invalid int32 1e40`},
+	}
+	for i, tt := range tests {
+		typ := mustNewType(t, tt.typ, tt.components)
+		if _, err := ParseArgument(typ, tt.input); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("test %d (%s %s): error mismatch: have %v, want %q", i, tt.typ, tt.input, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Package external implements a client for remote signers, such as a signing
+// daemon or an HSM gateway, that keep the private keys out of the process.
+//
+// The signer is reached over JSON-RPC 2.0 on HTTP, either on a TCP endpoint
+// (http://host:port) or on a Unix domain socket (unix:///path/to/signer.ipc or
+// just the absolute path of the socket). It has to serve two methods:
+//
+//	signer_accounts()              -> ["0x<address>", ...]
+//	signer_signHash(address, hash) -> "0x<65 bytes signature [R || S || V]>"
+//
+// The hash is the 32 byte transaction hash; V is the recovery id, 0/1 or 27/28.
+package external
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+	"github.com/KasperLiu/gobcos/rpc"
+)
+
+// ExternalSigner signs hashes with keys held by a remote signer.
+type ExternalSigner struct {
+	client   *rpc.Client
+	endpoint string
+}
+
+// NewExternalSigner creates a client for the signer listening on endpoint.
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalSigner{client: client, endpoint: endpoint}, nil
+}
+
+func dial(endpoint string) (*rpc.Client, error) {
+	path := endpoint
+	if strings.HasPrefix(endpoint, "unix://") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		path = u.Path
+	} else if !strings.HasPrefix(endpoint, "/") {
+		return rpc.Dial(endpoint)
+	}
+	// the host of the URL is ignored, every request goes to the socket
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}
+	return rpc.DialHTTPWithClient("http://signer", &http.Client{Transport: transport})
+}
+
+// Endpoint returns the endpoint of the remote signer.
+func (api *ExternalSigner) Endpoint() string {
+	return api.endpoint
+}
+
+// Accounts returns the addresses of the keys managed by the remote signer.
+func (api *ExternalSigner) Accounts(ctx context.Context) ([]common.Address, error) {
+	var accounts []common.Address
+	if err := api.client.CallContext(ctx, &accounts, "signer_accounts"); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// SignHash requests the signature of hash by the key of account. The signature is
+// returned in the [R || S || V] format of crypto.Sign, with V being 0 or 1.
+func (api *ExternalSigner) SignHash(ctx context.Context, account common.Address, hash []byte) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := api.client.CallContext(ctx, &sig, "signer_signHash", account, hexutil.Bytes(hash)); err != nil {
+		return nil, err
+	}
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("invalid signature length %d from the remote signer", len(sig))
+	}
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return nil, fmt.Errorf("invalid signature recovery id %d from the remote signer", sig[64])
+	}
+	return sig, nil
+}
+
+// Close closes the connection to the remote signer.
+func (api *ExternalSigner) Close() {
+	api.client.Close()
+}
@@ -0,0 +1,192 @@
+package external_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/accounts/external"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/crypto"
+)
+
+// signerStub is a minimal signing daemon holding a single key.
+type signerStub struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+	v       byte          // offset added to the recovery id, 0 or 27
+	delay   time.Duration // how long the signature requests hang
+}
+
+func newSignerStub(t *testing.T) *signerStub {
+	key, err := crypto.HexToECDSA("145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b58")
+	if err != nil {
+		t.Fatalf("init privateKey failed: %v", err)
+	}
+	return &signerStub{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+func (s *signerStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	resp := map[string]interface{}{"jsonrpc": "2.0"}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp["id"] = req.ID
+	switch req.Method {
+	case "signer_accounts":
+		resp["result"] = []common.Address{s.address}
+	case "signer_signHash":
+		time.Sleep(s.delay)
+		var (
+			account common.Address
+			hash    hexutil.Bytes
+		)
+		if len(req.Params) != 2 || json.Unmarshal(req.Params[0], &account) != nil || json.Unmarshal(req.Params[1], &hash) != nil {
+			resp["error"] = map[string]interface{}{"code": -32602, "message": "invalid params"}
+			break
+		}
+		if account != s.address {
+			resp["error"] = map[string]interface{}{"code": -32000, "message": "unknown account"}
+			break
+		}
+		sig, err := crypto.Sign(hash, s.key)
+		if err != nil {
+			resp["error"] = map[string]interface{}{"code": -32000, "message": err.Error()}
+			break
+		}
+		sig[64] += s.v
+		resp["result"] = hexutil.Bytes(sig)
+	default:
+		resp["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func testTransaction() *types.RawTransaction {
+	gas := big.NewInt(30000000)
+	to := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	return types.NewRawTransaction(big.NewInt(1), to, new(big.Int), gas, gas, big.NewInt(500), nil, big.NewInt(1), big.NewInt(1), nil)
+}
+
+func TestExternalSignerHTTP(t *testing.T) {
+	stub := newSignerStub(t)
+	stub.v = 27
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	signer, err := external.NewExternalSigner(srv.URL)
+	if err != nil {
+		t.Fatalf("dial remote signer failed: %v", err)
+	}
+	defer signer.Close()
+
+	accounts, err := signer.Accounts(context.Background())
+	if err != nil {
+		t.Fatalf("list accounts failed: %v", err)
+	}
+	if len(accounts) != 1 || accounts[0] != stub.address {
+		t.Fatalf("unexpected accounts: %v", accounts)
+	}
+	hash := crypto.Keccak256([]byte("gobcos"))
+	sig, err := signer.SignHash(context.Background(), stub.address, hash)
+	if err != nil {
+		t.Fatalf("sign hash failed: %v", err)
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatalf("recover public key failed: %v", err)
+	}
+	if crypto.PubkeyToAddress(*pub) != stub.address {
+		t.Fatalf("signature recovered to %s, want %s", crypto.PubkeyToAddress(*pub).Hex(), stub.address.Hex())
+	}
+	if _, err := signer.SignHash(context.Background(), common.Address{1}, hash); err == nil {
+		t.Fatalf("signing with an unknown account succeeded")
+	}
+}
+
+func TestRemoteTransactorUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gobcos-signer")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "signer.ipc")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen on %s failed: %v", socket, err)
+	}
+	stub := newSignerStub(t)
+	go http.Serve(listener, stub)
+	defer listener.Close()
+
+	for _, endpoint := range []string{socket, "unix://" + socket} {
+		remote, err := external.NewExternalSigner(endpoint)
+		if err != nil {
+			t.Fatalf("dial remote signer %s failed: %v", endpoint, err)
+		}
+		defer remote.Close()
+		auth := bind.NewRemoteTransactor(remote, stub.address, 0)
+		signed, err := bind.Sign(auth, testTransaction())
+		if err != nil {
+			t.Fatalf("sign transaction through %s failed: %v", endpoint, err)
+		}
+		from, err := types.RawSender(types.HomesteadRawSigner{}, signed)
+		if err != nil || from != stub.address {
+			t.Fatalf("transaction signed by %s (%v), want %s", from.Hex(), err, stub.address.Hex())
+		}
+	}
+
+	// a signer returning a signature of another key is rejected
+	remote, err := external.NewExternalSigner(socket)
+	if err != nil {
+		t.Fatalf("dial remote signer failed: %v", err)
+	}
+	defer remote.Close()
+	auth := bind.NewRemoteTransactor(remote, stub.address, 0)
+	if stub.key, err = crypto.GenerateKey(); err != nil {
+		t.Fatalf("generate key failed: %v", err)
+	}
+	if _, err := bind.Sign(auth, testTransaction()); err == nil {
+		t.Fatalf("signature of the wrong key accepted")
+	}
+}
+
+func TestRemoteTransactorTimeout(t *testing.T) {
+	stub := newSignerStub(t)
+	stub.delay = time.Second
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	remote, err := external.NewExternalSigner(srv.URL)
+	if err != nil {
+		t.Fatalf("dial remote signer failed: %v", err)
+	}
+	defer remote.Close()
+	auth := bind.NewRemoteTransactor(remote, stub.address, 100*time.Millisecond)
+
+	start := time.Now()
+	if _, err := bind.Sign(auth, testTransaction()); err == nil {
+		t.Fatal("signature of an unresponsive signer succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("unresponsive signer waited for %v, want about the 100ms timeout", elapsed)
+	}
+}
@@ -0,0 +1,251 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/KasperLiu/gobcos/accounts"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/math"
+	"github.com/KasperLiu/gobcos/crypto"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// HardenedKeyStart is the index of the first hardened child key, written i' in
+// derivation paths.
+const HardenedKeyStart = 0x80000000
+
+var (
+	// ErrInvalidSeed is returned for a seed which is not 16 to 64 bytes long.
+	ErrInvalidSeed = errors.New("seed must be 16 to 64 bytes long")
+	// ErrInvalidKey is returned in the rare case, of a probability below 2^-127,
+	// where a derived key is invalid. The next index should be used instead.
+	ErrInvalidKey = errors.New("derived key is invalid, use the next index")
+	// ErrHardenedFromPublic is returned when deriving a hardened child of a
+	// public key, which requires the private key.
+	ErrHardenedFromPublic = errors.New("cannot derive a hardened key from a public key")
+)
+
+// masterKeySecret is the HMAC key of the master key generation.
+var masterKeySecret = []byte("Bitcoin seed")
+
+// The version bytes of the serialized keys of the main network.
+var (
+	privateVersion = []byte{0x04, 0x88, 0xad, 0xe4} // xprv
+	publicVersion  = []byte{0x04, 0x88, 0xb2, 0x1e} // xpub
+)
+
+// ExtendedKey is a BIP-32 secp256k1 extended key, private or public, from which
+// child keys are derived.
+type ExtendedKey struct {
+	key         []byte // 32 byte private key or 33 byte compressed public key
+	chainCode   []byte
+	depth       uint8
+	parentFP    []byte // first 4 bytes of the hash of the parent public key
+	childNumber uint32
+	private     bool
+}
+
+// NewMasterKey derives the master private key of a seed, e.g. the one of a
+// mnemonic returned by NewSeed.
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrInvalidSeed
+	}
+	mac := hmac.New(sha512.New, masterKeySecret)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	k := new(big.Int).SetBytes(sum[:32])
+	if k.Sign() == 0 || k.Cmp(crypto.S256().Params().N) >= 0 {
+		return nil, ErrInvalidKey
+	}
+	return &ExtendedKey{key: sum[:32], chainCode: sum[32:], parentFP: make([]byte, 4), private: true}, nil
+}
+
+// IsPrivate reports whether k is a private extended key.
+func (k *ExtendedKey) IsPrivate() bool {
+	return k.private
+}
+
+// Depth returns the number of derivations from the master key to k.
+func (k *ExtendedKey) Depth() uint8 {
+	return k.depth
+}
+
+// Child derives the child key of k at index i, hardened if i is at least
+// HardenedKeyStart. The child of a private key is private, the one of a public
+// key is public.
+func (k *ExtendedKey) Child(i uint32) (*ExtendedKey, error) {
+	hardened := i >= HardenedKeyStart
+	if hardened && !k.private {
+		return nil, ErrHardenedFromPublic
+	}
+	// a hardened child is derived from the private key, else from the public one
+	data := make([]byte, 0, 37)
+	if hardened {
+		data = append(append(data, 0), k.key...)
+	} else {
+		data = append(data, k.publicKeyBytes()...)
+	}
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], i)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	curve := crypto.S256()
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(curve.Params().N) >= 0 {
+		return nil, ErrInvalidKey
+	}
+	child := &ExtendedKey{
+		chainCode:   sum[32:],
+		depth:       k.depth + 1,
+		parentFP:    k.fingerprint(),
+		childNumber: i,
+		private:     k.private,
+	}
+	if k.private {
+		// the child key is IL + k mod n
+		key := il.Add(il, new(big.Int).SetBytes(k.key))
+		key.Mod(key, curve.Params().N)
+		if key.Sign() == 0 {
+			return nil, ErrInvalidKey
+		}
+		child.key = math.PaddedBigBytes(key, 32)
+		return child, nil
+	}
+	// the child key is IL*G + K
+	pub, err := crypto.DecompressPubkey(k.key)
+	if err != nil {
+		return nil, err
+	}
+	x, y := curve.ScalarBaseMult(sum[:32])
+	x, y = curve.Add(x, y, pub.X, pub.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, ErrInvalidKey
+	}
+	child.key = crypto.CompressPubkey(&ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+	return child, nil
+}
+
+// Derive derives the descendant key of k along path, relative to k.
+func (k *ExtendedKey) Derive(path accounts.DerivationPath) (*ExtendedKey, error) {
+	key := k
+	for _, i := range path {
+		var err error
+		if key, err = key.Child(i); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Neuter returns the public extended key of k.
+func (k *ExtendedKey) Neuter() *ExtendedKey {
+	if !k.private {
+		return k
+	}
+	return &ExtendedKey{
+		key:         k.publicKeyBytes(),
+		chainCode:   k.chainCode,
+		depth:       k.depth,
+		parentFP:    k.parentFP,
+		childNumber: k.childNumber,
+	}
+}
+
+// PrivateKey returns the ECDSA private key of a private extended key.
+func (k *ExtendedKey) PrivateKey() (*ecdsa.PrivateKey, error) {
+	if !k.private {
+		return nil, errors.New("not a private extended key")
+	}
+	return crypto.ToECDSA(k.key)
+}
+
+// PublicKey returns the ECDSA public key of k.
+func (k *ExtendedKey) PublicKey() (*ecdsa.PublicKey, error) {
+	return crypto.DecompressPubkey(k.publicKeyBytes())
+}
+
+// Address returns the account address of the key pair of k.
+func (k *ExtendedKey) Address() (common.Address, error) {
+	pub, err := k.PublicKey()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// String returns the base58 serialization of k, starting with xprv for a private
+// key and xpub for a public one.
+func (k *ExtendedKey) String() string {
+	data := make([]byte, 0, 82)
+	if k.private {
+		data = append(data, privateVersion...)
+	} else {
+		data = append(data, publicVersion...)
+	}
+	data = append(data, k.depth)
+	data = append(data, k.parentFP...)
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], k.childNumber)
+	data = append(data, k.chainCode...)
+	if k.private {
+		data = append(data, 0)
+	}
+	data = append(data, k.key...)
+
+	first := sha256.Sum256(data)
+	checksum := sha256.Sum256(first[:])
+	return base58Encode(append(data, checksum[:4]...))
+}
+
+// publicKeyBytes returns the compressed public key of k.
+func (k *ExtendedKey) publicKeyBytes() []byte {
+	if !k.private {
+		return k.key
+	}
+	curve := crypto.S256()
+	x, y := curve.ScalarBaseMult(k.key)
+	return crypto.CompressPubkey(&ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+}
+
+// fingerprint returns the identifier of k used by its children.
+func (k *ExtendedKey) fingerprint() []byte {
+	hash := sha256.Sum256(k.publicKeyBytes())
+	hasher := ripemd160.New()
+	hasher.Write(hash[:])
+	return hasher.Sum(nil)[:4]
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode encodes b with the base58 alphabet of Bitcoin.
+func base58Encode(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix, mod := big.NewInt(58), new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	// every leading zero byte is encoded as the first character
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
@@ -0,0 +1,121 @@
+package hdwallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrInvalidEntropy is returned for an entropy which is not 128 to 256 bits
+	// long, by steps of 32 bits.
+	ErrInvalidEntropy = errors.New("entropy must be 128, 160, 192, 224 or 256 bits long")
+	// ErrInvalidChecksum is returned for a mnemonic whose checksum does not match
+	// its entropy, e.g. because of a mistyped word.
+	ErrInvalidChecksum = errors.New("invalid mnemonic checksum")
+)
+
+// wordIndex maps the words of the wordlist to their index.
+var wordIndex = func() map[string]int {
+	index := make(map[string]int, len(englishWords))
+	for i, word := range englishWords {
+		index[word] = i
+	}
+	return index
+}()
+
+// NewEntropy returns random entropy of the given number of bits, which must be
+// 128, 160, 192, 224 or 256 for a mnemonic of 12 to 24 words.
+func NewEntropy(bits int) ([]byte, error) {
+	if err := checkEntropySize(bits); err != nil {
+		return nil, err
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
+
+func checkEntropySize(bits int) error {
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return ErrInvalidEntropy
+	}
+	return nil
+}
+
+// NewMnemonic encodes entropy into the English mnemonic sentence of BIP-39, made
+// of 3 words per 32 bits of entropy.
+func NewMnemonic(entropy []byte) (string, error) {
+	bits := len(entropy) * 8
+	if err := checkEntropySize(bits); err != nil {
+		return "", err
+	}
+	// the entropy is followed by the first bits of its hash, and split into
+	// groups of 11 bits, each one being the index of a word
+	checksumBits := uint(bits / 32)
+	hash := sha256.Sum256(entropy)
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, checksumBits)
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	words := make([]string, (bits+int(checksumBits))/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = englishWords[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes a mnemonic sentence into its entropy, verifying its
+// checksum.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return nil, fmt.Errorf("invalid mnemonic of %d words, it must be made of 12, 15, 18, 21 or 24 words", len(words))
+	}
+	data := new(big.Int)
+	for _, word := range words {
+		index, ok := wordIndex[word]
+		if !ok {
+			return nil, fmt.Errorf("invalid mnemonic word %q", word)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+	checksumBits := uint(len(words) / 3)
+	checksum := new(big.Int).And(data, big.NewInt(1<<checksumBits-1)).Int64()
+	data.Rsh(data, checksumBits)
+
+	// 11 bits per word hold 32 bits of entropy and 1 of checksum per 3 words
+	entropy := make([]byte, len(words)/3*4)
+	b := data.Bytes()
+	copy(entropy[len(entropy)-len(b):], b)
+	if hash := sha256.Sum256(entropy); int64(hash[0]>>(8-checksumBits)) != checksum {
+		return nil, ErrInvalidChecksum
+	}
+	return entropy, nil
+}
+
+// ValidateMnemonic checks that the words of a mnemonic sentence are in the
+// wordlist and that its checksum is valid.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// NewSeed returns the 64 byte seed of a mnemonic sentence, protected by an
+// optional passphrase. The mnemonic is not validated, see ValidateMnemonic.
+func NewSeed(mnemonic, passphrase string) []byte {
+	password := norm.NFKD.String(strings.Join(strings.Fields(mnemonic), " "))
+	salt := norm.NFKD.String("mnemonic" + passphrase)
+	return pbkdf2.Key([]byte(password), []byte(salt), 2048, 64, sha512.New)
+}
@@ -0,0 +1,223 @@
+// Package hdwallet implements hierarchical deterministic wallets, managing many
+// accounts derived from a single seed:
+//
+//   - BIP-39 mnemonic sentences, encoding the entropy of the seed into words;
+//   - BIP-32 derivation of secp256k1 extended keys;
+//   - a Wallet deriving the accounts along BIP-44 derivation paths, e.g.
+//     m/44'/60'/0'/0/0 for the first account.
+//
+// A wallet is created from a mnemonic, and its accounts sign transactions
+// through bind.TransactOpts:
+//
+//	mnemonic, _ := hdwallet.NewMnemonic(entropy)
+//	wallet, _ := hdwallet.NewFromMnemonic(mnemonic, "")
+//	account, _ := wallet.Derive(accounts.DefaultBaseDerivationPath, true)
+//	auth, _ := wallet.NewTransactor(account)
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/KasperLiu/gobcos/accounts"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/crypto"
+)
+
+// Wallet is an accounts.Wallet whose accounts are derived from the master key
+// of a seed. Its keys are held in memory.
+type Wallet struct {
+	master *ExtendedKey
+	url    accounts.URL
+
+	mu       sync.RWMutex
+	accounts []accounts.Account                         // pinned accounts
+	paths    map[common.Address]accounts.DerivationPath // derivation paths of the pinned accounts
+}
+
+// NewFromMnemonic creates the wallet of a mnemonic sentence, protected by an
+// optional passphrase. The mnemonic must be valid, see ValidateMnemonic.
+func NewFromMnemonic(mnemonic, passphrase string) (*Wallet, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	return NewFromSeed(NewSeed(mnemonic, passphrase))
+}
+
+// NewFromSeed creates the wallet of a seed.
+func NewFromSeed(seed []byte) (*Wallet, error) {
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &Wallet{
+		master: master,
+		url:    accounts.URL{Scheme: "hd", Path: fmt.Sprintf("%x", master.fingerprint())},
+		paths:  make(map[common.Address]accounts.DerivationPath),
+	}, nil
+}
+
+// URL implements accounts.Wallet, returning the fingerprint of the master key
+// with the hd scheme.
+func (w *Wallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, the keys of the wallet are always available.
+func (w *Wallet) Status() (string, error) {
+	return "Open", nil
+}
+
+// Open implements accounts.Wallet, but is a noop since the seed is not encrypted.
+func (w *Wallet) Open(passphrase string) error { return nil }
+
+// Close implements accounts.Wallet, but is a noop since there is no meaningful
+// open operation.
+func (w *Wallet) Close() error { return nil }
+
+// Accounts implements accounts.Wallet, returning the accounts pinned by Derive.
+func (w *Wallet) Accounts() []accounts.Account {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// Contains implements accounts.Wallet, returning whether an account has been
+// pinned by Derive.
+func (w *Wallet) Contains(account accounts.Account) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	_, ok := w.paths[account.Address]
+	return ok
+}
+
+// Derive implements accounts.Wallet, deriving the account at path from the
+// master key. The account is pinned to the wallet if requested, so that it can
+// sign with it.
+func (w *Wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	key, err := w.master.Derive(path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	address, err := key.Address()
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	account := accounts.Account{
+		Address: address,
+		URL:     accounts.URL{Scheme: w.url.Scheme, Path: w.url.Path + "/" + path.String()},
+	}
+	if !pin {
+		return account, nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.paths[address]; !ok {
+		w.accounts = append(w.accounts, account)
+		w.paths[address] = append(accounts.DerivationPath{}, path...)
+	}
+	return account, nil
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop since the accounts of
+// FISCO BCOS have no balance to discover the used ones with.
+func (w *Wallet) SelfDerive(bases []accounts.DerivationPath, chain common.ChainStateReader) {
+}
+
+// Path returns the derivation path of a pinned account.
+func (w *Wallet) Path(account accounts.Account) (accounts.DerivationPath, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	return append(accounts.DerivationPath{}, path...), nil
+}
+
+// PrivateKey returns the private key of a pinned account.
+func (w *Wallet) PrivateKey(account accounts.Account) (*ecdsa.PrivateKey, error) {
+	path, err := w.Path(account)
+	if err != nil {
+		return nil, err
+	}
+	key, err := w.master.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey()
+}
+
+// NewTransactor creates a transaction signer for a pinned account.
+func (w *Wallet) NewTransactor(account accounts.Account) (*bind.TransactOpts, error) {
+	key, err := w.PrivateKey(account)
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewKeyedTransactor(key), nil
+}
+
+// signHash signs hash with the key of a pinned account.
+func (w *Wallet) signHash(account accounts.Account, hash []byte) ([]byte, error) {
+	key, err := w.PrivateKey(account)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sign(hash, key)
+}
+
+// SignData implements accounts.Wallet, signing keccak256(data) with the key of a
+// pinned account.
+func (w *Wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	return w.signHash(account, crypto.Keccak256(data))
+}
+
+// SignDataWithPassphrase implements accounts.Wallet, the passphrase is ignored
+// since the keys are not encrypted.
+func (w *Wallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	return w.SignData(account, mimeType, data)
+}
+
+// SignText implements accounts.Wallet, signing the hash of text prefixed by the
+// Ethereum prefix scheme.
+func (w *Wallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return w.signHash(account, accounts.TextHash(text))
+}
+
+// SignTextWithPassphrase implements accounts.Wallet, the passphrase is ignored
+// since the keys are not encrypted.
+func (w *Wallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	return w.SignText(account, text)
+}
+
+// SignTx implements accounts.Wallet, signing the transaction with the key of a
+// pinned account.
+func (w *Wallet) SignTx(account accounts.Account, tx *types.RawTransaction, chainID *big.Int) (*types.RawTransaction, error) {
+	key, err := w.PrivateKey(account)
+	if err != nil {
+		return nil, err
+	}
+	// Depending on the presence of the chain ID, sign with EIP155 or homestead
+	if chainID != nil {
+		return types.SignRawTx(tx, types.NewEIP155RawSigner(chainID), key)
+	}
+	return types.SignRawTx(tx, types.HomesteadRawSigner{}, key)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, the passphrase is ignored
+// since the keys are not encrypted.
+func (w *Wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.RawTransaction, chainID *big.Int) (*types.RawTransaction, error) {
+	return w.SignTx(account, tx, chainID)
+}
+
+var _ accounts.Wallet = (*Wallet)(nil)
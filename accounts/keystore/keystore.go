@@ -31,6 +31,7 @@ import (
 	"reflect"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 	"github.com/KasperLiu/gobcos/accounts"
 	"github.com/KasperLiu/gobcos/core/types"
@@ -71,8 +72,10 @@ type KeyStore struct {
 }
 
 type unlocked struct {
+	lastUsed int64 // Time of the last signature in unix nanoseconds, first for 64-bit atomic alignment
 	*Key
 	abort chan struct{}
+	idle  bool // Whether the timeout restarts with every signature
 }
 
 // NewKeyStore creates a keystore for the given directory.
@@ -265,6 +268,8 @@ func (ks *KeyStore) SignHash(a accounts.Account, hash []byte) ([]byte, error) {
 	if !found {
 		return nil, ErrLocked
 	}
+	atomic.StoreInt64(&unlockedKey.lastUsed, time.Now().UnixNano())
+
 	// Sign the hash using plain ECDSA operations
 	return crypto.Sign(hash, unlockedKey.PrivateKey)
 }
@@ -279,6 +284,8 @@ func (ks *KeyStore) SignTx(a accounts.Account, tx *types.RawTransaction, chainID
 	if !found {
 		return nil, ErrLocked
 	}
+	atomic.StoreInt64(&unlockedKey.lastUsed, time.Now().UnixNano())
+
 	// Depending on the presence of the chain ID, sign with EIP155 or homestead
 	if chainID != nil {
 		return types.SignRawTx(tx, types.NewEIP155RawSigner(chainID), unlockedKey.PrivateKey)
@@ -322,15 +329,25 @@ func (ks *KeyStore) Unlock(a accounts.Account, passphrase string) error {
 // Lock removes the private key with the given address from memory.
 func (ks *KeyStore) Lock(addr common.Address) error {
 	ks.mu.Lock()
-	if unl, found := ks.unlocked[addr]; found {
-		ks.mu.Unlock()
-		ks.expire(addr, unl, time.Duration(0)*time.Nanosecond)
-	} else {
-		ks.mu.Unlock()
+	defer ks.mu.Unlock()
+
+	if u, found := ks.unlocked[addr]; found {
+		ks.lock(addr, u)
 	}
 	return nil
 }
 
+// LockAll removes all the unlocked private keys from memory, e.g. when a
+// service shuts down.
+func (ks *KeyStore) LockAll() {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	for addr, u := range ks.unlocked {
+		ks.lock(addr, u)
+	}
+}
+
 // TimedUnlock unlocks the given account with the passphrase. The account
 // stays unlocked for the duration of timeout. A timeout of 0 unlocks the account
 // until the program exits. The account must match a unique key file.
@@ -339,6 +356,23 @@ func (ks *KeyStore) Lock(addr common.Address) error {
 // shortens the active unlock timeout. If the address was previously unlocked
 // indefinitely the timeout is not altered.
 func (ks *KeyStore) TimedUnlock(a accounts.Account, passphrase string, timeout time.Duration) error {
+	return ks.unlock(a, passphrase, timeout, false)
+}
+
+// IdleUnlock unlocks the given account with the passphrase until it has not
+// signed anything for the duration of idle. Every signature restarts the
+// timeout, so that a long running service keeps the key in memory only while
+// it is in use. An idle duration of 0 unlocks the account until the program
+// exits.
+//
+// If the account address is already unlocked for a duration, IdleUnlock
+// replaces the active unlock timeout. If the address was previously unlocked
+// indefinitely the timeout is not altered.
+func (ks *KeyStore) IdleUnlock(a accounts.Account, passphrase string, idle time.Duration) error {
+	return ks.unlock(a, passphrase, idle, true)
+}
+
+func (ks *KeyStore) unlock(a accounts.Account, passphrase string, timeout time.Duration, idle bool) error {
 	a, key, err := ks.getDecryptedKey(a, passphrase)
 	if err != nil {
 		return err
@@ -358,7 +392,7 @@ func (ks *KeyStore) TimedUnlock(a accounts.Account, passphrase string, timeout t
 		close(u.abort)
 	}
 	if timeout > 0 {
-		u = &unlocked{Key: key, abort: make(chan struct{})}
+		u = &unlocked{lastUsed: time.Now().UnixNano(), Key: key, abort: make(chan struct{}), idle: idle}
 		go ks.expire(a.Address, u, timeout)
 	} else {
 		u = &unlocked{Key: key}
@@ -388,21 +422,42 @@ func (ks *KeyStore) getDecryptedKey(a accounts.Account, auth string) (accounts.A
 func (ks *KeyStore) expire(addr common.Address, u *unlocked, timeout time.Duration) {
 	t := time.NewTimer(timeout)
 	defer t.Stop()
-	select {
-	case <-u.abort:
-		// just quit
-	case <-t.C:
+	for {
+		select {
+		case <-u.abort:
+			// just quit
+			return
+		case <-t.C:
+		}
+		if u.idle {
+			// wait again if the key signed something during the timeout
+			last := time.Unix(0, atomic.LoadInt64(&u.lastUsed))
+			if left := time.Until(last.Add(timeout)); left > 0 {
+				t.Reset(left)
+				continue
+			}
+		}
 		ks.mu.Lock()
 		// only drop if it's still the same key instance that dropLater
 		// was launched with. we can check that using pointer equality
 		// because the map stores a new pointer every time the key is
 		// unlocked.
 		if ks.unlocked[addr] == u {
-			zeroKey(u.PrivateKey)
-			delete(ks.unlocked, addr)
+			ks.lock(addr, u)
 		}
 		ks.mu.Unlock()
+		return
+	}
+}
+
+// lock zeroes an unlocked key and drops it, stopping its expire goroutine. The
+// caller must hold ks.mu.
+func (ks *KeyStore) lock(addr common.Address, u *unlocked) {
+	if u.abort != nil {
+		close(u.abort)
 	}
+	zeroKey(u.PrivateKey)
+	delete(ks.unlocked, addr)
 }
 
 // NewAccount generates a new key and stores it into the key directory,
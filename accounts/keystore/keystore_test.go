@@ -0,0 +1,119 @@
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/KasperLiu/gobcos/accounts"
+)
+
+var testSigData = make([]byte, 32)
+
+func tmpKeyStore(t *testing.T) (string, *KeyStore) {
+	dir, err := ioutil.TempDir("", "gobcos-keystore-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, NewKeyStore(dir, LightScryptN, LightScryptP)
+}
+
+func newTestAccount(t *testing.T, ks *KeyStore) accounts.Account {
+	a, err := ks.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestLock(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	a := newTestAccount(t, ks)
+	if _, err := ks.SignHash(a, testSigData); err != ErrLocked {
+		t.Fatalf("signing with a locked account: got %v, want %v", err, ErrLocked)
+	}
+	if err := ks.Unlock(a, "bar"); err != ErrDecrypt {
+		t.Fatalf("unlocking with a wrong passphrase: got %v, want %v", err, ErrDecrypt)
+	}
+	if err := ks.Unlock(a, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.SignHash(a, testSigData); err != nil {
+		t.Fatalf("signing with an unlocked account: %v", err)
+	}
+	if err := ks.Lock(a.Address); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.SignHash(a, testSigData); err != ErrLocked {
+		t.Fatalf("signing after Lock: got %v, want %v", err, ErrLocked)
+	}
+}
+
+func TestTimedUnlock(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	a := newTestAccount(t, ks)
+	if err := ks.TimedUnlock(a, "foo", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.SignHash(a, testSigData); err != nil {
+		t.Fatalf("signing before the timeout: %v", err)
+	}
+	time.Sleep(250 * time.Millisecond)
+	if _, err := ks.SignHash(a, testSigData); err != ErrLocked {
+		t.Fatalf("signing after the timeout: got %v, want %v", err, ErrLocked)
+	}
+}
+
+func TestIdleUnlock(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	a := newTestAccount(t, ks)
+	if err := ks.IdleUnlock(a, "foo", 200*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	// signing more often than the idle timeout keeps the account unlocked
+	for i := 0; i < 6; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if _, err := ks.SignHash(a, testSigData); err != nil {
+			t.Fatalf("signing %d while in use: %v", i, err)
+		}
+	}
+	time.Sleep(400 * time.Millisecond)
+	if _, err := ks.SignHash(a, testSigData); err != ErrLocked {
+		t.Fatalf("signing after being idle: got %v, want %v", err, ErrLocked)
+	}
+}
+
+func TestLockAll(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	a1, a2 := newTestAccount(t, ks), newTestAccount(t, ks)
+	if err := ks.Unlock(a1, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.IdleUnlock(a2, "foo", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	ks.mu.RLock()
+	key := ks.unlocked[a2.Address].PrivateKey
+	ks.mu.RUnlock()
+
+	ks.LockAll()
+	for _, a := range []accounts.Account{a1, a2} {
+		if _, err := ks.SignHash(a, testSigData); err != ErrLocked {
+			t.Errorf("signing with %x after LockAll: got %v, want %v", a.Address, err, ErrLocked)
+		}
+	}
+	for _, word := range key.D.Bits() {
+		if word != 0 {
+			t.Fatal("private key not zeroed by LockAll")
+		}
+	}
+}
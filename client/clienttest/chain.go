@@ -0,0 +1,971 @@
+package clienttest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/crypto"
+)
+
+const (
+	defaultGroupID   = 1
+	defaultChainID   = 1
+	defaultPoolLimit = 150000
+)
+
+// JSON-RPC error codes returned by FISCO BCOS 2.x nodes.
+const (
+	codeGroupNotExist     = -40001
+	codeBlockHashNotExist = -40003
+	codeBlockNumNotExist  = -40004
+	codeIndexOutOfRange   = -40005
+	codeInvalidConfig     = -40008
+	codeBlockLimitCheck   = common.RPCBlockLimitCheckFail
+	codeTxPoolIsFull      = common.RPCTxPoolIsFull
+	codeMalformedTx       = common.RPCMalformedTx
+	codeAlreadyInTxPool   = common.RPCAlreadyInTxPool
+	codeAlreadyInChain    = common.RPCAlreadyInChain
+	codeInvalidChainID    = common.RPCInvalidChainId
+	codeInvalidGroupID    = common.RPCInvalidGroupId
+	codeInvalidParams     = -32602
+)
+
+const zeroAddressHex = "0x0000000000000000000000000000000000000000"
+
+var emptyLogsBloom = "0x" + strings.Repeat("00", 256)
+
+// Execution is the outcome of a transaction, as produced by a
+// TransactionHandler.
+type Execution struct {
+	Status string          // receipt status, "0x0" if empty
+	Output []byte          // return data of the call
+	Logs   []*types.NewLog // events emitted by the transaction
+}
+
+// TransactionHandler executes a transaction sent by from when it is sealed.
+// Returning nil is the same as returning a successful Execution with no output.
+type TransactionHandler func(tx *types.RawTransaction, from common.Address) *Execution
+
+// CallHandler answers a read-only contract call with its return data.
+type CallHandler func(from, to common.Address, data []byte) ([]byte, error)
+
+type chainMethod func(c *chain, params []json.RawMessage) (interface{}, error)
+
+var chainMethods = map[string]chainMethod{
+	"getClientVersion":                    (*chain).clientVersion,
+	"getBlockNumber":                      (*chain).blockNumber,
+	"getPbftView":                         (*chain).pbftView,
+	"getSealerList":                       (*chain).sealerList,
+	"getObserverList":                     (*chain).observerList,
+	"getConsensusStatus":                  (*chain).consensusStatus,
+	"getSyncStatus":                       (*chain).syncStatus,
+	"getPeers":                            (*chain).peers,
+	"getGroupPeers":                       (*chain).groupPeers,
+	"getNodeIDList":                       (*chain).nodeIDList,
+	"getGroupList":                        (*chain).groupList,
+	"getBlockByHash":                      (*chain).blockByHash,
+	"getBlockByNumber":                    (*chain).blockByNumber,
+	"getBlockHashByNumber":                (*chain).blockHashByNumber,
+	"getTransactionByHash":                (*chain).transactionByHash,
+	"getTransactionByBlockHashAndIndex":   (*chain).transactionByBlockHashAndIndex,
+	"getTransactionByBlockNumberAndIndex": (*chain).transactionByBlockNumberAndIndex,
+	"getTransactionReceipt":               (*chain).transactionReceipt,
+	"getPendingTransactions":              (*chain).pendingTransactions,
+	"getPendingTxSize":                    (*chain).pendingTxSize,
+	"getCode":                             (*chain).code,
+	"getTotalTransactionCount":            (*chain).totalTransactionCount,
+	"getSystemConfigByKey":                (*chain).systemConfigByKey,
+	"call":                                (*chain).call,
+	"sendRawTransaction":                  (*chain).sendRawTransaction,
+}
+
+type block struct {
+	number    uint64
+	hash      common.Hash
+	parent    common.Hash
+	timestamp int64
+	txs       []*txEntry
+}
+
+type txEntry struct {
+	tx      *types.RawTransaction
+	from    common.Address
+	block   *block // nil while pending
+	index   int
+	receipt *types.Receipt
+}
+
+// chain is the in-memory state behind the default method handlers.
+type chain struct {
+	sealMu sync.Mutex // serialises sealing, held without mu while running handlers
+
+	mu        sync.Mutex
+	blocks    []*block
+	txs       map[common.Hash]*txEntry
+	pending   []*txEntry
+	autoSeal  bool
+	poolLimit int
+	codes     map[common.Address][]byte
+	config    map[string]string
+	sealers   []string
+	observers []string
+	nodeIDs   []string // connected nodes, the group peers if nil
+	execute   TransactionHandler
+	callFn    CallHandler
+}
+
+func newChain() *chain {
+	c := &chain{
+		txs:       make(map[common.Hash]*txEntry),
+		autoSeal:  true,
+		poolLimit: defaultPoolLimit,
+		codes:     make(map[common.Address][]byte),
+		config: map[string]string{
+			"tx_count_limit": "1000",
+			"tx_gas_limit":   "300000000",
+		},
+		sealers: []string{nodeID(0), nodeID(1), nodeID(2), nodeID(3)},
+	}
+	c.blocks = []*block{c.newBlock(nil)}
+	return c
+}
+
+// nodeID returns a deterministic 64 byte node ID.
+func nodeID(i int) string {
+	return hex.EncodeToString(crypto.Keccak512([]byte(fmt.Sprintf("node%d", i))))
+}
+
+func (c *chain) handler(method string) (HandlerFunc, bool) {
+	m, ok := chainMethods[method]
+	if !ok {
+		return nil, false
+	}
+	return func(params []json.RawMessage) (interface{}, error) {
+		return m(c, params)
+	}, true
+}
+
+// newBlock builds the block following the current head. It must be called
+// with mu held.
+func (c *chain) newBlock(txs []*txEntry) *block {
+	b := &block{timestamp: time.Now().UnixNano() / int64(time.Millisecond), txs: txs}
+	if len(c.blocks) > 0 {
+		head := c.blocks[len(c.blocks)-1]
+		b.number = head.number + 1
+		b.parent = head.hash
+	}
+	enc := [][]byte{new(big.Int).SetUint64(b.number).Bytes(), b.parent.Bytes()}
+	for _, e := range txs {
+		enc = append(enc, e.tx.Hash().Bytes())
+	}
+	b.hash = crypto.Keccak256Hash(enc...)
+	return b
+}
+
+func (c *chain) head() *block {
+	return c.blocks[len(c.blocks)-1]
+}
+
+// seal packs all pending transactions into a new block, running the
+// transaction handler for each of them.
+func (c *chain) seal() {
+	c.sealMu.Lock()
+	defer c.sealMu.Unlock()
+
+	c.mu.Lock()
+	entries := c.pending
+	c.pending = nil
+	execute := c.execute
+	c.mu.Unlock()
+
+	results := make([]*Execution, len(entries))
+	for i, e := range entries {
+		if execute != nil {
+			results[i] = execute(e.tx, e.from)
+		}
+		if results[i] == nil {
+			results[i] = &Execution{}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.newBlock(entries)
+	for i, e := range entries {
+		e.block, e.index = b, i
+		e.receipt = c.makeReceipt(e, results[i])
+	}
+	c.blocks = append(c.blocks, b)
+}
+
+// makeReceipt builds the receipt of a sealed transaction. It must be called
+// with mu held.
+func (c *chain) makeReceipt(e *txEntry, res *Execution) *types.Receipt {
+	status := res.Status
+	if status == "" {
+		status = "0x0"
+	}
+	r := &types.Receipt{
+		TransactionHash:  e.tx.Hash().Hex(),
+		TransactionIndex: hexutil.EncodeUint64(uint64(e.index)),
+		BlockHash:        e.block.hash.Hex(),
+		BlockNumber:      hexutil.EncodeUint64(e.block.number),
+		GasUsed:          "0x0",
+		ContractAddress:  zeroAddressHex,
+		Root:             e.block.hash.Hex(),
+		Status:           status,
+		From:             strings.ToLower(e.from.Hex()),
+		To:               zeroAddressHex,
+		Input:            hexutil.Encode(e.tx.Data()),
+		Output:           hexutil.Encode(res.Output),
+		Logs:             []*types.NewLog{},
+		LogsBloom:        emptyLogsBloom,
+	}
+	address := zeroAddressHex
+	if to := e.tx.To(); to != nil {
+		r.To = strings.ToLower(to.Hex())
+		address = r.To
+	} else if status == "0x0" {
+		contract := common.BytesToAddress(crypto.Keccak256(e.from.Bytes(), e.tx.Hash().Bytes())[12:])
+		c.codes[contract] = e.tx.Data()
+		r.ContractAddress = strings.ToLower(contract.Hex())
+		address = r.ContractAddress
+	}
+	for i, l := range res.Logs {
+		log := *l
+		log.LogIndex = hexutil.EncodeUint64(uint64(i))
+		log.TransactionIndex = r.TransactionIndex
+		log.TransactionHash = r.TransactionHash
+		log.BlockHash = r.BlockHash
+		log.BlockNumber = r.BlockNumber
+		if log.Address == "" {
+			log.Address = address
+		}
+		if log.Topics == nil {
+			log.Topics = []interface{}{}
+		}
+		r.Logs = append(r.Logs, &log)
+	}
+	return r
+}
+
+// Parameter helpers.
+
+func rpcError(code int, format string, args ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// checkParams verifies that params holds n arguments, the first one of which
+// is the group ID of the chain.
+func checkParams(params []json.RawMessage, n int) error {
+	if len(params) < n {
+		return rpcError(codeInvalidParams, "missing value for required argument %d", len(params))
+	}
+	var group uint64
+	if err := json.Unmarshal(params[0], &group); err != nil {
+		return rpcError(codeInvalidParams, "invalid groupID: %v", err)
+	}
+	if group != defaultGroupID {
+		return rpcError(codeGroupNotExist, "GroupID does not exist")
+	}
+	return nil
+}
+
+func stringParam(params []json.RawMessage, i int) (string, error) {
+	var s string
+	if err := json.Unmarshal(params[i], &s); err != nil {
+		return "", rpcError(codeInvalidParams, "invalid argument %d: %v", i, err)
+	}
+	return s, nil
+}
+
+// numberParam decodes a hex or decimal quantity.
+func numberParam(params []json.RawMessage, i int) (uint64, error) {
+	s, err := stringParam(params, i)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return 0, rpcError(codeInvalidParams, "invalid argument %d: %v", i, err)
+	}
+	return n, nil
+}
+
+func boolParam(params []json.RawMessage, i int) (bool, error) {
+	var b bool
+	if i >= len(params) {
+		return false, nil
+	}
+	if err := json.Unmarshal(params[i], &b); err != nil {
+		return false, rpcError(codeInvalidParams, "invalid argument %d: %v", i, err)
+	}
+	return b, nil
+}
+
+func encodeBig(n *big.Int) string {
+	if n == nil {
+		return "0x0"
+	}
+	return hexutil.EncodeBig(n)
+}
+
+// Node information.
+
+func (c *chain) clientVersion(params []json.RawMessage) (interface{}, error) {
+	return map[string]string{
+		"Build Time":         "20190705 21:19:13",
+		"Build Type":         "Linux/g++/RelWithDebInfo",
+		"Chain Id":           strconv.Itoa(defaultChainID),
+		"FISCO-BCOS Version": "2.0.0",
+		"Git Branch":         "master",
+		"Git Commit Hash":    "0000000000000000000000000000000000000000",
+		"Supported Version":  "2.0.0",
+	}, nil
+}
+
+func (c *chain) blockNumber(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return hexutil.EncodeUint64(c.head().number), nil
+}
+
+func (c *chain) pbftView(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	return "0x0", nil
+}
+
+func (c *chain) sealerList(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.sealers...), nil
+}
+
+func (c *chain) observerList(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.observers...), nil
+}
+
+func (c *chain) groupPeers(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(append([]string{}, c.sealers...), c.observers...), nil
+}
+
+func (c *chain) nodeIDList(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nodeIDs == nil {
+		return append(append([]string{}, c.sealers...), c.observers...), nil
+	}
+	return append([]string{}, c.nodeIDs...), nil
+}
+
+func (c *chain) groupList(params []json.RawMessage) (interface{}, error) {
+	return []int{defaultGroupID}, nil
+}
+
+func (c *chain) peers(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	nodes := append(append([]string{}, c.sealers...), c.observers...)
+	peers := []map[string]interface{}{}
+	for i, id := range nodes {
+		if i == 0 {
+			continue // the node we are connected to
+		}
+		peers = append(peers, map[string]interface{}{
+			"Agency":    "agency",
+			"IPAndPort": fmt.Sprintf("127.0.0.1:%d", 30300+i),
+			"Node":      fmt.Sprintf("node%d", i),
+			"NodeID":    id,
+			"Topic":     []string{},
+		})
+	}
+	return peers, nil
+}
+
+func (c *chain) consensusStatus(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	head := c.head()
+	status := map[string]interface{}{
+		"accountType":            1,
+		"allowFutureBlocks":      true,
+		"cfgErr":                 false,
+		"connectedNodes":         len(c.sealers) + len(c.observers) - 1,
+		"consensusedBlockNumber": head.number + 1,
+		"currentView":            0,
+		"groupId":                defaultGroupID,
+		"highestblockHash":       head.hash.Hex(),
+		"highestblockNumber":     head.number,
+		"leaderFailed":           false,
+		"nodeNum":                len(c.sealers),
+		"node_index":             0,
+		"omitEmptyBlock":         true,
+		"toView":                 0,
+	}
+	for i, id := range c.sealers {
+		status[fmt.Sprintf("sealer.%d", i)] = id
+	}
+	return []interface{}{status}, nil
+}
+
+func (c *chain) syncStatus(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	nodeID := ""
+	if len(c.sealers) > 0 {
+		nodeID = c.sealers[0]
+	}
+	return map[string]interface{}{
+		"blockNumber": c.head().number,
+		"genesisHash": strings.TrimPrefix(c.blocks[0].hash.Hex(), "0x"),
+		"isSyncing":   false,
+		"latestHash":  strings.TrimPrefix(c.head().hash.Hex(), "0x"),
+		"nodeId":      nodeID,
+		"peers":       []interface{}{},
+		"txPoolSize":  strconv.Itoa(len(c.pending)),
+	}, nil
+}
+
+func (c *chain) systemConfigByKey(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 2); err != nil {
+		return nil, err
+	}
+	key, err := stringParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.config[key]
+	if !ok {
+		return nil, rpcError(codeInvalidConfig, "Invalid System Config")
+	}
+	return value, nil
+}
+
+// Blocks and transactions.
+
+// marshalBlock encodes a block. It must be called with mu held.
+func (c *chain) marshalBlock(b *block, includeTx bool) map[string]interface{} {
+	txs := make([]interface{}, len(b.txs))
+	for i, e := range b.txs {
+		if includeTx {
+			txs[i] = marshalTx(e)
+		} else {
+			txs[i] = e.tx.Hash().Hex()
+		}
+	}
+	return map[string]interface{}{
+		"extraData":        []string{},
+		"gasLimit":         "0x0",
+		"gasUsed":          "0x0",
+		"hash":             b.hash.Hex(),
+		"logsBloom":        emptyLogsBloom,
+		"number":           hexutil.EncodeUint64(b.number),
+		"parentHash":       b.parent.Hex(),
+		"sealer":           "0x0",
+		"sealerList":       append([]string{}, c.sealers...),
+		"stateRoot":        b.hash.Hex(),
+		"timestamp":        hexutil.EncodeUint64(uint64(b.timestamp)),
+		"transactions":     txs,
+		"transactionsRoot": b.hash.Hex(),
+		"receiptsRoot":     b.hash.Hex(),
+	}
+}
+
+func marshalTx(e *txEntry) map[string]interface{} {
+	tx := map[string]interface{}{
+		"blockHash":        nil,
+		"blockNumber":      nil,
+		"from":             strings.ToLower(e.from.Hex()),
+		"gas":              encodeBig(e.tx.Gas()),
+		"gasPrice":         encodeBig(e.tx.GasPrice()),
+		"hash":             e.tx.Hash().Hex(),
+		"input":            hexutil.Encode(e.tx.Data()),
+		"nonce":            encodeBig(e.tx.Nonce()),
+		"to":               nil,
+		"transactionIndex": nil,
+		"value":            encodeBig(e.tx.Value()),
+	}
+	if to := e.tx.To(); to != nil {
+		tx["to"] = strings.ToLower(to.Hex())
+	}
+	if e.block != nil {
+		tx["blockHash"] = e.block.hash.Hex()
+		tx["blockNumber"] = hexutil.EncodeUint64(e.block.number)
+		tx["transactionIndex"] = hexutil.EncodeUint64(uint64(e.index))
+	}
+	return tx
+}
+
+// blockAt returns the block of the given number. It must be called with mu held.
+func (c *chain) blockAt(params []json.RawMessage, i int) (*block, error) {
+	n, err := numberParam(params, i)
+	if err != nil {
+		return nil, err
+	}
+	if n >= uint64(len(c.blocks)) {
+		return nil, rpcError(codeBlockNumNotExist, "BlockNumber does not exist")
+	}
+	return c.blocks[n], nil
+}
+
+// blockByHashParam returns the block of the given hash. It must be called
+// with mu held.
+func (c *chain) blockByHashParam(params []json.RawMessage, i int) (*block, error) {
+	s, err := stringParam(params, i)
+	if err != nil {
+		return nil, err
+	}
+	hash := common.HexToHash(s)
+	for _, b := range c.blocks {
+		if b.hash == hash {
+			return b, nil
+		}
+	}
+	return nil, rpcError(codeBlockHashNotExist, "BlockHash does not exist")
+}
+
+func txAt(b *block, params []json.RawMessage, i int) (interface{}, error) {
+	index, err := numberParam(params, i)
+	if err != nil {
+		return nil, err
+	}
+	if index >= uint64(len(b.txs)) {
+		return nil, rpcError(codeIndexOutOfRange, "TransactionIndex is out of range")
+	}
+	return marshalTx(b.txs[index]), nil
+}
+
+func (c *chain) blockByNumber(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 2); err != nil {
+		return nil, err
+	}
+	includeTx, err := boolParam(params, 2)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := c.blockAt(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	return c.marshalBlock(b, includeTx), nil
+}
+
+func (c *chain) blockByHash(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 2); err != nil {
+		return nil, err
+	}
+	includeTx, err := boolParam(params, 2)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := c.blockByHashParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	return c.marshalBlock(b, includeTx), nil
+}
+
+func (c *chain) blockHashByNumber(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 2); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := c.blockAt(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	return b.hash.Hex(), nil
+}
+
+func (c *chain) transactionByHash(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 2); err != nil {
+		return nil, err
+	}
+	s, err := stringParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.txs[common.HexToHash(s)]
+	if !ok {
+		return nil, nil
+	}
+	return marshalTx(e), nil
+}
+
+func (c *chain) transactionByBlockHashAndIndex(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 3); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := c.blockByHashParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	return txAt(b, params, 2)
+}
+
+func (c *chain) transactionByBlockNumberAndIndex(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 3); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := c.blockAt(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	return txAt(b, params, 2)
+}
+
+func (c *chain) transactionReceipt(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 2); err != nil {
+		return nil, err
+	}
+	s, err := stringParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.txs[common.HexToHash(s)]
+	if !ok || e.receipt == nil {
+		return nil, nil
+	}
+	return e.receipt, nil
+}
+
+func (c *chain) pendingTransactions(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	txs := []interface{}{}
+	for _, e := range c.pending {
+		txs = append(txs, marshalTx(e))
+	}
+	return txs, nil
+}
+
+func (c *chain) pendingTxSize(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return hexutil.EncodeUint64(uint64(len(c.pending))), nil
+}
+
+func (c *chain) totalTransactionCount(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 1); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total, failed uint64
+	for _, b := range c.blocks {
+		for _, e := range b.txs {
+			total++
+			if e.receipt.Status != "0x0" {
+				failed++
+			}
+		}
+	}
+	return map[string]string{
+		"blockNumber": hexutil.EncodeUint64(c.head().number),
+		"failedTxSum": hexutil.EncodeUint64(failed),
+		"txSum":       hexutil.EncodeUint64(total),
+	}, nil
+}
+
+// Contracts.
+
+func (c *chain) code(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 2); err != nil {
+		return nil, err
+	}
+	s, err := stringParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return hexutil.Encode(c.codes[common.HexToAddress(s)]), nil
+}
+
+func (c *chain) call(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 2); err != nil {
+		return nil, err
+	}
+	var arg struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(params[1], &arg); err != nil {
+		return nil, rpcError(codeInvalidParams, "invalid argument 1: %v", err)
+	}
+	c.mu.Lock()
+	callFn := c.callFn
+	number := c.head().number
+	c.mu.Unlock()
+
+	var output []byte
+	if callFn != nil {
+		var err error
+		output, err = callFn(common.HexToAddress(arg.From), common.HexToAddress(arg.To), common.FromHex(arg.Data))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return map[string]string{
+		"currentBlockNumber": hexutil.EncodeUint64(number),
+		"output":             hexutil.Encode(output),
+		"status":             "0x0",
+	}, nil
+}
+
+func (c *chain) sendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	if err := checkParams(params, 2); err != nil {
+		return nil, err
+	}
+	s, err := stringParam(params, 1)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := types.DecodeRawTransaction(s)
+	if err != nil {
+		return nil, rpcError(codeMalformedTx, "Malformed transaction: %v", err)
+	}
+	c.mu.Lock()
+	head := new(big.Int).SetUint64(c.head().number)
+	c.mu.Unlock()
+	// the node reports a known transaction before checking its blockLimit
+	from, err := tx.Verify(big.NewInt(defaultChainID), big.NewInt(defaultGroupID), head)
+	blockLimitErr := err == types.ErrInvalidBlockLimit
+	switch err {
+	case nil, types.ErrInvalidBlockLimit:
+	case types.ErrChainIdMismatch:
+		return nil, rpcError(codeInvalidChainID, "Invalid chain ID")
+	case types.ErrGroupIdMismatch:
+		return nil, rpcError(codeInvalidGroupID, "Invalid group ID")
+	default:
+		return nil, rpcError(codeMalformedTx, "Malformed transaction: %v", err)
+	}
+
+	c.mu.Lock()
+	hash := tx.Hash()
+	if e, ok := c.txs[hash]; ok {
+		c.mu.Unlock()
+		if e.block != nil {
+			return nil, rpcError(codeAlreadyInChain, "Transaction already in chain")
+		}
+		return nil, rpcError(codeAlreadyInTxPool, "Already in transaction pool")
+	}
+	if blockLimitErr {
+		c.mu.Unlock()
+		return nil, rpcError(codeBlockLimitCheck, "Block limit check fail")
+	}
+	if len(c.pending) >= c.poolLimit {
+		c.mu.Unlock()
+		return nil, rpcError(codeTxPoolIsFull, "Transaction pool is full")
+	}
+	e := &txEntry{tx: tx, from: from}
+	c.txs[hash] = e
+	c.pending = append(c.pending, e)
+	autoSeal := c.autoSeal
+	c.mu.Unlock()
+
+	if autoSeal {
+		c.seal()
+	}
+	return hash.Hex(), nil
+}
+
+// Scripting API of the default chain.
+
+// HandleTransaction sets the function executing sealed transactions. By
+// default every transaction succeeds with no output.
+func (s *Server) HandleTransaction(fn TransactionHandler) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	s.chain.execute = fn
+}
+
+// HandleCall sets the function answering read-only calls. By default every
+// call succeeds with no output.
+func (s *Server) HandleCall(fn CallHandler) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	s.chain.callFn = fn
+}
+
+// SetAutoSeal controls whether every accepted transaction is sealed into a
+// block right away (the default). If disabled, transactions wait in the pool
+// until Seal is called.
+func (s *Server) SetAutoSeal(on bool) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	s.chain.autoSeal = on
+}
+
+// SetTxPoolLimit sets the number of pending transactions after which the
+// node rejects new ones.
+func (s *Server) SetTxPoolLimit(limit int) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	s.chain.poolLimit = limit
+}
+
+// DropPending removes the pending transactions from the pool without sealing
+// them, as a node restarting or evicting them does. They never get a receipt
+// and may be sent again.
+func (s *Server) DropPending() {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	for _, e := range s.chain.pending {
+		delete(s.chain.txs, e.tx.Hash())
+	}
+	s.chain.pending = nil
+}
+
+// Seal packs all pending transactions into a new block. Calling it with no
+// pending transactions produces an empty block.
+func (s *Server) Seal() {
+	s.chain.seal()
+}
+
+// BlockNumber returns the current block height.
+func (s *Server) BlockNumber() uint64 {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	return s.chain.head().number
+}
+
+// Transactions returns all accepted transactions in arrival order, pending
+// ones included.
+func (s *Server) Transactions() []*types.RawTransaction {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	var txs []*types.RawTransaction
+	for _, b := range s.chain.blocks {
+		for _, e := range b.txs {
+			txs = append(txs, e.tx)
+		}
+	}
+	for _, e := range s.chain.pending {
+		txs = append(txs, e.tx)
+	}
+	return txs
+}
+
+// Receipt returns the receipt of a sealed transaction, or nil if it is
+// unknown or still pending.
+func (s *Server) Receipt(hash common.Hash) *types.Receipt {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	if e, ok := s.chain.txs[hash]; ok {
+		return e.receipt
+	}
+	return nil
+}
+
+// SetCode stores contract code at the given address.
+func (s *Server) SetCode(addr common.Address, code []byte) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	s.chain.codes[addr] = common.CopyBytes(code)
+}
+
+// SetSystemConfig sets a system configuration item such as tx_count_limit.
+func (s *Server) SetSystemConfig(key, value string) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	s.chain.config[key] = value
+}
+
+// SetSealerList replaces the consensus sealers of the group. The first sealer
+// is the node the server pretends to be.
+func (s *Server) SetSealerList(nodeIDs []string) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	s.chain.sealers = append([]string{}, nodeIDs...)
+}
+
+// SetObserverList replaces the observers of the group.
+func (s *Server) SetObserverList(nodeIDs []string) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	s.chain.observers = append([]string{}, nodeIDs...)
+}
+
+// SetNodeIDList replaces the IDs of the nodes the server is connected to,
+// which need not belong to the group. By default these are the group peers.
+func (s *Server) SetNodeIDList(nodeIDs []string) {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	s.chain.nodeIDs = append([]string{}, nodeIDs...)
+}
+
+// SealerList returns the current consensus sealers.
+func (s *Server) SealerList() []string {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	return append([]string{}, s.chain.sealers...)
+}
+
+// ObserverList returns the current observers.
+func (s *Server) ObserverList() []string {
+	s.chain.mu.Lock()
+	defer s.chain.mu.Unlock()
+	return append([]string{}, s.chain.observers...)
+}
@@ -0,0 +1,237 @@
+// Package clienttest provides a mock FISCO BCOS 2.x JSON-RPC node for tests.
+//
+// The node runs on an httptest server and keeps a minimal in-memory chain:
+// every accepted transaction is sealed into its own block and gets a receipt
+// immediately. All methods can be overridden with scripted responses and every
+// request received by the node is recorded.
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// HandlerFunc answers a single JSON-RPC call. The returned value is encoded as
+// the JSON-RPC result; a non-nil error is reported as a JSON-RPC error.
+type HandlerFunc func(params []json.RawMessage) (interface{}, error)
+
+// Request is a JSON-RPC request recorded by the server.
+type Request struct {
+	Method string
+	Params []json.RawMessage
+}
+
+// Param decodes the i'th positional parameter of the request into v.
+func (r Request) Param(i int, v interface{}) error {
+	if i >= len(r.Params) {
+		return fmt.Errorf("request %s has no parameter %d", r.Method, i)
+	}
+	return json.Unmarshal(r.Params[i], v)
+}
+
+// Error is a JSON-RPC error returned by a HandlerFunc.
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// ErrorCode returns the JSON-RPC error code.
+func (e *Error) ErrorCode() int { return e.Code }
+
+type jsonrpcRequest struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonrpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *jsonrpcError   `json:"error,omitempty"`
+}
+
+// Server is a mock FISCO BCOS node serving JSON-RPC over HTTP.
+type Server struct {
+	// URL is the base URL of the form http://ipaddr:port with no trailing slash.
+	URL string
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc // scripted handlers overriding the defaults
+	requests []Request              // requests in arrival order
+	batches  []int                  // sizes of the batch requests in arrival order
+	chain    *chain                 // default in-memory chain state
+}
+
+// NewServer starts and returns a new mock node for group 1 of chain 1.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		handlers: make(map[string]HandlerFunc),
+		chain:    newChain(),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts down the server and blocks until all outstanding requests
+// on this server have completed.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Handle replaces the handler of the given method.
+func (s *Server) Handle(method string, handler HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = handler
+}
+
+// SetResult makes the given method always answer with result.
+func (s *Server) SetResult(method string, result interface{}) {
+	s.Handle(method, func([]json.RawMessage) (interface{}, error) {
+		return result, nil
+	})
+}
+
+// SetError makes the given method always fail with the JSON-RPC error.
+func (s *Server) SetError(method string, code int, message string) {
+	s.Handle(method, func([]json.RawMessage) (interface{}, error) {
+		return nil, &Error{Code: code, Message: message}
+	})
+}
+
+// Reset drops the scripted handlers, so that every method is answered by the
+// default chain again.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = make(map[string]HandlerFunc)
+}
+
+// Requests returns all requests received so far, in arrival order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsFor returns the received requests of a single method.
+func (s *Server) RequestsFor(method string) []Request {
+	var reqs []Request
+	for _, req := range s.Requests() {
+		if req.Method == method {
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}
+
+// BatchSizes returns the number of calls carried by each batch request received
+// so far, in arrival order.
+func (s *Server) BatchSizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.batches...)
+}
+
+// ClearRequests forgets the recorded requests and batches.
+func (s *Server) ClearRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.batches = nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var reqs []jsonrpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			json.NewEncoder(w).Encode(parseErrorResponse(err))
+			return
+		}
+		s.mu.Lock()
+		s.batches = append(s.batches, len(reqs))
+		s.mu.Unlock()
+		resps := make([]*jsonrpcResponse, len(reqs))
+		for i := range reqs {
+			resps[i] = s.dispatch(&reqs[i])
+		}
+		json.NewEncoder(w).Encode(resps)
+		return
+	}
+	var req jsonrpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		json.NewEncoder(w).Encode(parseErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(s.dispatch(&req))
+}
+
+func parseErrorResponse(err error) *jsonrpcResponse {
+	return &jsonrpcResponse{Version: "2.0", ID: json.RawMessage("null"), Error: &jsonrpcError{Code: -32700, Message: err.Error()}}
+}
+
+// dispatch records the request and runs its handler.
+func (s *Server) dispatch(req *jsonrpcRequest) *jsonrpcResponse {
+	var params []json.RawMessage
+	if len(req.Params) > 0 && string(req.Params) != "null" {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return &jsonrpcResponse{Version: "2.0", ID: req.ID, Error: &jsonrpcError{Code: -32602, Message: "non-array args"}}
+		}
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: req.Method, Params: params})
+	handler, ok := s.handlers[req.Method]
+	s.mu.Unlock()
+
+	if !ok {
+		handler, ok = s.chain.handler(req.Method)
+	}
+	if !ok {
+		return &jsonrpcResponse{Version: "2.0", ID: req.ID, Error: &jsonrpcError{
+			Code:    -32601,
+			Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method),
+		}}
+	}
+	result, err := handler(params)
+	if err != nil {
+		code := -32000
+		if ec, ok := err.(interface{ ErrorCode() int }); ok {
+			code = ec.ErrorCode()
+		}
+		return &jsonrpcResponse{Version: "2.0", ID: req.ID, Error: &jsonrpcError{Code: code, Message: err.Error()}}
+	}
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	return &jsonrpcResponse{Version: "2.0", ID: req.ID, Result: result}
+}
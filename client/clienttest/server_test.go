@@ -0,0 +1,74 @@
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func post(t *testing.T, srv *Server, body string) []byte {
+	resp, err := http.Post(srv.URL, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	defer resp.Body.Close()
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	return out
+}
+
+func TestScriptedResponses(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	srv.SetResult("getBlockNumber", "0x10")
+	srv.SetError("getPbftView", -40007, "Only pbft consensus supports the view property")
+	out := post(t, srv, `[
+		{"jsonrpc":"2.0","id":1,"method":"getBlockNumber","params":[1]},
+		{"jsonrpc":"2.0","id":2,"method":"getPbftView","params":[1]},
+		{"jsonrpc":"2.0","id":3,"method":"getSealerList","params":[2]},
+		{"jsonrpc":"2.0","id":4,"method":"unknown","params":[]}
+	]`)
+	var resps []jsonrpcResponse
+	if err := json.Unmarshal(out, &resps); err != nil {
+		t.Fatalf("invalid batch response %s: %v", out, err)
+	}
+	if len(resps) != 4 {
+		t.Fatalf("have %d responses, want 4", len(resps))
+	}
+	if resps[0].Result != "0x10" {
+		t.Errorf("scripted result mismatch: have %v", resps[0].Result)
+	}
+	for i, code := range []int{-40007, -40001, -32601} {
+		if resp := resps[i+1]; resp.Error == nil || resp.Error.Code != code {
+			t.Errorf("response %d: have error %+v, want code %d", i+1, resp.Error, code)
+		}
+	}
+
+	if reqs := srv.RequestsFor("getBlockNumber"); len(reqs) != 1 {
+		t.Fatalf("recorded %d getBlockNumber requests, want 1", len(reqs))
+	} else {
+		var group int
+		if err := reqs[0].Param(0, &group); err != nil || group != 1 {
+			t.Errorf("recorded group mismatch: have %d (%v)", group, err)
+		}
+	}
+
+	srv.Reset()
+	srv.ClearRequests()
+	out = post(t, srv, `{"jsonrpc":"2.0","id":1,"method":"getBlockNumber","params":[1]}`)
+	var resp jsonrpcResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("invalid response %s: %v", out, err)
+	}
+	if resp.Result != "0x0" {
+		t.Errorf("default result mismatch: have %v, want 0x0", resp.Result)
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Errorf("recorded %d requests after ClearRequests, want 1", n)
+	}
+}
@@ -23,18 +23,33 @@ import (
 	"fmt"
 	"math/big"
 	"errors"
+	"strconv"
+	"sync"
 
 	"github.com/KasperLiu/gobcos/common"
 	"github.com/KasperLiu/gobcos/common/hexutil"
 	"github.com/KasperLiu/gobcos/rpc"
 	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/log"
 	"github.com/KasperLiu/gobcos/rlp"
 )
 
+const (
+	// DefaultBatchSize is the default number of calls carried by a single
+	// JSON-RPC batch request of the bulk read methods.
+	DefaultBatchSize = 100
+	// DefaultBatchConcurrency is the default number of batch requests of a
+	// bulk read that may be in flight at the same time.
+	DefaultBatchConcurrency = 4
+)
+
 // Client defines typed wrappers for the Ethereum RPC API. 
 type Client struct {
 	c       *rpc.Client
 	groupID uint
+
+	batchSize        int // calls per batch request of the bulk read methods
+	batchConcurrency int // batch requests in flight at the same time
 }
 
 // Dial connects a client to the given URL and groupID.
@@ -49,17 +64,44 @@ func DialContext(ctx context.Context, rawurl string, groupID uint) (*Client, err
 		return nil, err
 	}
 	client := NewClient(c, groupID)
-	_, err = client.GetClientVersion(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("the RPC server does not support the FISCO BCOS RPC API: GroupID or URL is wrong")
+	if err := client.checkConnection(context.Background(), rawurl); err != nil {
+		return nil, err
 	}
-
 	return client, nil
 }
 
+// checkConnection checks that the node at rawurl serves the FISCO BCOS RPC API.
+func (gc *Client) checkConnection(ctx context.Context, rawurl string) error {
+	if _, err := gc.GetClientVersion(ctx); err != nil {
+		log.Warn("Failed to connect to the node", "url", rawurl, "group", gc.groupID, "err", err)
+		return fmt.Errorf("the RPC server does not support the FISCO BCOS RPC API: GroupID or URL is wrong")
+	}
+	log.Info("Connected to the node", "url", rawurl, "group", gc.groupID)
+	return nil
+}
+
 // NewClient creates a client that uses the given RPC client.
 func NewClient(c *rpc.Client, groupID uint) *Client {
-	return &Client{c: c, groupID: groupID}
+	return &Client{c: c, groupID: groupID, batchSize: DefaultBatchSize, batchConcurrency: DefaultBatchConcurrency}
+}
+
+// SetBatchSize sets the number of calls carried by a single batch request of the
+// bulk read methods, e.g. GetTransactionReceipts. A size below 1 restores the
+// default.
+func (gc *Client) SetBatchSize(size int) {
+	if size < 1 {
+		size = DefaultBatchSize
+	}
+	gc.batchSize = size
+}
+
+// SetBatchConcurrency sets the number of batch requests of a bulk read that may
+// be in flight at the same time. A limit below 1 restores the default.
+func (gc *Client) SetBatchConcurrency(limit int) {
+	if limit < 1 {
+		limit = DefaultBatchConcurrency
+	}
+	gc.batchConcurrency = limit
 }
 
 // Close disconnects the rpc
@@ -176,12 +218,27 @@ func (gc *Client) PendingCallContract(ctx context.Context, msg common.CallMsg) (
 func (gc *Client) SendTransaction(ctx context.Context, tx *types.RawTransaction) error {
 	data, err := rlp.EncodeToBytes(tx)
 	if err != nil {
-		fmt.Printf("rlp encode tx error!")
+		log.Error("Failed to encode the transaction", "err", err)
 		return err
 	}
 	return gc.c.CallContext(ctx, nil, "sendRawTransaction", gc.groupID, common.ToHex(data))
 }
 
+// VerifyTransaction checks a signed transaction against the chain the client is connected to:
+// the signature, the chain ID, the group ID and whether the blockLimit is still valid for the
+// current block. It returns the address of the sender.
+func (gc *Client) VerifyTransaction(ctx context.Context, tx *types.RawTransaction) (common.Address, error) {
+	chainID, err := gc.GetChainID(ctx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var blockNumber hexutil.Big
+	if err := gc.c.CallContext(ctx, &blockNumber, "getBlockNumber", gc.groupID); err != nil {
+		return common.Address{}, err
+	}
+	return tx.Verify(chainID, gc.GetGroupID(), (*big.Int)(&blockNumber))
+}
+
 // TransactionReceipt returns the receipt of a transaction by transaction hash.
 // Note that the receipt is not available for pending transactions.
 func (gc *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
@@ -474,6 +531,134 @@ func (gc *Client) GetTransactionReceipt(ctx context.Context, txhash string) (*ty
 	return raw, err
 }
 
+// GetBlocksByNumberRange returns the information of the blocks from number from
+// to number to (both inclusive), in order. The calls are sent in batches, see
+// SetBatchSize and SetBatchConcurrency. The i'th element of the returned errors
+// is the error of the i'th block, whose information is nil then; the final error
+// is only set if the range is invalid or ctx is done before all batches are sent.
+func (gc *Client) GetBlocksByNumberRange(ctx context.Context, from, to uint64, includetx bool) ([][]byte, []error, error) {
+	if from > to {
+		return nil, nil, fmt.Errorf("invalid block range [%d, %d]", from, to)
+	}
+	raws := make([]interface{}, to-from+1)
+	batch := make([]rpc.BatchElem, len(raws))
+	for i := range batch {
+		batch[i] = rpc.BatchElem{
+			Method: "getBlockByNumber",
+			Args:   []interface{}{gc.groupID, hexutil.EncodeUint64(from + uint64(i)), includetx},
+			Result: &raws[i],
+		}
+	}
+	err := gc.batchCall(ctx, batch)
+	blocks, errs := marshalBatch(batch, raws, "Block not found")
+	return blocks, errs, err
+}
+
+// GetTransactionsByHashes returns the information of the transactions with the
+// given hashes, in order. The calls are sent in batches, see SetBatchSize and
+// SetBatchConcurrency. The i'th element of the returned errors is the error of the
+// i'th transaction, whose information is nil then; the final error is only set if
+// ctx is done before all batches are sent.
+func (gc *Client) GetTransactionsByHashes(ctx context.Context, txHashes []common.Hash) ([][]byte, []error, error) {
+	raws := make([]interface{}, len(txHashes))
+	batch := make([]rpc.BatchElem, len(txHashes))
+	for i, hash := range txHashes {
+		batch[i] = rpc.BatchElem{
+			Method: "getTransactionByHash",
+			Args:   []interface{}{gc.groupID, hash.Hex()},
+			Result: &raws[i],
+		}
+	}
+	err := gc.batchCall(ctx, batch)
+	txs, errs := marshalBatch(batch, raws, "Transaction not found")
+	return txs, errs, err
+}
+
+// GetTransactionReceipts returns the receipts of the transactions with the given
+// hashes, in order. The calls are sent in batches, see SetBatchSize and
+// SetBatchConcurrency. The i'th element of the returned errors is the error of the
+// i'th receipt, which is nil then, and common.NotFound for a transaction that is
+// not mined yet; the final error is only set if ctx is done before all batches
+// are sent.
+func (gc *Client) GetTransactionReceipts(ctx context.Context, txHashes []common.Hash) ([]*types.Receipt, []error, error) {
+	receipts := make([]*types.Receipt, len(txHashes))
+	batch := make([]rpc.BatchElem, len(txHashes))
+	for i, hash := range txHashes {
+		batch[i] = rpc.BatchElem{
+			Method: "getTransactionReceipt",
+			Args:   []interface{}{gc.groupID, hash.Hex()},
+			Result: &receipts[i],
+		}
+	}
+	err := gc.batchCall(ctx, batch)
+	errs := make([]error, len(batch))
+	for i, elem := range batch {
+		switch {
+		case elem.Error != nil:
+			errs[i], receipts[i] = elem.Error, nil
+		case receipts[i] == nil:
+			errs[i] = common.NotFound
+		}
+	}
+	return receipts, errs, err
+}
+
+// batchCall sends the calls in batches of at most batchSize elements, with at most
+// batchConcurrency batches in flight. The failure of a whole batch is recorded as
+// the error of each of its elements. If ctx is done before all batches are sent,
+// ctx.Err() is returned and recorded as the error of the unsent elements.
+func (gc *Client) batchCall(ctx context.Context, batch []rpc.BatchElem) error {
+	var (
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, gc.batchConcurrency)
+		done = ctx.Done()
+	)
+	for start := 0; start < len(batch); start += gc.batchSize {
+		end := start + gc.batchSize
+		if end > len(batch) {
+			end = len(batch)
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-done:
+			for i := start; i < len(batch); i++ {
+				batch[i].Error = ctx.Err()
+			}
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(elems []rpc.BatchElem) {
+			defer func() { <-sem; wg.Done() }()
+			if err := gc.c.BatchCallContext(ctx, elems); err != nil {
+				for i := range elems {
+					elems[i].Error = err
+				}
+			}
+		}(batch[start:end])
+	}
+	wg.Wait()
+	return nil
+}
+
+// marshalBatch formats the raw results of a batch like the single-call methods do,
+// reporting null results with the notFound error.
+func marshalBatch(batch []rpc.BatchElem, raws []interface{}, notFound string) ([][]byte, []error) {
+	results := make([][]byte, len(batch))
+	errs := make([]error, len(batch))
+	for i, elem := range batch {
+		switch {
+		case elem.Error != nil:
+			errs[i] = elem.Error
+		case raws[i] == nil:
+			errs[i] = errors.New(notFound)
+		default:
+			results[i], errs[i] = json.MarshalIndent(raws[i], "", "\t")
+		}
+	}
+	return results, errs
+}
+
 // GetContractAddress returns a contract address according to the transaction hash 
 func (gc *Client) GetContractAddress(ctx context.Context, txhash string) (common.Address, error) {
 	var raw interface{}
@@ -521,6 +706,24 @@ func (gc *Client) GetPendingTxSize(ctx context.Context) ([]byte, error) {
 	return js, err
 }
 
+// PendingTxSize returns the number of transactions in the txpool
+func (gc *Client) PendingTxSize(ctx context.Context) (uint64, error) {
+	var size hexutil.Uint64
+	if err := gc.c.CallContext(ctx, &size, "getPendingTxSize", gc.groupID); err != nil {
+		return 0, err
+	}
+	return uint64(size), nil
+}
+
+// BlockNumber returns the current block height of the group
+func (gc *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	var number hexutil.Uint64
+	if err := gc.c.CallContext(ctx, &number, "getBlockNumber", gc.groupID); err != nil {
+		return 0, err
+	}
+	return uint64(number), nil
+}
+
 // GetCode returns the contract code according to the contract address
 func (gc *Client) GetCode(ctx context.Context, addr string) ([]byte, error) {
 	var raw interface{}
@@ -553,3 +756,17 @@ func (gc *Client) GetSystemConfigByKey(ctx context.Context, findkey string) ([]b
 	js, err := json.MarshalIndent(raw, "", "\t")
 	return js, err
 }
+
+// TxCountLimit returns the system configuration tx_count_limit, the maximum number of
+// transactions in a block
+func (gc *Client) TxCountLimit(ctx context.Context) (uint64, error) {
+	var raw string
+	if err := gc.c.CallContext(ctx, &raw, "getSystemConfigByKey", gc.groupID, "tx_count_limit"); err != nil {
+		return 0, err
+	}
+	limit, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tx_count_limit %q: %v", raw, err)
+	}
+	return limit, nil
+}
@@ -1,22 +1,80 @@
 package client
 
 import (
-	"testing"
+	"bytes"
 	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/crypto"
+	"github.com/KasperLiu/gobcos/log"
+	"github.com/KasperLiu/gobcos/rlp"
 )
 
-func GetClient(t *testing.T) (*Client) {
+const testPrivateKey = "145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b58"
+
+// GetClient starts a mock node and returns a client connected to it.
+func GetClient(t *testing.T) (*Client, *clienttest.Server) {
+	srv := clienttest.NewServer()
 	// RPC API
 	groupID := uint(1)
-	c, err := Dial("http://localhost:8545", groupID)  // change to your RPC and groupID
+	c, err := Dial(srv.URL, groupID)
 	if err != nil {
+		srv.Close()
 		t.Fatalf("can not dial to the RPC API: %v", err)
 	}
-	return c
+	return c, srv
+}
+
+// SignTestTransaction signs a transaction (a contract creation if to is nil)
+// for the chain the client is connected to.
+func SignTestTransaction(t *testing.T, c *Client, to *common.Address, data []byte) *types.RawTransaction {
+	key, err := crypto.HexToECDSA(testPrivateKey)
+	if err != nil {
+		t.Fatalf("invalid private key: %v", err)
+	}
+	blockLimit, err := c.GetBlockLimit(context.Background())
+	if err != nil {
+		t.Fatalf("blockLimit not found: %v", err)
+	}
+	chainID, err := c.GetChainID(context.Background())
+	if err != nil {
+		t.Fatalf("Chain ID not found: %v", err)
+	}
+	gas := big.NewInt(30000000)
+	var rawTx *types.RawTransaction
+	if to == nil {
+		rawTx = types.NewRawContractCreation(big.NewInt(1), new(big.Int), gas, gas, blockLimit, data, chainID, c.GetGroupID(), nil)
+	} else {
+		rawTx = types.NewRawTransaction(big.NewInt(1), *to, new(big.Int), gas, gas, blockLimit, data, chainID, c.GetGroupID(), nil)
+	}
+	signedTx, err := types.SignRawTx(rawTx, types.HomesteadRawSigner{}, key)
+	if err != nil {
+		t.Fatalf("sign transaction failed: %v", err)
+	}
+	return signedTx
+}
+
+// SendTestTransaction signs a transaction (a contract creation if to is nil)
+// and sends it to the node, returning its hash.
+func SendTestTransaction(t *testing.T, c *Client, to *common.Address, data []byte) common.Hash {
+	signedTx := SignTestTransaction(t, c, to, data)
+	if err := c.SendTransaction(context.Background(), signedTx); err != nil {
+		t.Fatalf("send transaction failed: %v", err)
+	}
+	return signedTx.Hash()
 }
 
 func TestClientVersion(t *testing.T) {
-	c := GetClient(t)
+	c, srv := GetClient(t)
+	defer srv.Close()
 
 	cv, err := c.GetClientVersion(context.Background())
 	if err != nil {
@@ -27,19 +85,33 @@ func TestClientVersion(t *testing.T) {
 }
 
 func TestBlockNumber(t *testing.T) {
-    c := GetClient(t)
-	// cannot use big.NewInt to construct json request
-	// TODO: analysis the ethereum's big.NewInt
+	c, srv := GetClient(t)
+	defer srv.Close()
+
 	bn, err := c.GetBlockNumber(context.Background())
 	if err != nil {
 		t.Fatalf("block number not found: %v", err)
 	}
+	if string(bn) != `"0x0"` {
+		t.Fatalf("block number mismatch: have %s, want \"0x0\"", bn)
+	}
+
+	SendTestTransaction(t, c, nil, []byte{0x60, 0x80})
+	bn, err = c.GetBlockNumber(context.Background())
+	if err != nil {
+		t.Fatalf("block number not found: %v", err)
+	}
+	if string(bn) != `"0x1"` {
+		t.Fatalf("block number mismatch: have %s, want \"0x1\"", bn)
+	}
 
 	t.Logf("latest block number: \n%s", bn)
 }
 
 func TestPBFTView(t *testing.T) {
-	c := GetClient(t)
+	c, srv := GetClient(t)
+	defer srv.Close()
+
 	pv, err := c.GetPBFTView(context.Background())
 	if err != nil {
 		t.Fatalf("PBFT view not found: %v", err)
@@ -48,272 +120,528 @@ func TestPBFTView(t *testing.T) {
 	t.Logf("PBFT view: \n%s", pv)
 }
 
-// func TestBlockLimit(t *testing.T) {
-//     c := GetClient(t)
-// 	// cannot use big.NewInt to construct json request
-// 	// TODO: analysis the ethereum's big.NewInt
-// 	bl, err := c.GetBlockLimit(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("blockLimit not found: %v", err)
-// 	}
-
-// 	t.Logf("latest blockLimit: \n%s", bl)
-// }
-
-// func TestGroupID(t *testing.T) {
-//     c := GetClient(t)
-// 	// cannot use big.NewInt to construct json request
-// 	// TODO: analysis the ethereum's big.NewInt
-// 	groupid := c.GetGroupID()
-// 	t.Logf("current groupID: \n%s", groupid)
-// }
-
-// func TestChainID(t *testing.T) {
-//     c := GetClient(t)
-// 	// cannot use big.NewInt to construct json request
-// 	// TODO: analysis the ethereum's big.NewInt
-// 	chainid, err := c.GetChainID(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("Chain ID not found: %v", err)
-// 	}
-// 	t.Logf("Chain ID: \n%s", chainid)
-// }
-
-// func TestSealerList(t *testing.T) {
-// 	c := GetClient(t)
-// 	sl, err := c.GetSealerList(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("sealer list not found: %v", err)
-// 	}
-
-// 	t.Logf("sealer list:\n%s", sl)
-// }
-
-// func TestObserverList(t *testing.T) {
-// 	c := GetClient(t)
-// 	ol, err := c.GetObserverList(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("observer list not found: %v", err)
-// 	}
-
-// 	t.Logf("observer list:\n%s", ol)
-// }
-
-// func TestConsensusStatus(t *testing.T) {
-// 	c := GetClient(t)
-// 	status, err := c.GetConsensusStatus(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("consensus status not found: %v", err)
-// 	}
-
-// 	t.Logf("consensus status:\n%s", status)
-// }
-
-// func TestSyncStatus(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	raw, err := c.GetSyncStatus(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("synchronization status not found: %v", err)
-// 	}
-
-// 	t.Logf("synchronization Status:\n%s", raw)
-// }
-
-// func TestPeers(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	raw, err := c.GetPeers(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("peers not found: %v", err)
-// 	}
-
-// 	t.Logf("peers:\n%s", raw)
-// }
-
-// func TestGroupPeers(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	raw, err := c.GetGroupPeers(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("group peers not found: %v", err)
-// 	}
-
-// 	t.Logf("group peers:\n%s", raw)
-// }
-
-// func TestNodeIDList(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	raw, err := c.GetNodeIDList(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("nodeID list not found: %v", err)
-// 	}
-    
-// 	t.Logf("nodeID list:\n %s", raw)
-// }
-
-// func TestGroupList(t *testing.T) {
-// 	c := GetClient(t)
-// 	raw, err := c.GetGroupList(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("group list not found: %v", err)
-// 	}
-
-// 	t.Logf("group list:\n%s", raw)
-// }
-
-// func TestBlockByHash(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	bhash := "0xc0b21d064b97bafda716e07785fe8bb20cc23506bb980f12c7f7a4f4ef50ce30"
-// 	includeTx := false
-// 	raw, err := c.GetBlockByHash(context.Background(), bhash, includeTx)
-// 	if err != nil {
-// 		t.Fatalf("block not found: %v", err)
-// 	}
-
-// 	t.Logf("block by hash:\n%s", raw)
-// }
-
-// func TestBlockByNumber(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	bnum := "0x1"
-// 	includeTx := true
-// 	raw, err := c.GetBlockByNumber(context.Background(), bnum, includeTx)
-// 	if err != nil {
-// 		t.Fatalf("block not found: %v", err)
-// 	}
-
-// 	t.Logf("block by number:\n%s", raw)
-// }
-
-// func TestBlockHashByNumber(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	bnum := "0x1"
-// 	raw, err := c.GetBlockHashByNumber(context.Background(), bnum)
-// 	if err != nil {
-// 		t.Fatalf("block hash not found: %v", err)
-// 	}
-
-// 	t.Logf("block hash by number:\n%s", raw)
-// }
-
-// func TestTransactionByHash(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	txhash := "0xed51827558939e8d103cbf8f6ff37f8a99582f09afa29e5636d0e54a073d0893"
-// 	raw, err := c.GetTransactionByHash(context.Background(), txhash)
-// 	if err != nil {
-// 		t.Fatalf("transaction not found: %v", err)
-// 	}
-
-// 	t.Logf("transaction by hash:\n%s", raw)
-// }
-
-// func TestTransactionByBlockHashAndIndex(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	bhash := "0xc0b21d064b97bafda716e07785fe8bb20cc23506bb980f12c7f7a4f4ef50ce30"
-// 	txindex := "0x0"
-// 	raw, err := c.GetTransactionByBlockHashAndIndex(context.Background(), bhash, txindex)
-// 	if err != nil {
-// 		t.Fatalf("transaction not found: %v", err)
-// 	}
-
-// 	t.Logf("transaction by block hash and transaction index:\n%s", raw)
-// }
-
-// func TestTransactionByBlockNumberAndIndex(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	bnum := "0x1"
-// 	txindex := "0x0"
-// 	raw, err := c.GetTransactionByBlockNumberAndIndex(context.Background(), bnum, txindex)
-// 	if err != nil {
-// 		t.Fatalf("transaction not found: %v", err)
-// 	}
-
-// 	t.Logf("transaction by block number and transaction index:\n%s", raw)
-// }
-
-// func TestTransactionReceipt(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	txhash := "0x6613da911621248ffc2983a4553d44fb299887ae2d803eb81d9890465fbf29e5"
-// 	raw, err := c.GetTransactionReceipt(context.Background(), txhash)
-// 	if err != nil {
-// 		t.Fatalf("transaction receipt not found: %v", err)
-// 	}
-// 	t.Logf("transaction receipt by transaction hash:\n%s", raw)
-// }
-
-// func TestContractAddress(t *testing.T) {
-// 	c := GetClient(t)
-// 	txhash := "0x4a2a4d878318a83491383d29d6550c088bdcf692e3055b060342dcd85177c621"
-// 	ca, err := c.GetContractAddress(context.Background(), txhash)
-// 	if err != nil {
-// 		t.Fatalf("ContractAddress not found: %v", err)
-// 	}
-
-// 	t.Logf("ContractAddress: \n%s", ca.String())
-// }
-
-// func TestPendingTransactions(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	raw, err := c.GetPendingTransactions(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("pending transactions not found: %v", err)
-// 	}
-
-// 	t.Logf("pending transactions:\n%s", raw)
-// }
-
-// func TestPendingTxSize(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	raw, err := c.GetPendingTxSize(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("pending transactions not found: %v", err)
-// 	}
-
-// 	t.Logf("the amount of the pending transactions:\n%s", raw)
-// }
-
-// func TestGetCode(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	addr := "0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292"
-// 	raw, err := c.GetCode(context.Background(), addr)
-// 	if err != nil {
-// 		t.Fatalf("contract not found: %v", err)
-// 	}
-
-// 	t.Logf("the contract code:\n%s", raw)
-// }
-
-// func TestTotalTransactionCount(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	raw, err := c.GetTotalTransactionCount(context.Background())
-// 	if err != nil {
-// 		t.Fatalf("transactions not found: %v", err)
-// 	}
-
-// 	t.Logf("the totoal transactions and present block height:\n%s", raw)
-// }
-
-// func TestSystemConfigByKey(t *testing.T) {
-// 	c := GetClient(t)
-	
-// 	findkey := "tx_count_limit"
-// 	raw, err := c.GetSystemConfigByKey(context.Background(), findkey)
-// 	if err != nil {
-// 		t.Fatalf("the value not found: %v", err)
-// 	}
-
-// 	t.Logf("the value got by the key:\n%s", raw)
-// }
+func TestBlockLimit(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	bl, err := c.GetBlockLimit(context.Background())
+	if err != nil {
+		t.Fatalf("blockLimit not found: %v", err)
+	}
+	if bl.Cmp(big.NewInt(500)) != 0 {
+		t.Fatalf("blockLimit mismatch: have %v, want 500", bl)
+	}
+
+	t.Logf("latest blockLimit: \n%s", bl)
+}
+
+func TestGroupID(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	groupid := c.GetGroupID()
+	t.Logf("current groupID: \n%s", groupid)
+}
+
+func TestChainID(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	chainid, err := c.GetChainID(context.Background())
+	if err != nil {
+		t.Fatalf("Chain ID not found: %v", err)
+	}
+	if chainid.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("Chain ID mismatch: have %v, want 1", chainid)
+	}
+	t.Logf("Chain ID: \n%s", chainid)
+}
+
+func TestSealerList(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	sl, err := c.GetSealerList(context.Background())
+	if err != nil {
+		t.Fatalf("sealer list not found: %v", err)
+	}
+
+	t.Logf("sealer list:\n%s", sl)
+}
+
+func TestObserverList(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	ol, err := c.GetObserverList(context.Background())
+	if err != nil {
+		t.Fatalf("observer list not found: %v", err)
+	}
+
+	t.Logf("observer list:\n%s", ol)
+}
+
+func TestConsensusStatus(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	status, err := c.GetConsensusStatus(context.Background())
+	if err != nil {
+		t.Fatalf("consensus status not found: %v", err)
+	}
+
+	t.Logf("consensus status:\n%s", status)
+}
+
+func TestSyncStatus(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	raw, err := c.GetSyncStatus(context.Background())
+	if err != nil {
+		t.Fatalf("synchronization status not found: %v", err)
+	}
+
+	t.Logf("synchronization Status:\n%s", raw)
+}
+
+func TestPeers(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	raw, err := c.GetPeers(context.Background())
+	if err != nil {
+		t.Fatalf("peers not found: %v", err)
+	}
+
+	t.Logf("peers:\n%s", raw)
+}
+
+func TestGroupPeers(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	raw, err := c.GetGroupPeers(context.Background())
+	if err != nil {
+		t.Fatalf("group peers not found: %v", err)
+	}
+
+	t.Logf("group peers:\n%s", raw)
+}
+
+func TestNodeIDList(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	raw, err := c.GetNodeIDList(context.Background())
+	if err != nil {
+		t.Fatalf("nodeID list not found: %v", err)
+	}
+
+	t.Logf("nodeID list:\n %s", raw)
+}
+
+func TestGroupList(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	raw, err := c.GetGroupList(context.Background())
+	if err != nil {
+		t.Fatalf("group list not found: %v", err)
+	}
+
+	t.Logf("group list:\n%s", raw)
+}
+
+func TestBlockByHash(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	txhash := SendTestTransaction(t, c, nil, []byte{0x60, 0x80})
+	bhash := srv.Receipt(txhash).BlockHash
+	includeTx := false
+	raw, err := c.GetBlockByHash(context.Background(), bhash, includeTx)
+	if err != nil {
+		t.Fatalf("block not found: %v", err)
+	}
+	if !strings.Contains(string(raw), txhash.Hex()) {
+		t.Fatalf("block does not contain transaction %s:\n%s", txhash.Hex(), raw)
+	}
+
+	t.Logf("block by hash:\n%s", raw)
+}
+
+func TestBlockByNumber(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	SendTestTransaction(t, c, nil, []byte{0x60, 0x80})
+	bnum := "0x1"
+	includeTx := true
+	raw, err := c.GetBlockByNumber(context.Background(), bnum, includeTx)
+	if err != nil {
+		t.Fatalf("block not found: %v", err)
+	}
+
+	t.Logf("block by number:\n%s", raw)
+
+	if _, err := c.GetBlockByNumber(context.Background(), "0x2", includeTx); err == nil {
+		t.Fatalf("expected an error for a block that does not exist")
+	}
+}
+
+func TestBlockHashByNumber(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	bnum := "0x0"
+	raw, err := c.GetBlockHashByNumber(context.Background(), bnum)
+	if err != nil {
+		t.Fatalf("block hash not found: %v", err)
+	}
+
+	t.Logf("block hash by number:\n%s", raw)
+}
+
+func TestTransactionByHash(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	txhash := SendTestTransaction(t, c, nil, []byte{0x60, 0x80}).Hex()
+	raw, err := c.GetTransactionByHash(context.Background(), txhash)
+	if err != nil {
+		t.Fatalf("transaction not found: %v", err)
+	}
+
+	t.Logf("transaction by hash:\n%s", raw)
+}
+
+func TestTransactionByBlockHashAndIndex(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	txhash := SendTestTransaction(t, c, nil, []byte{0x60, 0x80})
+	bhash := srv.Receipt(txhash).BlockHash
+	txindex := "0x0"
+	raw, err := c.GetTransactionByBlockHashAndIndex(context.Background(), bhash, txindex)
+	if err != nil {
+		t.Fatalf("transaction not found: %v", err)
+	}
+
+	t.Logf("transaction by block hash and transaction index:\n%s", raw)
+}
+
+func TestTransactionByBlockNumberAndIndex(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	SendTestTransaction(t, c, nil, []byte{0x60, 0x80})
+	bnum := "0x1"
+	txindex := "0x0"
+	raw, err := c.GetTransactionByBlockNumberAndIndex(context.Background(), bnum, txindex)
+	if err != nil {
+		t.Fatalf("transaction not found: %v", err)
+	}
+
+	t.Logf("transaction by block number and transaction index:\n%s", raw)
+}
+
+func TestTransactionReceipt(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	to := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+		return &clienttest.Execution{Output: []byte{0x01}}
+	})
+	txhash := SendTestTransaction(t, c, &to, []byte{0x01, 0x02}).Hex()
+	raw, err := c.GetTransactionReceipt(context.Background(), txhash)
+	if err != nil {
+		t.Fatalf("transaction receipt not found: %v", err)
+	}
+	if raw.GetStatus() != "0x0" || raw.GetOutput() != "0x01" || raw.GetTo() != strings.ToLower(to.Hex()) {
+		t.Fatalf("unexpected transaction receipt: %+v", raw)
+	}
+	t.Logf("transaction receipt by transaction hash:\n%s", raw)
+}
+
+func TestTransactionReceipts(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	to := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	mined := SendTestTransaction(t, c, &to, []byte{0x01})
+	srv.SetAutoSeal(false)
+	pending := SendTestTransaction(t, c, &to, []byte{0x02})
+	srv.ClearRequests()
+
+	receipts, errs, err := c.GetTransactionReceipts(context.Background(), []common.Hash{mined, pending})
+	if err != nil {
+		t.Fatalf("transaction receipts not found: %v", err)
+	}
+	if len(receipts) != 2 || receipts[0] == nil || receipts[0].TransactionHash != mined.Hex() || errs[0] != nil {
+		t.Fatalf("unexpected transaction receipts: %v (%v)", receipts, errs)
+	}
+	if receipts[1] != nil || errs[1] != common.NotFound {
+		t.Fatalf("pending transaction: have %v (%v), want %v", receipts[1], errs[1], common.NotFound)
+	}
+	if reqs := srv.RequestsFor("getTransactionReceipt"); len(reqs) != 2 {
+		t.Fatalf("unexpected requests: %v", reqs)
+	}
+}
+
+func TestBulkReads(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	to := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	var hashes []common.Hash
+	for i := 0; i < 7; i++ {
+		hashes = append(hashes, SendTestTransaction(t, c, &to, []byte{byte(i)}))
+	}
+	missing := common.HexToHash("0x01")
+	hashes = append(hashes, missing)
+	c.SetBatchSize(3)
+	c.SetBatchConcurrency(2)
+	srv.ClearRequests()
+
+	receipts, errs, err := c.GetTransactionReceipts(context.Background(), hashes)
+	if err != nil {
+		t.Fatalf("transaction receipts not found: %v", err)
+	}
+	if sizes := srv.BatchSizes(); !reflect.DeepEqual(sizes, []int{3, 3, 2}) {
+		t.Fatalf("unexpected batch sizes: %v", sizes)
+	}
+	for i, hash := range hashes[:7] {
+		if errs[i] != nil || receipts[i] == nil || receipts[i].TransactionHash != hash.Hex() {
+			t.Fatalf("receipt %d mismatch: %v (%v)", i, receipts[i], errs[i])
+		}
+	}
+	if receipts[7] != nil || errs[7] == nil {
+		t.Fatalf("expected an error for a missing receipt, have %v", receipts[7])
+	}
+
+	txs, errs, err := c.GetTransactionsByHashes(context.Background(), hashes)
+	if err != nil {
+		t.Fatalf("transactions not found: %v", err)
+	}
+	for i, hash := range hashes[:7] {
+		if errs[i] != nil || !strings.Contains(string(txs[i]), hash.Hex()) {
+			t.Fatalf("transaction %d mismatch: %s (%v)", i, txs[i], errs[i])
+		}
+	}
+	if txs[7] != nil || errs[7] == nil {
+		t.Fatalf("expected an error for a missing transaction, have %s", txs[7])
+	}
+
+	// Every transaction is sealed into its own block, block 8 does not exist
+	blocks, errs, err := c.GetBlocksByNumberRange(context.Background(), 1, 8, false)
+	if err != nil {
+		t.Fatalf("blocks not found: %v", err)
+	}
+	for i, hash := range hashes[:7] {
+		if errs[i] != nil || !strings.Contains(string(blocks[i]), hash.Hex()) {
+			t.Fatalf("block %d mismatch: %s (%v)", i+1, blocks[i], errs[i])
+		}
+	}
+	if blocks[7] != nil || errs[7] == nil {
+		t.Fatalf("expected an error for a missing block, have %s", blocks[7])
+	}
+	if _, _, err := c.GetBlocksByNumberRange(context.Background(), 2, 1, false); err == nil {
+		t.Fatalf("expected an error for an invalid block range")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, errs, err := c.GetTransactionReceipts(ctx, hashes); err != context.Canceled || errs[7] != context.Canceled {
+		t.Fatalf("expected the read to be canceled, have %v", err)
+	}
+}
+
+func TestContractAddress(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	txhash := SendTestTransaction(t, c, nil, []byte{0x60, 0x80}).Hex()
+	ca, err := c.GetContractAddress(context.Background(), txhash)
+	if err != nil {
+		t.Fatalf("ContractAddress not found: %v", err)
+	}
+	code, err := c.PendingCodeAt(context.Background(), ca)
+	if err != nil {
+		t.Fatalf("contract not found: %v", err)
+	}
+	if len(code) == 0 {
+		t.Fatalf("no code at the deployed contract address %s", ca.String())
+	}
+
+	t.Logf("ContractAddress: \n%s", ca.String())
+}
+
+func TestPendingTransactions(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	raw, err := c.GetPendingTransactions(context.Background())
+	if err != nil {
+		t.Fatalf("pending transactions not found: %v", err)
+	}
+
+	t.Logf("pending transactions:\n%s", raw)
+}
+
+func TestPendingTxSize(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	srv.SetAutoSeal(false)
+	SendTestTransaction(t, c, nil, []byte{0x60, 0x80})
+	raw, err := c.GetPendingTxSize(context.Background())
+	if err != nil {
+		t.Fatalf("pending transactions not found: %v", err)
+	}
+	if string(raw) != `"0x1"` {
+		t.Fatalf("pending transaction size mismatch: have %s, want \"0x1\"", raw)
+	}
+	if size, err := c.PendingTxSize(context.Background()); err != nil || size != 1 {
+		t.Fatalf("pending transaction size mismatch: have %d (%v), want 1", size, err)
+	}
+
+	t.Logf("the amount of the pending transactions:\n%s", raw)
+}
+
+func TestGetCode(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	addr := "0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292"
+	srv.SetCode(common.HexToAddress(addr), []byte{0x60, 0x80})
+	raw, err := c.GetCode(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("contract not found: %v", err)
+	}
+
+	t.Logf("the contract code:\n%s", raw)
+}
+
+func TestTotalTransactionCount(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	raw, err := c.GetTotalTransactionCount(context.Background())
+	if err != nil {
+		t.Fatalf("transactions not found: %v", err)
+	}
+
+	t.Logf("the totoal transactions and present block height:\n%s", raw)
+}
+
+func TestSystemConfigByKey(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	findkey := "tx_count_limit"
+	raw, err := c.GetSystemConfigByKey(context.Background(), findkey)
+	if err != nil {
+		t.Fatalf("the value not found: %v", err)
+	}
+
+	if limit, err := c.TxCountLimit(context.Background()); err != nil || limit != 1000 {
+		t.Fatalf("tx_count_limit mismatch: have %d (%v), want 1000", limit, err)
+	}
+
+	t.Logf("the value got by the key:\n%s", raw)
+}
+
+func TestCallContract(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	to := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	srv.HandleCall(func(from, addr common.Address, data []byte) ([]byte, error) {
+		if addr != to {
+			t.Errorf("call sent to %s, want %s", addr.Hex(), to.Hex())
+		}
+		return append([]byte{0xff}, data...), nil
+	})
+	out, err := c.CallContract(context.Background(), common.CallMsg{To: &to, Data: []byte{0x01}}, nil)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if common.Bytes2Hex(out) != "ff01" {
+		t.Fatalf("call output mismatch: have %x, want ff01", out)
+	}
+	if reqs := srv.RequestsFor("call"); len(reqs) != 1 {
+		t.Fatalf("recorded %d call requests, want 1", len(reqs))
+	}
+}
+
+func TestVerifyTransaction(t *testing.T) {
+	c, srv := GetClient(t)
+	defer srv.Close()
+
+	tx := SignTestTransaction(t, c, nil, []byte{0x60, 0x80})
+	from, err := c.VerifyTransaction(context.Background(), tx)
+	if err != nil {
+		t.Fatalf("verify transaction failed: %v", err)
+	}
+	key, _ := crypto.HexToECDSA(testPrivateKey)
+	if from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("sender mismatch: have %s", from.Hex())
+	}
+
+	// a transaction of another group
+	gas := big.NewInt(30000000)
+	other := types.NewRawTransaction(big.NewInt(1), common.Address{}, new(big.Int), gas, gas, tx.BlockLimit(), nil, tx.FiscoChainId(), big.NewInt(2), nil)
+	if other, err = types.SignRawTx(other, types.HomesteadRawSigner{}, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.VerifyTransaction(context.Background(), other); err != types.ErrGroupIdMismatch {
+		t.Fatalf("have error %v, want %v", err, types.ErrGroupIdMismatch)
+	}
+
+	// the blockLimit expires after 500 blocks
+	srv.SetResult("getBlockNumber", "0x1f4")
+	if _, err := c.VerifyTransaction(context.Background(), tx); err != types.ErrInvalidBlockLimit {
+		t.Fatalf("have error %v, want %v", err, types.ErrInvalidBlockLimit)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var (
+		mu  sync.Mutex
+		out bytes.Buffer
+	)
+	log.Root().SetHandler(log.FuncHandler(func(r *log.Record) error {
+		mu.Lock()
+		defer mu.Unlock()
+		out.Write(log.TerminalFormat().Format(r))
+		return nil
+	}))
+	defer log.Root().SetHandler(log.DiscardHandler())
+
+	c, srv := GetClient(t)
+	defer srv.Close()
+	tx := SignTestTransaction(t, c, nil, []byte{0x60, 0x80})
+	if err := c.SendTransaction(context.Background(), tx); err != nil {
+		t.Fatalf("send transaction failed: %v", err)
+	}
+	data, _ := rlp.EncodeToBytes(tx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	logs := out.String()
+	for _, want := range []string{
+		"Connected to the node",
+		"method=getBlockNumber params=[1]",
+		fmt.Sprintf("method=sendRawTransaction params=\"<redacted %d bytes>\"", len(`[1,""]`)+len(common.ToHex(data))),
+		"Received RPC response",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("log %q missing in:\n%s", want, logs)
+		}
+	}
+	if strings.Contains(logs, common.ToHex(data)[2:]) {
+		t.Errorf("signed transaction logged:\n%s", logs)
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/KasperLiu/gobcos/rpc"
+)
+
+// Options configures a Client dialed with DialWithOptions.
+type Options struct {
+	// HTTPClient is the HTTP client the requests are sent with, a new one if nil.
+	HTTPClient *http.Client
+	// Retry is the policy of retrying the calls failing with a transient error,
+	// e.g. a connection reset or a 502 from a proxy. The read methods reported
+	// by IsReadMethod are retried if its Retryable is nil.
+	Retry rpc.RetryPolicy
+	// Breaker is the circuit breaker policy of the node.
+	Breaker rpc.BreakerPolicy
+	// BatchSize and BatchConcurrency configure the bulk read methods, see
+	// SetBatchSize and SetBatchConcurrency.
+	BatchSize        int
+	BatchConcurrency int
+}
+
+// DefaultOptions returns the options retrying a read call up to 3 times and
+// failing fast for 10 seconds after 5 consecutive failures of the node.
+func DefaultOptions() Options {
+	return Options{
+		Retry: rpc.RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: 200 * time.Millisecond,
+			MaxBackoff:     2 * time.Second,
+			Multiplier:     2,
+			Jitter:         0.5,
+		},
+		Breaker: rpc.BreakerPolicy{
+			FailureThreshold: 5,
+			OpenTimeout:      10 * time.Second,
+		},
+	}
+}
+
+// readMethods are the idempotent methods of the FISCO BCOS RPC API.
+var readMethods = map[string]bool{
+	"call":                                true,
+	"eth_getLogs":                         true,
+	"getBlockByHash":                      true,
+	"getBlockByNumber":                    true,
+	"getBlockHashByNumber":                true,
+	"getBlockNumber":                      true,
+	"getClientVersion":                    true,
+	"getCode":                             true,
+	"getConsensusStatus":                  true,
+	"getGroupList":                        true,
+	"getGroupPeers":                       true,
+	"getNodeIDList":                       true,
+	"getObserverList":                     true,
+	"getPbftView":                         true,
+	"getPeers":                            true,
+	"getPendingTransactions":              true,
+	"getPendingTxSize":                    true,
+	"getSealerList":                       true,
+	"getSyncStatus":                       true,
+	"getSystemConfigByKey":                true,
+	"getTotalTransactionCount":            true,
+	"getTransactionByBlockHashAndIndex":   true,
+	"getTransactionByBlockNumberAndIndex": true,
+	"getTransactionByHash":                true,
+	"getTransactionReceipt":               true,
+}
+
+// IsReadMethod reports whether method only reads the state of the node, so that a
+// failed call of it can safely be sent again. sendRawTransaction is not.
+func IsReadMethod(method string) bool {
+	return readMethods[method]
+}
+
+// DialWithOptions connects a client to the given URL and groupID, configured with
+// opts.
+func DialWithOptions(ctx context.Context, rawurl string, groupID uint, opts Options) (*Client, error) {
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = new(http.Client)
+	}
+	c, err := rpc.DialHTTPWithClient(rawurl, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	client := NewClientWithOptions(c, groupID, opts)
+	if err := client.checkConnection(ctx, rawurl); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewClientWithOptions creates a client that uses the given RPC client, which is
+// configured with opts.
+func NewClientWithOptions(c *rpc.Client, groupID uint, opts Options) *Client {
+	retry := opts.Retry
+	if retry.Retryable == nil {
+		retry.Retryable = IsReadMethod
+	}
+	c.SetRetryPolicy(retry)
+	c.SetCircuitBreaker(opts.Breaker)
+	client := NewClient(c, groupID)
+	client.SetBatchSize(opts.BatchSize)
+	client.SetBatchConcurrency(opts.BatchConcurrency)
+	return client
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/metrics"
+	"github.com/KasperLiu/gobcos/rpc"
+)
+
+// flakyProxy forwards the requests to a mock node, failing the ones for which a
+// failure has been injected.
+type flakyProxy struct {
+	*httptest.Server
+	mu       sync.Mutex
+	failures []int // HTTP statuses of the next requests, 0 to reset the connection
+	requests int
+}
+
+func newFlakyProxy(t *testing.T, node *clienttest.Server) *flakyProxy {
+	target, err := url.Parse(node.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forward := httputil.NewSingleHostReverseProxy(target)
+	p := new(flakyProxy)
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		p.requests++
+		status, fail := 0, len(p.failures) > 0
+		if fail {
+			status, p.failures = p.failures[0], p.failures[1:]
+		}
+		p.mu.Unlock()
+		switch {
+		case !fail:
+			forward.ServeHTTP(w, r)
+		case status == 0:
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+		default:
+			http.Error(w, "injected failure", status)
+		}
+	}))
+	return p
+}
+
+// inject makes the next requests fail with the given statuses.
+func (p *flakyProxy) inject(statuses ...int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures = append(p.failures, statuses...)
+	p.requests = 0
+}
+
+// sent returns the number of requests received since the last inject.
+func (p *flakyProxy) sent() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.requests
+}
+
+func TestRetryPolicy(t *testing.T) {
+	node := clienttest.NewServer()
+	defer node.Close()
+	proxy := newFlakyProxy(t, node)
+	defer proxy.Close()
+
+	opts := DefaultOptions()
+	opts.Retry.InitialBackoff = time.Millisecond
+	opts.Breaker.FailureThreshold = 0
+	c, err := DialWithOptions(context.Background(), proxy.URL, 1, opts)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	m := metrics.NewRPCMetrics()
+	c.c.SetInstrumentation(m)
+	ctx := context.Background()
+
+	// Transient failures of the read calls are retried
+	proxy.inject(http.StatusBadGateway, 0)
+	if _, err := c.GetBlockNumber(ctx); err != nil {
+		t.Fatalf("read call not retried: %v", err)
+	}
+	if n := proxy.sent(); n != 3 {
+		t.Fatalf("expected 3 requests, got %d", n)
+	}
+	proxy.inject(http.StatusServiceUnavailable)
+	if _, _, err := c.GetBlocksByNumberRange(ctx, 0, 0, false); err != nil {
+		t.Fatalf("batch of read calls not retried: %v", err)
+	}
+	// until the attempts are exhausted
+	proxy.inject(http.StatusGatewayTimeout, http.StatusGatewayTimeout, http.StatusGatewayTimeout)
+	_, err = c.GetBlockNumber(ctx)
+	if httpErr, ok := err.(*rpc.HTTPError); !ok || httpErr.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("expected the 504 error, got %v", err)
+	}
+	if n := proxy.sent(); n != opts.Retry.MaxAttempts {
+		t.Fatalf("expected %d requests, got %d", opts.Retry.MaxAttempts, n)
+	}
+
+	// Transactions, non transient and JSON-RPC errors are not retried
+	tx := SignTestTransaction(t, c, nil, nil)
+	proxy.inject(http.StatusBadGateway)
+	if err := c.SendTransaction(ctx, tx); err == nil {
+		t.Fatalf("expected the transaction to fail")
+	}
+	proxy.inject(http.StatusInternalServerError)
+	if _, err := c.GetBlockNumber(ctx); err == nil || !strings.Contains(err.Error(), "injected failure") {
+		t.Fatalf("expected the 500 error, got %v", err)
+	}
+	node.SetError("getPbftView", -40007, "Only pbft consensus supports the view property")
+	proxy.inject()
+	if _, err := c.GetPBFTView(ctx); err == nil {
+		t.Fatalf("expected a JSON-RPC error")
+	}
+	if n := proxy.sent(); n != 1 {
+		t.Fatalf("JSON-RPC error retried, %d requests", n)
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, want := range []string{
+		`gobcos_rpc_call_retries_total{method="getBlockNumber"} 4`,
+		`gobcos_rpc_call_retries_total{method="getBlockByNumber"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metric %q missing in:\n%s", want, rec.Body.String())
+		}
+	}
+	if strings.Contains(rec.Body.String(), `retries_total{method="sendRawTransaction"}`) {
+		t.Errorf("transaction retried:\n%s", rec.Body.String())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	node := clienttest.NewServer()
+	defer node.Close()
+	proxy := newFlakyProxy(t, node)
+	defer proxy.Close()
+
+	opts := Options{Breaker: rpc.BreakerPolicy{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond}}
+	c, err := DialWithOptions(context.Background(), proxy.URL, 1, opts)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// A JSON-RPC error is an answer of the node, which resets the failures
+	node.SetError("getPbftView", -40007, "Only pbft consensus supports the view property")
+	for i := 0; i < 2; i++ {
+		proxy.inject(http.StatusBadGateway)
+		if _, err := c.GetBlockNumber(ctx); err == nil {
+			t.Fatalf("expected the injected failure")
+		}
+		if _, err := c.GetPBFTView(ctx); err == nil || err == rpc.ErrCircuitOpen {
+			t.Fatalf("expected a JSON-RPC error, got %v", err)
+		}
+	}
+	// The breaker opens after the threshold of consecutive failures
+	proxy.inject(http.StatusBadGateway, 0)
+	c.GetBlockNumber(ctx)
+	c.GetBlockNumber(ctx)
+	if _, err := c.GetBlockNumber(ctx); err != rpc.ErrCircuitOpen {
+		t.Fatalf("expected an open breaker, got %v", err)
+	}
+	if n := proxy.sent(); n != 2 {
+		t.Fatalf("request sent through an open breaker, %d requests", n)
+	}
+	// and probes the node after the timeout, reopening on failure
+	time.Sleep(opts.Breaker.OpenTimeout)
+	proxy.inject(http.StatusBadGateway)
+	c.GetBlockNumber(ctx)
+	if _, err := c.GetBlockNumber(ctx); err != rpc.ErrCircuitOpen {
+		t.Fatalf("expected the breaker to reopen, got %v", err)
+	}
+	// and closing on success
+	time.Sleep(opts.Breaker.OpenTimeout)
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetBlockNumber(ctx); err != nil {
+			t.Fatalf("breaker not closed: %v", err)
+		}
+	}
+}
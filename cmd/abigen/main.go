@@ -21,12 +21,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	
 	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/cmd/utils"
 	"github.com/KasperLiu/gobcos/common/compiler"
 	"github.com/KasperLiu/gobcos/crypto"
+	"github.com/KasperLiu/gobcos/log"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -68,11 +70,19 @@ var (
 		Name:  "sol",
 		Usage: "Path to the Ethereum contract Solidity source to build and bind",
 	}
+	standardJSONFlag = cli.StringFlag{
+		Name:  "standard-json",
+		Usage: "Path to the Solidity standard-json input (sources, remappings and settings) to build and bind",
+	}
 	solcFlag = cli.StringFlag{
 		Name:  "solc",
 		Usage: "Solidity compiler to use if source builds are requested",
 		Value: "solc",
 	}
+	solcVersionFlag = cli.StringFlag{
+		Name:  "solc-version",
+		Usage: "Version of the locally installed Solidity compiler to use if source builds are requested (overrides --solc)",
+	}
 	vyFlag = cli.StringFlag{
 		Name:  "vy",
 		Usage: "Path to the Ethereum contract Vyper source to build and bind",
@@ -94,11 +104,24 @@ var (
 		Name:  "out",
 		Usage: "Output file for the generated binding (default = stdout)",
 	}
+	projectFlag = cli.StringFlag{
+		Name:  "project",
+		Usage: "Path to the YAML project config to build the contracts of and bind into one package per group",
+	}
+	checkFlag = cli.BoolFlag{
+		Name:  "check",
+		Usage: "Fail if the bindings of the project are out of date instead of writing them",
+	}
 	langFlag = cli.StringFlag{
 		Name:  "lang",
-		Usage: "Destination language for the bindings (go, java, objc)",
+		Usage: "Destination language for the bindings (go, java, ts, objc)",
 		Value: "go",
 	}
+	logLevelFlag = cli.StringFlag{
+		Name:  "log-level",
+		Usage: "Level of the logs written to stderr (trace, debug, info, warn, error, crit)",
+		Value: "warn",
+	}
 )
 
 func init() {
@@ -109,21 +132,27 @@ func init() {
 		typeFlag,
 		jsonFlag,
 		solFlag,
+		standardJSONFlag,
 		solcFlag,
+		solcVersionFlag,
 		vyFlag,
 		vyperFlag,
 		excFlag,
 		pkgFlag,
 		outFlag,
+		projectFlag,
+		checkFlag,
 		langFlag,
+		logLevelFlag,
 	}
+	app.Before = setupLog
 	app.Action = utils.MigrateFlags(abigen)
 	cli.CommandHelpTemplate = commandHelperTemplate
 }
 
 func abigen(c *cli.Context) error {
-	utils.CheckExclusive(c, abiFlag, jsonFlag, solFlag, vyFlag) // Only one source can be selected.
-	if c.GlobalString(pkgFlag.Name) == "" {
+	utils.CheckExclusive(c, abiFlag, jsonFlag, solFlag, standardJSONFlag, vyFlag, projectFlag) // Only one source can be selected.
+	if c.GlobalString(pkgFlag.Name) == "" && !c.GlobalIsSet(projectFlag.Name) {
 		utils.Fatalf("No destination package specified (--pkg)")
 	}
 	var lang bind.Lang
@@ -132,12 +161,18 @@ func abigen(c *cli.Context) error {
 		lang = bind.LangGo
 	case "java":
 		lang = bind.LangJava
+	case "ts", "typescript":
+		lang = bind.LangTypeScript
 	case "objc":
 		lang = bind.LangObjC
 		utils.Fatalf("Objc binding generation is uncompleted")
 	default:
 		utils.Fatalf("Unsupported destination language \"%s\" (--lang)", c.GlobalString(langFlag.Name))
 	}
+	// If a whole project was specified, build and bind it package by package
+	if c.GlobalIsSet(projectFlag.Name) {
+		return abigenProject(c, lang)
+	}
 	// If the entire solidity code was specified, build and bind based on that
 	var (
 		abis  []string
@@ -163,16 +198,15 @@ func abigen(c *cli.Context) error {
 		}
 		abis = append(abis, string(abi))
 
-		var bin []byte
+		var bin string
 		if binFile := c.GlobalString(binFlag.Name); binFile != "" {
-			if bin, err = ioutil.ReadFile(binFile); err != nil {
+			blob, err := ioutil.ReadFile(binFile)
+			if err != nil {
 				utils.Fatalf("Failed to read input bytecode: %v", err)
 			}
-			if strings.Contains(string(bin), "//") {
-				utils.Fatalf("Contract has additional library references, please use other mode(e.g. --combined-json) to catch library infos")
-			}
+			bin = parseBin(blob, libs)
 		}
-		bins = append(bins, string(bin))
+		bins = append(bins, bin)
 
 		kind := c.GlobalString(typeFlag.Name)
 		if kind == "" {
@@ -190,10 +224,25 @@ func abigen(c *cli.Context) error {
 
 		switch {
 		case c.GlobalIsSet(solFlag.Name):
-			contracts, err = compiler.CompileSolidity(c.GlobalString(solcFlag.Name), c.GlobalString(solFlag.Name))
+			contracts, err = compiler.CompileSolidity(solidityCompiler(c), c.GlobalString(solFlag.Name))
 			if err != nil {
 				utils.Fatalf("Failed to build Solidity contract: %v", err)
 			}
+		case c.GlobalIsSet(standardJSONFlag.Name):
+			path := c.GlobalString(standardJSONFlag.Name)
+			blob, err := ioutil.ReadFile(path)
+			if err != nil {
+				utils.Fatalf("Failed to read standard-json input: %v", err)
+			}
+			input := new(compiler.StandardInput)
+			if err := json.Unmarshal(blob, input); err != nil {
+				utils.Fatalf("Failed to parse standard-json input: %v", err)
+			}
+			// sources and imports are resolved relative to the input file
+			contracts, err = compiler.CompileSolidityStandard(solidityCompiler(c), input, filepath.Dir(path))
+			if err != nil {
+				utils.Fatalf("Failed to build Solidity contracts: %v", err)
+			}
 		case c.GlobalIsSet(vyFlag.Name):
 			contracts, err = compiler.CompileVyper(c.GlobalString(vyperFlag.Name), c.GlobalString(vyFlag.Name))
 			if err != nil {
@@ -244,9 +293,88 @@ func abigen(c *cli.Context) error {
 	return nil
 }
 
-func main() {
-	// log.Root().SetHandler(log.LvlFilterHandler(log.LvlInfo, log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+// parseBin returns the bytecode of a solc --bin output, adding the libraries of
+// its link reference comments, as in "// $<pattern>$ -> lib/Math.sol:Math", to libs.
+func parseBin(blob []byte, libs map[string]string) string {
+	var bin strings.Builder
+	for _, line := range strings.Split(string(blob), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "//") {
+			bin.WriteString(line)
+			continue
+		}
+		ref := strings.SplitN(strings.TrimSpace(line[2:]), "->", 2)
+		if len(ref) != 2 {
+			continue
+		}
+		pattern, name := strings.Trim(strings.TrimSpace(ref[0]), "$"), strings.TrimSpace(ref[1])
+		libs[pattern] = name[strings.LastIndex(name, ":")+1:]
+	}
+	return bin.String()
+}
+
+// abigenProject builds the project of the --project config and writes, or with
+// --check verifies, the bindings of its packages.
+func abigenProject(c *cli.Context, lang bind.Lang) error {
+	config, err := loadProject(c.GlobalString(projectFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to load project: %v", err)
+	}
+	// The compiler given on the command line takes precedence over the config
+	var solc string
+	switch {
+	case c.GlobalIsSet(solcFlag.Name) || c.GlobalIsSet(solcVersionFlag.Name):
+		solc = solidityCompiler(c)
+	case config.SolcVersion != "":
+		found, err := compiler.FindSolidity(config.SolcVersion)
+		if err != nil {
+			utils.Fatalf("Failed to find Solidity compiler: %v", err)
+		}
+		solc = found.Path
+	case config.Solc != "":
+		solc = config.Solc
+	default:
+		solc = solidityCompiler(c)
+	}
+	contracts, err := config.compile(solc)
+	if err != nil {
+		utils.Fatalf("Failed to build Solidity contracts: %v", err)
+	}
+	files, err := bindProject(config, contracts, lang)
+	if err != nil {
+		utils.Fatalf("Failed to generate ABI binding: %v", err)
+	}
+	if err := writeProject(files, c.GlobalBool(checkFlag.Name)); err != nil {
+		utils.Fatalf("%v", err)
+	}
+	return nil
+}
 
+// solidityCompiler returns the solc binary selected by --solc-version, or the
+// one given by --solc.
+func solidityCompiler(c *cli.Context) string {
+	version := c.GlobalString(solcVersionFlag.Name)
+	if version == "" {
+		return c.GlobalString(solcFlag.Name)
+	}
+	solc, err := compiler.FindSolidity(version)
+	if err != nil {
+		utils.Fatalf("Failed to find Solidity compiler: %v", err)
+	}
+	return solc.Path
+}
+
+// setupLog writes the logs of the level set by --log-level to stderr.
+func setupLog(c *cli.Context) error {
+	lvl, err := log.LvlFromString(c.GlobalString(logLevelFlag.Name))
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	log.Root().SetHandler(log.LvlFilterHandler(lvl, log.StreamHandler(os.Stderr, log.TerminalFormat())))
+	return nil
+}
+
+func main() {
 	if err := app.Run(os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
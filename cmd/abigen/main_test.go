@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+)
+
+func TestParseLinkedBin(t *testing.T) {
+	pattern := bind.LibraryPattern("lib/Math.sol:Math")
+	code := "608060405234801561001057600080fd5b5073__$" + pattern + "$__63eee9720660"
+	// the content of a solc --bin output file of a contract linking a library
+	blob := code + "\n\n// $" + pattern + "$ -> lib/Math.sol:Math\n"
+
+	libs := make(map[string]string)
+	bin := parseBin([]byte(blob), libs)
+	if bin != code {
+		t.Fatalf("bytecode mismatch:\nhave %s\nwant %s", bin, code)
+	}
+	if len(libs) != 1 || libs[pattern] != "Math" {
+		t.Fatalf("unexpected libraries: %v", libs)
+	}
+	binding, err := bind.Bind([]string{"Store"}, []string{"[]"}, []string{bin}, nil, "store", bind.LangGo, libs)
+	if err != nil {
+		t.Fatalf("failed to bind: %v", err)
+	}
+	if !strings.Contains(binding, `var StoreBin = "0x`+code+`"`) {
+		t.Fatalf("bytecode not bound:\n%s", binding)
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/common/compiler"
+	"gopkg.in/yaml.v2"
+)
+
+// projectConfig is the YAML configuration of a project built by abigen --project.
+// Paths are relative to the directory of the configuration file.
+//
+//	contracts: contracts          # directory holding the Solidity sources
+//	out: bindings                 # root of the generated packages
+//	solc-version: 0.5.2           # or solc: /path/to/solc
+//	remappings: ["openzeppelin/=node_modules/openzeppelin/"]
+//	optimizer: {enabled: true, runs: 200}
+//	exclude: [Migrations, "test/"]
+//	packages:
+//	  - name: token
+//	    out: bindings/token
+//	    contracts: ["token/"]
+//	    libraries: [SafeMath]
+//
+// Contracts are selected by name (Store), by fully qualified name
+// (token/ERC20.sol:ERC20), by source file (token/ERC20.sol) or by directory
+// (token/). Without any packages, one package is generated per directory of
+// the contracts, named after the directory, that deploys the libraries of the
+// directory along with the contracts linking them.
+type projectConfig struct {
+	Contracts   string   `yaml:"contracts"`
+	Out         string   `yaml:"out"`
+	Solc        string   `yaml:"solc"`
+	SolcVersion string   `yaml:"solc-version"`
+	Remappings  []string `yaml:"remappings"`
+	Optimizer   *struct {
+		Enabled bool `yaml:"enabled"`
+		Runs    int  `yaml:"runs"`
+	} `yaml:"optimizer"`
+	EVMVersion string           `yaml:"evm-version"`
+	Exclude    []string         `yaml:"exclude"`
+	Packages   []projectPackage `yaml:"packages"`
+}
+
+// projectPackage is a group of contracts bound into one Go package.
+type projectPackage struct {
+	Name      string   `yaml:"name"`      // Name of the Go package
+	Out       string   `yaml:"out"`       // Directory of the package (default = <out>/<name>)
+	Contracts []string `yaml:"contracts"` // Contracts bound into the package
+	Libraries []string `yaml:"libraries"` // Libraries bound along to be deployed with the contracts linking them
+}
+
+// projectFile is a generated binding of a project.
+type projectFile struct {
+	path string
+	code []byte
+}
+
+// loadProject reads the project configuration, resolving its paths.
+func loadProject(file string) (*projectConfig, error) {
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	config := new(projectConfig)
+	if err := yaml.UnmarshalStrict(blob, config); err != nil {
+		return nil, fmt.Errorf("invalid project config %s: %v", file, err)
+	}
+	root := filepath.Dir(file)
+	if config.Contracts == "" {
+		config.Contracts = "contracts"
+	}
+	config.Contracts = filepath.Join(root, config.Contracts)
+	config.Out = filepath.Join(root, config.Out)
+	if strings.ContainsRune(config.Solc, filepath.Separator) {
+		config.Solc = filepath.Join(root, config.Solc)
+	}
+	for i, pkg := range config.Packages {
+		if pkg.Name == "" {
+			return nil, fmt.Errorf("package %d of %s has no name", i, file)
+		}
+		if pkg.Out == "" {
+			config.Packages[i].Out = filepath.Join(config.Out, pkg.Name)
+		} else {
+			config.Packages[i].Out = filepath.Join(root, pkg.Out)
+		}
+	}
+	return config, nil
+}
+
+// compile builds every Solidity source of the project with solc.
+func (config *projectConfig) compile(solc string) (map[string]*compiler.Contract, error) {
+	var sources []string
+	err := filepath.Walk(config.Contracts, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".sol") {
+			sources = append(sources, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	input, err := compiler.NewStandardInput(config.Contracts, sources...)
+	if err != nil {
+		return nil, err
+	}
+	input.Settings.Remappings = config.Remappings
+	input.Settings.EVMVersion = config.EVMVersion
+	if config.Optimizer != nil {
+		input.Settings.Optimizer = &compiler.OptimizerSettings{Enabled: config.Optimizer.Enabled, Runs: config.Optimizer.Runs}
+	}
+	contracts, err := compiler.CompileSolidityStandard(solc, input, config.Contracts)
+	if err != nil {
+		return nil, err
+	}
+	// rewrite the library references of solc before 0.5 into the link patterns
+	// bind resolves
+	names := make([]string, 0, len(contracts))
+	for fqn := range contracts {
+		names = append(names, fqn)
+	}
+	for fqn, contract := range contracts {
+		if contract.Code, err = bind.LinkPatterns(contract.Code, names); err != nil {
+			return nil, fmt.Errorf("%s: %v", fqn, err)
+		}
+	}
+	return contracts, nil
+}
+
+// matchContract reports whether the contract of the fully qualified name fqn is
+// selected by sel.
+func matchContract(sel, fqn string) bool {
+	file, name := fqn[:strings.LastIndex(fqn, ":")], fqn[strings.LastIndex(fqn, ":")+1:]
+	switch {
+	case strings.Contains(sel, ":"):
+		return sel == fqn
+	case strings.HasSuffix(sel, "/"):
+		return strings.HasPrefix(file, sel)
+	case strings.HasSuffix(sel, ".sol"):
+		return sel == file
+	}
+	return sel == name
+}
+
+// groups returns the packages of the project, one per directory of the contracts
+// if none are configured.
+func (config *projectConfig) groups(contracts map[string]*compiler.Contract) []projectPackage {
+	if len(config.Packages) > 0 {
+		return config.Packages
+	}
+	dirs := make(map[string][]string)
+	for fqn := range contracts {
+		dir := path.Dir(fqn[:strings.LastIndex(fqn, ":")])
+		dirs[dir] = append(dirs[dir], fqn)
+	}
+	var packages []projectPackage
+	for dir, members := range dirs {
+		name := path.Base(dir)
+		if dir == "." {
+			name = filepath.Base(config.Contracts)
+		}
+		packages = append(packages, projectPackage{
+			Name:      strings.ToLower(strings.Replace(name, "-", "", -1)),
+			Out:       filepath.Join(config.Out, filepath.FromSlash(dir)),
+			Contracts: members,
+			Libraries: members,
+		})
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Out < packages[j].Out })
+	return packages
+}
+
+// bindProject generates the bindings of every package of the project.
+func bindProject(config *projectConfig, contracts map[string]*compiler.Contract, lang bind.Lang) ([]projectFile, error) {
+	// Sort the contracts for a deterministic output
+	var names []string
+	for fqn := range contracts {
+		names = append(names, fqn)
+	}
+	sort.Strings(names)
+
+	excluded := func(fqn string) bool {
+		for _, sel := range config.Exclude {
+			if matchContract(sel, fqn) {
+				return true
+			}
+		}
+		return false
+	}
+	var files []projectFile
+	for _, pkg := range config.groups(contracts) {
+		var (
+			selected = make(map[string]bool)
+			libs     = make(map[string]string)
+		)
+		for _, fqn := range names {
+			for _, sel := range pkg.Contracts {
+				if matchContract(sel, fqn) && !excluded(fqn) {
+					selected[fqn] = true
+				}
+			}
+			for _, sel := range pkg.Libraries {
+				if matchContract(sel, fqn) {
+					selected[fqn] = true
+					libs[bind.LibraryPattern(fqn)] = fqn[strings.LastIndex(fqn, ":")+1:]
+				}
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("package %s: no contracts selected", pkg.Name)
+		}
+		var (
+			abis  []string
+			bins  []string
+			types []string
+			sigs  []map[string]string
+			seen  = make(map[string]string)
+		)
+		for _, fqn := range names {
+			if !selected[fqn] {
+				continue
+			}
+			contract := contracts[fqn]
+			name := fqn[strings.LastIndex(fqn, ":")+1:]
+			if other, ok := seen[name]; ok {
+				return nil, fmt.Errorf("package %s: contracts %s and %s have the same name", pkg.Name, other, fqn)
+			}
+			seen[name] = fqn
+
+			abi, err := json.Marshal(contract.Info.AbiDefinition) // Flatten the compiler parse
+			if err != nil {
+				return nil, fmt.Errorf("package %s: failed to parse ABI of %s: %v", pkg.Name, fqn, err)
+			}
+			abis = append(abis, string(abi))
+			bins = append(bins, contract.Code)
+			sigs = append(sigs, contract.Hashes)
+			types = append(types, name)
+		}
+		code, err := bind.Bind(types, abis, bins, sigs, pkg.Name, lang, libs)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %v", pkg.Name, err)
+		}
+		ext := ".go"
+		switch lang {
+		case bind.LangJava:
+			ext = ".java"
+		case bind.LangTypeScript:
+			ext = ".ts"
+		}
+		files = append(files, projectFile{path: filepath.Join(pkg.Out, pkg.Name+ext), code: []byte(code)})
+	}
+	return files, nil
+}
+
+// writeProject writes the generated bindings, or with check only reports the
+// ones that differ from the files on disk.
+func writeProject(files []projectFile, check bool) error {
+	var stale []string
+	for _, file := range files {
+		if check {
+			if existing, err := ioutil.ReadFile(file.path); err != nil || !bytes.Equal(existing, file.code) {
+				stale = append(stale, file.path)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(file.path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file.path, file.code, 0644); err != nil {
+			return err
+		}
+	}
+	if len(stale) > 0 {
+		return fmt.Errorf("bindings are out of date, run abigen --project to regenerate them:\n\t%s", strings.Join(stale, "\n\t"))
+	}
+	return nil
+}
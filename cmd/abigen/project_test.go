@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+)
+
+// testProjectOutput is the standard-json output of solc 0.4 for the test project,
+// in which Store links the Math library with the legacy __name__ reference.
+const testProjectOutput = `{
+  "contracts": {
+    "Store.sol": {
+      "Store": {
+        "abi": [{"constant":false,"inputs":[{"name":"v","type":"uint256"}],"name":"set","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"}],
+        "evm": {"bytecode": {"object": "6080__lib/Math.sol:Math_____________________6000"}, "methodIdentifiers": {"set(uint256)": "60fe47b1"}}
+      },
+      "Migrations": {
+        "abi": [],
+        "evm": {"bytecode": {"object": "6080"}}
+      }
+    },
+    "lib/Math.sol": {
+      "Math": {
+        "abi": [{"constant":true,"inputs":[{"name":"a","type":"uint256"}],"name":"double","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"pure","type":"function"}],
+        "evm": {"bytecode": {"object": "6001"}, "methodIdentifiers": {"double(uint256)": "eee97206"}}
+      }
+    }
+  }
+}`
+
+const testProjectConfig = `contracts: contracts
+out: bindings
+solc: ./solc
+exclude: [Migrations]
+packages:
+  - name: store
+    contracts: [Store.sol]
+    libraries: [Math]
+`
+
+// writeSolcStub installs a fake solc of the given version into dir, printing
+// the content of output.json as the common/compiler tests do.
+func writeSolcStub(t *testing.T, dir, version string) {
+	if runtime.GOOS == "windows" {
+		t.Skip("solc stub requires a POSIX shell")
+	}
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then
+	echo "solc, the solidity compiler commandline interface"
+	echo "Version: ` + version + `+commit.59dbf8f1.Linux.g++"
+	exit 0
+fi
+cat > /dev/null
+cat "` + filepath.Join(dir, "output.json") + `"
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "solc"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write solc stub: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "output.json"), []byte(testProjectOutput), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestProject writes the test project into a temporary directory and loads it.
+func newTestProject(t *testing.T) (string, *projectConfig) {
+	dir, err := ioutil.TempDir("", "abigen-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSolcStub(t, dir, "0.4.25")
+	os.MkdirAll(filepath.Join(dir, "contracts", "lib"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "contracts", "Store.sol"), []byte("contract Store {}\ncontract Migrations {}"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "contracts", "lib", "Math.sol"), []byte("library Math {}"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "project.yaml"), []byte(testProjectConfig), 0644)
+
+	config, err := loadProject(filepath.Join(dir, "project.yaml"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to load project: %v", err)
+	}
+	return dir, config
+}
+
+func TestBindProjectDeterministic(t *testing.T) {
+	dir, config := newTestProject(t)
+	defer os.RemoveAll(dir)
+
+	var first []projectFile
+	for i := 0; i < 10; i++ {
+		contracts, err := config.compile(config.Solc)
+		if err != nil {
+			t.Fatalf("failed to compile: %v", err)
+		}
+		files, err := bindProject(config, contracts, bind.LangGo)
+		if err != nil {
+			t.Fatalf("failed to bind: %v", err)
+		}
+		if i == 0 {
+			first = files
+			continue
+		}
+		if len(files) != len(first) {
+			t.Fatalf("run %d: %d files generated, want %d", i, len(files), len(first))
+		}
+		for j := range files {
+			if files[j].path != first[j].path || !bytes.Equal(files[j].code, first[j].code) {
+				t.Fatalf("run %d: %s differs from the first run", i, files[j].path)
+			}
+		}
+	}
+	if len(first) != 1 || first[0].path != filepath.Join(dir, "bindings", "store", "store.go") {
+		t.Fatalf("unexpected files: %v", first)
+	}
+	code := string(first[0].code)
+	if !strings.Contains(code, "type Store struct") || !strings.Contains(code, "type Math struct") || strings.Contains(code, "Migrations") {
+		t.Errorf("unexpected contracts bound:\n%s", code)
+	}
+	// the legacy reference is rewritten into the link pattern of the library
+	if pattern := "__$" + bind.LibraryPattern("lib/Math.sol:Math") + "$__"; !strings.Contains(code, pattern) {
+		t.Errorf("library reference not rewritten into %s:\n%s", pattern, code)
+	}
+}
+
+func TestWriteProjectCheck(t *testing.T) {
+	dir, config := newTestProject(t)
+	defer os.RemoveAll(dir)
+
+	contracts, err := config.compile(config.Solc)
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+	files, err := bindProject(config, contracts, bind.LangGo)
+	if err != nil {
+		t.Fatalf("failed to bind: %v", err)
+	}
+	path := files[0].path
+
+	// missing bindings are stale, and checking does not write them
+	if err := writeProject(files, true); err == nil || !strings.Contains(err.Error(), path) {
+		t.Fatalf("missing bindings: got %v, want %s reported", err, path)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("bindings written by --check: %v", err)
+	}
+	if err := writeProject(files, false); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if err := writeProject(files, true); err != nil {
+		t.Fatalf("up to date bindings reported stale: %v", err)
+	}
+	// edited bindings are stale and left as is
+	if err := ioutil.WriteFile(path, []byte("package store\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeProject(files, true); err == nil || !strings.Contains(err.Error(), path) {
+		t.Fatalf("edited bindings: got %v, want %s reported", err, path)
+	}
+	if blob, _ := ioutil.ReadFile(path); string(blob) != "package store\n" {
+		t.Fatal("bindings overwritten by --check")
+	}
+}
@@ -0,0 +1,295 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package console
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/crypto"
+	"github.com/KasperLiu/gobcos/governance"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ======= governance =======
+
+var (
+	proposalDescription string
+	proposalExpiresIn   time.Duration
+	proposalKeystore    string
+)
+
+var newProposalCmd = &cobra.Command{
+	Use:   "newProposal",
+	Short: "[operation] [file] [args...]     Propose a governance operation to the committee",
+	Long: `Describes a governance operation on the chain and group of the connected node in a
+proposal written to a JSON file. The proposal is passed to the members of the committee of the config file,
+who approve it with approveProposal, and is sent with executeProposal once enough
+members approved it.
+Arguments:
+[operation]: one of ` + strings.Join(governance.OperationNames(), ", ") + `.
+[file]:      the file the proposal is written to.
+[args...]:   the arguments of the operation:
+             addSealer, addObserver, removeNode: [nodeID]
+             setSystemConfig:                    [key] [value]
+             grantPermission, revokePermission:  [tableName] [address]
+
+The members approve the operation and not a transaction, which is only built when the
+proposal is executed. The proposal must be executed before it expires, by default 7 days
+after its creation, which is changed by --expires-in.
+
+For example:
+
+    [newProposal] [addSealer] [./proposal.json] [ea2ca519148cafc3e92c8d9a8572b41ea2f62d0d19e99273ee18cccd34ab50079b4ec82fe5f4ae51bd95dd788811c97153ece8c05eac7a5ae34c96454c4d3123]
+    [newProposal] [setSystemConfig] [./proposal.json] [tx_count_limit] [2000] --description "raise the block size" --expires-in 48h`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if RPC == nil {
+			fmt.Println("newProposal requires a connection to a node, please check the config file")
+			return
+		}
+		if proposalExpiresIn <= 0 {
+			fmt.Println("--expires-in must be positive")
+			return
+		}
+		proposal, err := governance.NewProposal(context.Background(), RPC, args[0], args[2:]...)
+		if err != nil {
+			fmt.Printf("build the proposal failed: %v\n", err)
+			return
+		}
+		proposal.Description = proposalDescription
+		proposal.Expiry = proposal.Created.Add(proposalExpiresIn)
+		if err := proposal.Write(args[1]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Proposal %s written to %s, it must be executed before %s\n", proposal.Hash().Hex(), args[1], proposal.Expiry.Local().Format("2006-01-02 15:04:05"))
+	},
+}
+
+var approveProposalCmd = &cobra.Command{
+	Use:   "approveProposal",
+	Short: "[proposalFile]                   Approve a proposal as a committee member",
+	Long: `Signs the hash of the operation of a proposal written by newProposal with the
+encrypted key file given by --keystore, whose passphrase is prompted for, and adds the
+approval to the proposal file. The signer must be a member of the committee of the
+config file. No node is needed.
+Arguments:
+[proposalFile]: the file written by newProposal.
+
+For example:
+
+    [approveProposal] [./proposal.json] --keystore ./bin/account/alice.keystore`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{annotationDial: "false"},
+	Run: func(cmd *cobra.Command, args []string) {
+		committee, err := loadCommittee()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		proposal, err := governance.ReadProposal(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := proposal.Validate(); err != nil {
+			fmt.Printf("invalid proposal: %v\n", err)
+			return
+		}
+		if proposal.Expired() {
+			fmt.Println(governance.ErrProposalExpired)
+			return
+		}
+		if proposal.Executed != nil {
+			fmt.Println(governance.ErrProposalExecuted)
+			return
+		}
+		key, err := loadPrivateKey(proposalKeystore)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		approver := crypto.PubkeyToAddress(key.PublicKey)
+		if !committee.IsMember(approver) {
+			fmt.Printf("%s is not a member of the committee\n", approver.Hex())
+			return
+		}
+		if err := proposal.Approve(key); err != nil {
+			fmt.Printf("approve the proposal failed: %v\n", err)
+			return
+		}
+		if err := proposal.Write(args[0]); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Proposal %s approved by %s\n\n", proposal.Hash().Hex(), approver.Hex())
+		printProposal(proposal, committee)
+	},
+}
+
+var showProposalCmd = &cobra.Command{
+	Use:   "showProposal",
+	Short: "[proposalFile]                   Show a proposal and its approvals",
+	Long: `Prints the operation of a proposal written by newProposal, its expiry and the
+approvals of the committee of the config file. No node is needed.
+Arguments:
+[proposalFile]: the file written by newProposal.
+
+For example:
+
+    [showProposal] [./proposal.json]`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{annotationDial: "false"},
+	Run: func(cmd *cobra.Command, args []string) {
+		committee, err := loadCommittee()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		proposal, err := governance.ReadProposal(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		printProposal(proposal, committee)
+	},
+}
+
+var executeProposalCmd = &cobra.Command{
+	Use:   "executeProposal",
+	Short: "[proposalFile]                   Send a proposal approved by the committee",
+	Long: `Verifies that a proposal written by newProposal is approved by enough members of the
+committee of the config file, has not expired and has not been executed yet, then builds
+the transaction of its operation with a fresh blockLimit, signs it with the encrypted key
+file given by --keystore, whose passphrase is prompted for and which must be a node
+manager, and sends it to the connected node. The hash of the transaction is recorded in
+the proposal file, so that the proposal is not executed again.
+Arguments:
+[proposalFile]: the file written by newProposal.
+
+For example:
+
+    [executeProposal] [./proposal.json] --keystore ./bin/account/admin.keystore`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if RPC == nil {
+			fmt.Println("executeProposal requires a connection to a node, please check the config file")
+			return
+		}
+		committee, err := loadCommittee()
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		proposal, err := governance.ReadProposal(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if err := committee.Verify(proposal); err != nil {
+			fmt.Printf("the proposal cannot be executed: %v\n", err)
+			return
+		}
+		auth, err := newTransactor(proposalKeystore)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		tx, err := governance.Execute(context.Background(), committee, proposal, RPC, auth)
+		if err != nil {
+			fmt.Printf("execute the proposal failed: %v\n", err)
+			return
+		}
+		if err := proposal.Write(args[0]); err != nil {
+			fmt.Printf("record the execution failed, keep the transaction hash %s with the proposal: %v\n", tx.Hash().Hex(), err)
+			return
+		}
+		fmt.Printf("Proposal executed by %s, transaction hash: %s\n", auth.From.Hex(), tx.Hash().Hex())
+		fmt.Println("Check its result with getTransactionReceipt")
+	},
+}
+
+// loadCommittee returns the Committee of the config file.
+func loadCommittee() (*governance.Committee, error) {
+	var members []common.Address
+	for _, member := range viper.GetStringSlice("Committee.Members") {
+		if !common.IsHexAddress(member) {
+			return nil, fmt.Errorf("invalid committee member %s in the config file", member)
+		}
+		members = append(members, common.HexToAddress(member))
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("the committee has not been set, please check the Committee of the config file")
+	}
+	committee, err := governance.NewCommittee(viper.GetInt("Committee.Threshold"), members...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid committee in the config file: %v", err)
+	}
+	return committee, nil
+}
+
+func printProposal(proposal *governance.Proposal, committee *governance.Committee) {
+	fmt.Printf("Proposal:    %s\n", proposal.Hash().Hex())
+	fmt.Printf("Operation:   %s %s\n", proposal.Operation, strings.Join(proposal.Args, " "))
+	if proposal.Description != "" {
+		fmt.Printf("Description: %s\n", proposal.Description)
+	}
+	fmt.Printf("Created:     %s\n", proposal.Created.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("Contract:    %s\n", proposal.Contract.Hex())
+	fmt.Printf("Chain:       %v, group %v\n", proposal.ChainID, proposal.GroupID)
+	if proposal.Expired() {
+		fmt.Printf("Expiry:      %s (expired)\n", proposal.Expiry.Local().Format("2006-01-02 15:04:05"))
+	} else {
+		fmt.Printf("Expiry:      %s\n", proposal.Expiry.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	tally, err := committee.Tally(proposal)
+	if err != nil {
+		fmt.Printf("Invalid:     %v\n", err)
+		return
+	}
+	fmt.Printf("Approvals:   %d of %d required\n", len(tally.Approved), tally.Threshold)
+	for _, member := range tally.Approved {
+		fmt.Printf("    approved %s\n", member.Hex())
+	}
+	for _, member := range tally.Pending {
+		fmt.Printf("    pending  %s\n", member.Hex())
+	}
+	for _, err := range tally.Rejected {
+		fmt.Printf("    rejected %v\n", err)
+	}
+	if proposal.Executed != nil {
+		fmt.Printf("Executed:    %s\n", proposal.Executed.Hex())
+	} else if tally.Passed() && !proposal.Expired() {
+		fmt.Println("The proposal can be executed")
+	}
+}
+
+func init() {
+	newProposalCmd.Flags().StringVar(&proposalDescription, "description", "", "description of the proposal shown to the approvers")
+	newProposalCmd.Flags().DurationVar(&proposalExpiresIn, "expires-in", governance.DefaultProposalLifetime, "time after which the proposal may not be approved or executed anymore")
+	approveProposalCmd.Flags().StringVar(&proposalKeystore, "keystore", "", "encrypted key file to approve with")
+	approveProposalCmd.MarkFlagRequired("keystore")
+	executeProposalCmd.Flags().StringVar(&proposalKeystore, "keystore", "", "encrypted key file to sign with")
+	executeProposalCmd.MarkFlagRequired("keystore")
+
+	rootCmd.AddCommand(newProposalCmd, approveProposalCmd, showProposalCmd, executeProposalCmd)
+}
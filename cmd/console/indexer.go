@@ -0,0 +1,329 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package console
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/indexer"
+	"github.com/spf13/cobra"
+)
+
+// ======= indexer =======
+
+var (
+	indexerDB      string
+	indexerABIs    []string
+	indexerPoll    time.Duration
+	findTxFrom     string
+	findTxTo       string
+	findEventsAddr string
+	findEventsName string
+	findLimit      int
+	exportFormat   string
+	exportABIDir   string
+	exportOut      string
+)
+
+var indexerCmd = &cobra.Command{
+	Use:   "indexer",
+	Short: "[start]                          Index transactions and events into a local database",
+	Long: `Manages the chain indexer, which follows the chain and indexes its transactions and
+events into a local LevelDB database to be queried by findTx and findEvents.`,
+}
+
+var indexerStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Follow the chain and index it until interrupted",
+	Long: `Follows the chain from the checkpoint of the database until interrupted with Ctrl-C.
+The method calls and events of the contracts whose ABIs are registered with --abi are
+decoded, the registered ABIs are kept in the database for later runs.
+Note that the database can only be opened by one process at a time, stop the indexer
+before querying it with findTx or findEvents.
+
+For example:
+
+    [indexer] [start] --db ./bin/indexer --abi 0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292=./Store.abi`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if RPC == nil {
+			fmt.Println("indexer requires a connection to a node, please check the config file")
+			return
+		}
+		store, err := indexer.OpenStore(indexerDB)
+		if err != nil {
+			fmt.Printf("open the indexer database failed: %v\n", err)
+			return
+		}
+		defer store.Close()
+		for _, spec := range indexerABIs {
+			if err := registerABI(store, spec); err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+		ctx, stop := interruptContext()
+		defer stop()
+
+		ix := indexer.New(RPC, store)
+		last, _ := store.Checkpoint()
+		fmt.Printf("Indexing from block %d into %s, press Ctrl-C to stop\n", last, indexerDB)
+		for {
+			next, err := ix.Sync(ctx)
+			if next != last {
+				fmt.Printf("Indexed up to block %d\n", next-1)
+				last = next
+			}
+			if err != nil && ctx.Err() == nil {
+				fmt.Printf("index failed: %v\n", err)
+				return
+			}
+			select {
+			case <-time.After(indexerPoll):
+			case <-ctx.Done():
+				fmt.Printf("Indexer stopped, it will resume from block %d\n", last)
+				return
+			}
+		}
+	},
+}
+
+var findTxCmd = &cobra.Command{
+	Use:   "findTx",
+	Short: "--from [address] --to [contract]  Find indexed transactions",
+	Long: `Finds the transactions in the indexer database sent by an account and/or sent to
+(or creating) a contract, in chain order.
+
+For example:
+
+    [findTx] --from 0x83309d045a19c44dc3722d15a6abd472f95866ac --limit 10`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		var filter indexer.TxFilter
+		for _, arg := range []struct {
+			value string
+			addr  **common.Address
+		}{{findTxFrom, &filter.From}, {findTxTo, &filter.To}} {
+			if arg.value == "" {
+				continue
+			}
+			if !common.IsHexAddress(arg.value) {
+				fmt.Printf("invalid address: %s\n", arg.value)
+				return
+			}
+			address := common.HexToAddress(arg.value)
+			*arg.addr = &address
+		}
+		if filter.From == nil && filter.To == nil {
+			fmt.Println("findTx requires --from or --to" + info)
+			return
+		}
+		filter.Limit = findLimit
+		store, err := indexer.OpenStore(indexerDB)
+		if err != nil {
+			fmt.Printf("open the indexer database failed: %v\n", err)
+			return
+		}
+		defer store.Close()
+		txs, err := store.FindTransactions(filter)
+		if err != nil {
+			fmt.Printf("find transactions failed: %v\n", err)
+			return
+		}
+		printIndexed(len(txs), "transactions", txs)
+	},
+}
+
+var findEventsCmd = &cobra.Command{
+	Use:   "findEvents",
+	Short: "--contract [address] --event [name] Find indexed events",
+	Long: `Finds the events emitted by a contract in the indexer database, in chain order. The
+event is given by its name in the registered ABI of the contract or by its topic in hex,
+all events of the contract are listed if it is omitted.
+
+For example:
+
+    [findEvents] --contract 0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292 --event ItemSet`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !common.IsHexAddress(findEventsAddr) {
+			fmt.Printf("invalid contract address: %q%s\n", findEventsAddr, info)
+			return
+		}
+		store, err := indexer.OpenStore(indexerDB)
+		if err != nil {
+			fmt.Printf("open the indexer database failed: %v\n", err)
+			return
+		}
+		defer store.Close()
+		events, err := store.FindEvents(indexer.EventFilter{
+			Contract: common.HexToAddress(findEventsAddr),
+			Event:    findEventsName,
+			Limit:    findLimit,
+		})
+		if err != nil {
+			fmt.Printf("find events failed: %v\n", err)
+			return
+		}
+		printIndexed(len(events), "events", events)
+	},
+}
+
+var exportBlocksCmd = &cobra.Command{
+	Use:   "exportBlocks",
+	Short: "[from] [to]                      Export the transactions of blocks to a JSON Lines or CSV file",
+	Long: `Exports the transactions of the blocks from [from] to [to] (both inclusive) along with
+their receipts and events to a JSON Lines or CSV file. The inputs, outputs and events are
+decoded against the ABI files (*.abi or *.json) of the --abi directory: a file named after
+a contract address, e.g. 0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292.abi, is only used for
+that contract, the others are used for all contracts.
+The progress is kept in a file next to the output with a ".progress" suffix, an
+interrupted export continues where it stopped when it is run again with the same
+arguments.
+Arguments:
+[from]: the first block number.
+[to]:   the last block number.
+
+For example:
+
+    [exportBlocks] [1] [100000] --format csv --abi ./abi --out ./audit.csv`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if RPC == nil {
+			fmt.Println("exportBlocks requires a connection to a node, please check the config file")
+			return
+		}
+		from, err := isValidNumber(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		to, err := isValidNumber(args[1])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if from < 0 || to < from {
+			fmt.Printf("invalid block range [%s, %s]%s\n", args[0], args[1], info)
+			return
+		}
+		var abis indexer.ABIRegistry
+		if exportABIDir != "" {
+			dir, err := indexer.LoadABIDir(exportABIDir)
+			if err != nil {
+				fmt.Printf("load the ABI directory failed: %v\n", err)
+				return
+			}
+			abis = dir
+		}
+		out := exportOut
+		if out == "" {
+			out = fmt.Sprintf("./blocks_%d_%d.%s", from, to, exportFormat)
+		}
+		ctx, stop := interruptContext()
+		defer stop()
+
+		exporter := indexer.NewExporter(RPC, abis)
+		exporter.Format = exportFormat
+		exporter.Progress = func(next uint64) {
+			fmt.Printf("\rExported up to block %d of %d", next-1, to)
+		}
+		err = exporter.Export(ctx, out, uint64(from), uint64(to))
+		fmt.Println()
+		switch {
+		case err == context.Canceled:
+			fmt.Printf("Export interrupted, run the same command again to continue it\n")
+		case err != nil:
+			fmt.Printf("export failed: %v\n", err)
+		default:
+			fmt.Printf("Blocks %d to %d exported to %s\n", from, to, out)
+		}
+	},
+}
+
+// interruptContext returns a context which is canceled on Ctrl-C.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(interrupt)
+		cancel()
+	}
+}
+
+// registerABI registers the ABI given as address=abiFile.
+func registerABI(store *indexer.Store, spec string) error {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 || !common.IsHexAddress(parts[0]) {
+		return fmt.Errorf("invalid --abi %q, it should be given as address=abiFile", spec)
+	}
+	abiJSON, err := ioutil.ReadFile(parts[1])
+	if err != nil {
+		return err
+	}
+	if err := store.RegisterABI(common.HexToAddress(parts[0]), string(abiJSON)); err != nil {
+		return fmt.Errorf("invalid ABI file %s: %v", parts[1], err)
+	}
+	return nil
+}
+
+func printIndexed(n int, what string, records interface{}) {
+	js, err := json.MarshalIndent(records, "", "\t")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%d %s found:\n%s\n", n, what, js)
+}
+
+func init() {
+	indexerCmd.PersistentFlags().StringVar(&indexerDB, "db", "./bin/indexer", "directory of the indexer database")
+	indexerStartCmd.Flags().StringSliceVar(&indexerABIs, "abi", nil, "ABI of a contract to decode, given as address=abiFile (repeatable)")
+	indexerStartCmd.Flags().DurationVar(&indexerPoll, "poll", indexer.DefaultPollInterval, "interval of checking for new blocks")
+	indexerCmd.AddCommand(indexerStartCmd)
+
+	for _, cmd := range []*cobra.Command{findTxCmd, findEventsCmd} {
+		cmd.Flags().StringVar(&indexerDB, "db", "./bin/indexer", "directory of the indexer database")
+		cmd.Flags().IntVar(&findLimit, "limit", 0, "maximum number of results, 0 for no limit")
+	}
+	findTxCmd.Flags().StringVar(&findTxFrom, "from", "", "address of the sender")
+	findTxCmd.Flags().StringVar(&findTxTo, "to", "", "address of the called or created contract")
+	findEventsCmd.Flags().StringVar(&findEventsAddr, "contract", "", "address of the contract")
+	findEventsCmd.Flags().StringVar(&findEventsName, "event", "", "name or topic of the event")
+	findEventsCmd.MarkFlagRequired("contract")
+
+	exportBlocksCmd.Flags().StringVar(&exportFormat, "format", indexer.FormatJSONL, "output format, jsonl or csv")
+	exportBlocksCmd.Flags().StringVar(&exportABIDir, "abi", "", "directory of the ABI files to decode the transactions with")
+	exportBlocksCmd.Flags().StringVar(&exportOut, "out", "", "output file (default ./blocks_[from]_[to].[format])")
+
+	rootCmd.AddCommand(indexerCmd, findTxCmd, findEventsCmd, exportBlocksCmd)
+}
@@ -16,10 +16,12 @@ limitations under the License.
 package console
 
 import (
+  "context"
   "fmt"
   "os"
 
   "github.com/KasperLiu/gobcos/client"
+  "github.com/KasperLiu/gobcos/log"
   "github.com/spf13/cobra"
   "github.com/spf13/viper"
 
@@ -27,6 +29,8 @@ import (
 
 
 var cfgFile string
+
+var logLevel string
 // RPC is the client connected to the blockchain
 var RPC *client.Client
 // GroupID default
@@ -39,7 +43,7 @@ var PrivateKey = "145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b
 // GetClient is used for test, it will be init by a config file later.
 func getClient(url string, groupID uint) (*client.Client) {
 	// RPC API
-	c, err := client.Dial(url, groupID)  // change to your RPC and groupID
+	c, err := client.DialWithOptions(context.Background(), url, groupID, client.DefaultOptions())  // change to your RPC and groupID
 	if err != nil {
     fmt.Println("can not dial to the RPC API, please check the config file gobcos_config.yaml: ", err)
     os.Exit(1)
@@ -67,8 +71,17 @@ Please access the github site for more details:
   // Uncomment the following line if your bare application
   // has an action associated with it:
   //	Run: func(cmd *cobra.Command, args []string) { },
+  PersistentPreRun: func(cmd *cobra.Command, args []string) {
+    if URL != "" && cmd.Annotations[annotationDial] != "false" {
+      RPC = getClient(URL, GroupID)
+    }
+  },
 }
 
+// annotationDial set to "false" on a command keeps the RPCurl node from being
+// dialed before it runs, e.g. for the commands dialing the nodes themselves.
+const annotationDial = "dial"
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -79,13 +92,14 @@ func Execute() {
 }
 
 func init() {
-  cobra.OnInitialize(initConfig)
+  cobra.OnInitialize(initLog, initConfig)
 
   // Here you will define your flags and configuration settings.
   // Cobra supports persistent flags, which, if defined here,
   // will be global for your application.
 
   rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is the project directory ./gobcos_config.yaml)")
+  rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "warn", "level of the logs written to stderr: trace, debug, info, warn, error or crit")
 
 
   // Cobra also supports local flags, which will only run
@@ -94,6 +108,16 @@ func init() {
 }
 
 
+// initLog writes the logs of the level set by --log-level to stderr.
+func initLog() {
+  lvl, err := log.LvlFromString(logLevel)
+  if err != nil {
+    fmt.Println(err)
+    os.Exit(1)
+  }
+  log.Root().SetHandler(log.LvlFilterHandler(lvl, log.StreamHandler(os.Stderr, log.TerminalFormat())))
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
   if cfgFile != "" {
@@ -128,6 +152,5 @@ func initConfig() {
       fmt.Println("RPCurl has not been set, please check the config file gobcos_config.yaml")
       os.Exit(1)
     }
-    RPC = getClient(URL, GroupID)
   }
 }
\ No newline at end of file
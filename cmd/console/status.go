@@ -0,0 +1,192 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package console
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/health"
+	"github.com/KasperLiu/gobcos/rpc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ======= status =======
+
+var (
+	statusNodes    []string
+	statusWatch    bool
+	statusInterval time.Duration
+	statusMaxLag   uint64
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "[--node url]...                  Check the health of the nodes of the group",
+	Long: `Queries every node of the group and prints their block heights, PBFT views and
+peers, the state of the sealers and the problems found: unreachable or lagging nodes,
+sealers which are disconnected or behind, a view change in progress and a sealer
+list which does not match the _sys_consensus_ table.
+
+The nodes are given with --node, or else by the Nodes list of the config file, or
+else the node of RPCurl is checked alone. The command exits with status 1 if a
+problem is found, unless --watch is set to refresh the status until interrupted.
+
+For example:
+
+    [status] --node http://127.0.0.1:8545 --node http://127.0.0.1:8546 --watch`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{annotationDial: "false"},
+	Run: func(cmd *cobra.Command, args []string) {
+		urls := statusNodes
+		if len(urls) == 0 {
+			urls = viper.GetStringSlice("Nodes")
+		}
+		if len(urls) == 0 && URL != "" {
+			urls = []string{URL}
+		}
+		if len(urls) == 0 {
+			fmt.Println("no node to check, please set --node or the Nodes of the config file")
+			os.Exit(1)
+		}
+		var nodes []health.Node
+		for _, url := range urls {
+			c, err := rpc.DialHTTP(url)
+			if err != nil {
+				fmt.Printf("invalid node URL %s: %v\n", url, err)
+				os.Exit(1)
+			}
+			nodes = append(nodes, health.Node{Name: url, Client: client.NewClientWithOptions(c, GroupID, client.DefaultOptions())})
+		}
+		checker := health.NewChecker(nodes...)
+		if statusMaxLag > 0 {
+			checker.MaxBlockLag = statusMaxLag
+		}
+
+		if !statusWatch {
+			ctx, cancel := context.WithTimeout(context.Background(), statusTimeout())
+			report := checker.Check(ctx)
+			cancel()
+			printStatus(report)
+			if !report.Healthy() {
+				os.Exit(1)
+			}
+			return
+		}
+		ctx, stop := interruptContext()
+		defer stop()
+		for {
+			checkCtx, cancel := context.WithTimeout(ctx, statusTimeout())
+			report := checker.Check(checkCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			// clear the terminal before refreshing the status
+			fmt.Print("\033[H\033[2J")
+			printStatus(report)
+			fmt.Printf("\nRefreshing every %v, press Ctrl-C to stop\n", statusInterval)
+			select {
+			case <-time.After(statusInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	},
+}
+
+// statusTimeout returns how long a check may take, at most the refresh interval
+// in watch mode.
+func statusTimeout() time.Duration {
+	if statusWatch && statusInterval > 0 && statusInterval < 10*time.Second {
+		return statusInterval
+	}
+	return 10 * time.Second
+}
+
+func printStatus(report *health.Report) {
+	view := "-"
+	if report.HighestView >= 0 {
+		view = fmt.Sprint(report.HighestView)
+	}
+	fmt.Printf("Group %d at %s, highest block %d, highest view %s\n\n", GroupID, report.Time.Format("2006-01-02 15:04:05"), report.HighestBlock, view)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tNODE ID\tVERSION\tSEALER\tBLOCK\tVIEW\tPEERS\tTX POOL\tSYNCING")
+	for _, node := range report.Nodes {
+		if node.Err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t-\t-\t-\tunreachable\n", node.Name)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%v\t%d\t%s\t%d\t%d\t%v\n", node.Name, health.ShortID(node.NodeID), node.Version,
+			node.Sealer, node.BlockNumber, formatView(node.View), node.Peers, node.TxPoolSize, node.Syncing)
+	}
+	w.Flush()
+
+	if len(report.Sealers) > 0 {
+		fmt.Println()
+		fmt.Fprintln(w, "SEALER\tCONNECTED\tBLOCK\tVIEW\tLAGGING\tIN _sys_consensus_")
+		for _, sealer := range report.Sealers {
+			inTable := "-"
+			if report.ConsensusTable != nil {
+				inTable = fmt.Sprint(contains(report.ConsensusTable, sealer.NodeID))
+			}
+			fmt.Fprintf(w, "%s\t%v\t%d\t%s\t%v\t%s\n", health.ShortID(sealer.NodeID), sealer.Connected, sealer.BlockNumber,
+				formatView(sealer.View), sealer.Lagging, inTable)
+		}
+		w.Flush()
+	}
+
+	fmt.Println()
+	if report.Healthy() {
+		fmt.Println("Healthy, no problem found")
+		return
+	}
+	fmt.Println("Problems found:")
+	for _, problem := range report.Problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+}
+
+func formatView(view int64) string {
+	if view < 0 {
+		return "-"
+	}
+	return fmt.Sprint(view)
+}
+
+func contains(ids []string, id string) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	statusCmd.Flags().StringArrayVar(&statusNodes, "node", nil, "RPC URL of a node to check (repeatable, default the Nodes of the config file)")
+	statusCmd.Flags().BoolVar(&statusWatch, "watch", false, "refresh the status until interrupted")
+	statusCmd.Flags().DurationVar(&statusInterval, "interval", 5*time.Second, "refresh interval of --watch")
+	statusCmd.Flags().Uint64Var(&statusMaxLag, "max-lag", health.DefaultMaxBlockLag, "number of blocks a node may be behind before it is reported")
+
+	rootCmd.AddCommand(statusCmd)
+}
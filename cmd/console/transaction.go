@@ -0,0 +1,363 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package console
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/accounts/keystore"
+	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// ======= transaction =======
+
+var decodeTransactionCmd = &cobra.Command{
+	Use:   "decodeTransaction",
+	Short: "[signedTx] [abiFile]             Decode and verify a signed transaction",
+	Long: `Decodes a signed transaction in hex (the parameter of sendRawTransaction), recovers
+its sender and verifies the signature, the chain ID, the group ID and the blockLimit
+against the node of the config file. The verification is skipped when the node cannot
+be reached.
+Arguments:
+[signedTx]: the RLP encoded transaction in hex.
+[abiFile]:  optional, the ABI file of the called contract that is used to decode the
+            transaction input.
+
+For example:
+
+    [decodeTransaction] [0xf8ac...] [./Store.abi]`,
+	Args:        cobra.RangeArgs(1, 2),
+	Annotations: map[string]string{annotationDial: "false"},
+	Run: func(cmd *cobra.Command, args []string) {
+		_, err := isValidHex(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		tx, err := types.DecodeRawTransaction(args[0])
+		if err != nil {
+			fmt.Printf("decode transaction failed: %v\n", err)
+			return
+		}
+		printRawTransaction(tx)
+
+		// decoding works offline, the node is only needed for the verification
+		if URL != "" {
+			if c, err := client.DialWithOptions(context.Background(), URL, GroupID, client.DefaultOptions()); err == nil {
+				RPC = c
+			}
+		}
+		if RPC == nil {
+			fmt.Println("Verification: skipped, the console is not connected to a node")
+		} else if from, err := RPC.VerifyTransaction(context.Background(), tx); err != nil {
+			fmt.Printf("Verification: failed: %v\n", err)
+		} else {
+			fmt.Printf("Verification: passed, sender %s\n", from.Hex())
+		}
+
+		if len(args) == 2 {
+			if err := printTransactionInput(tx, args[1]); err != nil {
+				fmt.Printf("decode transaction input failed: %v\n", err)
+			}
+		}
+	},
+}
+
+func printRawTransaction(tx *types.RawTransaction) {
+	fmt.Printf("Transaction Hash: %s\n", tx.Hash().Hex())
+	if from, err := types.RawSender(types.HomesteadRawSigner{}, tx); err != nil {
+		fmt.Printf("From:             unknown (%v)\n", err)
+	} else {
+		fmt.Printf("From:             %s\n", from.Hex())
+	}
+	if to := tx.To(); to != nil {
+		fmt.Printf("To:               %s\n", to.Hex())
+	} else {
+		fmt.Println("To:               contract creation")
+	}
+	fmt.Printf("Nonce:            %v\n", tx.Nonce())
+	fmt.Printf("Gas Price:        %v\n", tx.GasPrice())
+	fmt.Printf("Gas Limit:        %v\n", tx.Gas())
+	fmt.Printf("Block Limit:      %v\n", tx.BlockLimit())
+	fmt.Printf("Value:            %v\n", tx.Value())
+	fmt.Printf("Chain ID:         %v\n", tx.FiscoChainId())
+	fmt.Printf("Group ID:         %v\n", tx.GroupId())
+	fmt.Printf("Extra Data:       %s\n", hexutil.Encode(tx.ExtraData()))
+	fmt.Printf("Input:            %s\n", hexutil.Encode(tx.Data()))
+}
+
+// printTransactionInput decodes the method call of tx with the ABI stored in abiFile.
+func printTransactionInput(tx *types.RawTransaction, abiFile string) error {
+	file, err := os.Open(abiFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	parsed, err := abi.JSON(file)
+	if err != nil {
+		return fmt.Errorf("invalid ABI file %s: %v", abiFile, err)
+	}
+	if tx.To() == nil {
+		fmt.Println("The transaction deploys a contract, its input is the contract bytecode followed by the constructor arguments")
+		return nil
+	}
+	data := tx.Data()
+	method, err := parsed.MethodById(data)
+	if err != nil {
+		return err
+	}
+	values, err := method.Inputs.UnpackValues(data[4:])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Method:           %s\n", method.Sig())
+	for i, input := range method.Inputs {
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		fmt.Printf("    %s %s: %s\n", input.Type.String(), strings.TrimSpace(name), abi.FormatValue(input.Type, values[i]))
+	}
+	return nil
+}
+
+var buildTxCmd = &cobra.Command{
+	Use:   "buildTx",
+	Short: "[contract] [abi] [method] [file] Build an unsigned transaction to sign offline",
+	Long: `Builds an unsigned transaction that calls a contract method and writes it to a JSON file,
+which can be signed on another machine with signTx. The blockLimit, the chain ID and the
+group ID are taken from the connected node.
+Arguments:
+[contractAddress]: the address of the contract.
+[abiFile]:         the ABI file of the contract.
+[method]:          the name of the called method.
+[outFile]:         the file the unsigned transaction is written to.
+[args...]:         the arguments of the method, arrays and tuples are given in JSON, e.g. [1,2,3]
+                   or {"name":"x","value":1}.
+
+For example:
+
+    [buildTx] [0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292] [./Store.abi] [set] [./tx.json] [42]`,
+	Args: cobra.MinimumNArgs(4),
+	Run: func(cmd *cobra.Command, args []string) {
+		if RPC == nil {
+			fmt.Println("buildTx requires a connection to a node, please check the config file")
+			return
+		}
+		if !common.IsHexAddress(args[0]) {
+			fmt.Printf("invalid contract address: %s\n", args[0])
+			return
+		}
+		file, err := os.Open(args[1])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		defer file.Close()
+		parsed, err := abi.JSON(file)
+		if err != nil {
+			fmt.Printf("invalid ABI file %s: %v\n", args[1], err)
+			return
+		}
+		method, ok := parsed.Methods[args[2]]
+		if !ok {
+			fmt.Printf("method %s not found in %s\n", args[2], args[1])
+			return
+		}
+		params, err := parseMethodArgs(method, args[4:])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		contract := bind.NewBoundContract(common.HexToAddress(args[0]), parsed, RPC, RPC, RPC)
+		tx, err := contract.BuildTransaction(&bind.TransactOpts{}, method.Name, params...)
+		if err != nil {
+			fmt.Printf("build transaction failed: %v\n", err)
+			return
+		}
+		if err := writeTransaction(args[3], tx); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Unsigned transaction written to %s, it is valid until block %v\n", args[3], tx.BlockLimit())
+	},
+}
+
+var signKeystore string
+
+var signTxCmd = &cobra.Command{
+	Use:   "signTx",
+	Short: "[unsignedTxFile] [signedTxFile]  Sign a transaction built by buildTx",
+	Long: `Signs the transaction in a JSON file written by buildTx with the encrypted key file
+given by --keystore, whose passphrase is prompted for, and writes the signed transaction
+to another file. No node is needed.
+Arguments:
+[unsignedTxFile]: the file written by buildTx.
+[signedTxFile]:   the file the signed transaction is written to.
+
+For example:
+
+    [signTx] [./tx.json] [./signed.json] --keystore ./bin/account/alice.keystore`,
+	Args:        cobra.ExactArgs(2),
+	Annotations: map[string]string{annotationDial: "false"},
+	Run: func(cmd *cobra.Command, args []string) {
+		tx, err := readTransaction(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		auth, err := newTransactor(signKeystore)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		signed, err := bind.Sign(auth, tx)
+		if err != nil {
+			fmt.Printf("sign transaction failed: %v\n", err)
+			return
+		}
+		if err := writeTransaction(args[1], signed); err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Transaction %s signed by %s, written to %s\n", signed.Hash().Hex(), auth.From.Hex(), args[1])
+	},
+}
+
+// newTransactor returns the signer of the encrypted key file keystore, whose
+// passphrase is prompted for.
+func newTransactor(keystore string) (*bind.TransactOpts, error) {
+	key, err := loadPrivateKey(keystore)
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewKeyedTransactor(key), nil
+}
+
+// loadPrivateKey decrypts the key file keystore, whose passphrase is prompted
+// for. There is no default key, the built-in PrivateKey being public.
+func loadPrivateKey(keystorePath string) (*ecdsa.PrivateKey, error) {
+	if keystorePath == "" {
+		return nil, fmt.Errorf("no key to sign with, please set --keystore")
+	}
+	keyJSON, err := ioutil.ReadFile(keystorePath)
+	if err != nil {
+		return nil, fmt.Errorf("read the key file failed: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Passphrase of %s: ", keystorePath)
+	passphrase, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("read the passphrase failed: %v", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, string(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt %s failed: %v", keystorePath, err)
+	}
+	return key.PrivateKey, nil
+}
+
+var sendSignedTxCmd = &cobra.Command{
+	Use:   "sendSignedTx",
+	Short: "[signedTxFile]                   Broadcast a transaction signed by signTx",
+	Long: `Sends the signed transaction in a JSON file written by signTx to the connected node.
+Arguments:
+[signedTxFile]: the file written by signTx.
+
+For example:
+
+    [sendSignedTx] [./signed.json]`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if RPC == nil {
+			fmt.Println("sendSignedTx requires a connection to a node, please check the config file")
+			return
+		}
+		tx, err := readTransaction(args[0])
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if _, err := types.RawSender(types.HomesteadRawSigner{}, tx); err != nil {
+			fmt.Printf("the transaction is not signed: %v\n", err)
+			return
+		}
+		if err := RPC.SendTransaction(context.Background(), tx); err != nil {
+			fmt.Printf("send transaction failed: %v\n", err)
+			return
+		}
+		fmt.Printf("Transaction Hash: %s\n", tx.Hash().Hex())
+	},
+}
+
+func readTransaction(file string) (*types.RawTransaction, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.RawTransaction)
+	if err := tx.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("invalid transaction file %s: %v", file, err)
+	}
+	return tx, nil
+}
+
+func writeTransaction(file string, tx *types.RawTransaction) error {
+	data, err := tx.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// parseMethodArgs converts the command line arguments to the Go values expected by
+// the inputs of method.
+func parseMethodArgs(method abi.Method, args []string) ([]interface{}, error) {
+	if len(args) != len(method.Inputs) {
+		return nil, fmt.Errorf("method %s requires %d arguments, got %d", method.Sig(), len(method.Inputs), len(args))
+	}
+	params := make([]interface{}, len(args))
+	for i, input := range method.Inputs {
+		value, err := abi.ParseArgument(input.Type, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %d of %s: %v", i, method.Sig(), err)
+		}
+		params[i] = value
+	}
+	return params, nil
+}
+
+func init() {
+	signTxCmd.Flags().StringVar(&signKeystore, "keystore", "", "encrypted key file to sign with")
+	signTxCmd.MarkFlagRequired("keystore")
+
+	rootCmd.AddCommand(decodeTransactionCmd)
+	rootCmd.AddCommand(buildTxCmd)
+	rootCmd.AddCommand(signTxCmd)
+	rootCmd.AddCommand(sendSignedTxCmd)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package console
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/KasperLiu/gobcos/accounts"
+	"github.com/KasperLiu/gobcos/accounts/hdwallet"
+	"github.com/KasperLiu/gobcos/crypto"
+	"github.com/spf13/cobra"
+)
+
+// ======= HD wallet =======
+
+var (
+	mnemonicWords    int
+	walletMnemonic   string
+	walletPassphrase string
+	deriveCount      uint32
+	derivePrivate    bool
+)
+
+var newMnemonicCmd = &cobra.Command{
+	Use:   "newMnemonic",
+	Short: "[--words 12]                     Generate a BIP-39 mnemonic to derive accounts from",
+	Long: `Generates a random BIP-39 mnemonic sentence of 12, 15, 18, 21 or 24 words, from
+which the accounts are derived by deriveAccount. Write it down and keep it secret, it
+is the backup of all the accounts derived from it.
+
+For example:
+
+    [newMnemonic] --words 24`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{annotationDial: "false"},
+	Run: func(cmd *cobra.Command, args []string) {
+		// every 3 words encode 32 bits of entropy
+		entropy, err := hdwallet.NewEntropy(mnemonicWords / 3 * 32)
+		if err != nil || mnemonicWords%3 != 0 {
+			fmt.Println("--words must be 12, 15, 18, 21 or 24")
+			return
+		}
+		mnemonic, err := hdwallet.NewMnemonic(entropy)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		wallet, err := hdwallet.NewFromMnemonic(mnemonic, "")
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		account, err := wallet.Derive(accounts.DefaultBaseDerivationPath, false)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		fmt.Printf("Mnemonic: %s\n", mnemonic)
+		fmt.Printf("First account (%s): %s\n", accounts.DefaultBaseDerivationPath, account.Address.Hex())
+		fmt.Println("Write the mnemonic down and keep it secret, anyone knowing it controls the accounts derived from it.")
+	},
+}
+
+var deriveAccountCmd = &cobra.Command{
+	Use:   "deriveAccount",
+	Short: "[path]                           Derive accounts from a BIP-39 mnemonic",
+	Long: `Derives the accounts of a BIP-39 mnemonic along a BIP-32 derivation path. The
+mnemonic is read from the standard input unless it is given by --mnemonic, which is
+kept in the shell history.
+Arguments:
+[path]: optional, the derivation path, absolute (m/44'/60'/0'/0/1) or relative to
+        m/44'/60'/0'/0 (1), default m/44'/60'/0'/0/0. With --count, the last component
+        of the path is incremented to derive the next accounts.
+
+For example:
+
+    [deriveAccount] [m/44'/60'/0'/0/0] --count 5
+    [deriveAccount] [3] --private`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{annotationDial: "false"},
+	Run: func(cmd *cobra.Command, args []string) {
+		path := accounts.DefaultBaseDerivationPath
+		if len(args) == 1 {
+			var err error
+			if path, err = accounts.ParseDerivationPath(args[0]); err != nil {
+				fmt.Printf("invalid derivation path %s: %v\n", args[0], err)
+				return
+			}
+		}
+		mnemonic := walletMnemonic
+		if mnemonic == "" {
+			fmt.Fprint(os.Stderr, "Mnemonic: ")
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				fmt.Printf("read the mnemonic failed: %v\n", err)
+				return
+			}
+			mnemonic = strings.TrimSpace(line)
+		}
+		wallet, err := hdwallet.NewFromMnemonic(mnemonic, walletPassphrase)
+		if err != nil {
+			fmt.Printf("invalid mnemonic: %v\n", err)
+			return
+		}
+
+		path = append(accounts.DerivationPath{}, path...)
+		for i := uint32(0); i < deriveCount; i++ {
+			if i > 0 {
+				path[len(path)-1]++
+			}
+			account, err := wallet.Derive(path, true)
+			if err != nil {
+				fmt.Printf("derive %s failed: %v\n", path, err)
+				return
+			}
+			if !derivePrivate {
+				fmt.Printf("%-24s %s\n", path, account.Address.Hex())
+				continue
+			}
+			key, err := wallet.PrivateKey(account)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			fmt.Printf("%-24s %s %s\n", path, account.Address.Hex(), hex.EncodeToString(crypto.FromECDSA(key)))
+		}
+	},
+}
+
+func init() {
+	newMnemonicCmd.Flags().IntVar(&mnemonicWords, "words", 12, "number of words of the mnemonic: 12, 15, 18, 21 or 24")
+	deriveAccountCmd.Flags().StringVar(&walletMnemonic, "mnemonic", "", "mnemonic of the accounts (default read from the standard input)")
+	deriveAccountCmd.Flags().StringVar(&walletPassphrase, "passphrase", "", "optional BIP-39 passphrase protecting the mnemonic")
+	deriveAccountCmd.Flags().Uint32Var(&deriveCount, "count", 1, "number of consecutive accounts to derive")
+	deriveAccountCmd.Flags().BoolVar(&derivePrivate, "private", false, "also print the private keys in hex")
+
+	rootCmd.AddCommand(newMnemonicCmd, deriveAccountCmd)
+}
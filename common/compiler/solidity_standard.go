@@ -0,0 +1,313 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// StandardInput is the input of a solc --standard-json run.
+type StandardInput struct {
+	Language string                    `json:"language"`
+	Sources  map[string]StandardSource `json:"sources"`
+	Settings StandardSettings          `json:"settings"`
+}
+
+// StandardSource is a source unit of a standard-JSON input, given either by its
+// content or by the URLs (paths) solc loads it from.
+type StandardSource struct {
+	Content string   `json:"content,omitempty"`
+	URLs    []string `json:"urls,omitempty"`
+}
+
+// StandardSettings holds the compiler settings of a standard-JSON input.
+type StandardSettings struct {
+	Remappings      []string                       `json:"remappings,omitempty"`
+	Optimizer       *OptimizerSettings             `json:"optimizer,omitempty"`
+	EVMVersion      string                         `json:"evmVersion,omitempty"`
+	Libraries       map[string]map[string]string   `json:"libraries,omitempty"`
+	OutputSelection map[string]map[string][]string `json:"outputSelection,omitempty"`
+}
+
+// OptimizerSettings switches the code optimizer of solc.
+type OptimizerSettings struct {
+	Enabled bool `json:"enabled"`
+	Runs    int  `json:"runs,omitempty"`
+}
+
+// standardOutputSelection is the output requested from solc when the input
+// doesn't select any, everything needed to fill in a Contract.
+var standardOutputSelection = map[string]map[string][]string{
+	"*": {"*": {
+		"abi", "metadata", "userdoc", "devdoc", "evm.methodIdentifiers",
+		"evm.bytecode.object", "evm.bytecode.sourceMap",
+		"evm.deployedBytecode.object", "evm.deployedBytecode.sourceMap",
+	}},
+}
+
+// standard-json output format
+type solcStandardOutput struct {
+	Errors []struct {
+		Severity         string `json:"severity"`
+		Message          string `json:"message"`
+		FormattedMessage string `json:"formattedMessage"`
+	} `json:"errors"`
+	Contracts map[string]map[string]struct {
+		Abi      interface{} `json:"abi"`
+		Metadata string      `json:"metadata"`
+		Userdoc  interface{} `json:"userdoc"`
+		Devdoc   interface{} `json:"devdoc"`
+		Evm      struct {
+			Bytecode struct {
+				Object    string `json:"object"`
+				SourceMap string `json:"sourceMap"`
+			} `json:"bytecode"`
+			DeployedBytecode struct {
+				Object    string `json:"object"`
+				SourceMap string `json:"sourceMap"`
+			} `json:"deployedBytecode"`
+			MethodIdentifiers map[string]string `json:"methodIdentifiers"`
+		} `json:"evm"`
+	} `json:"contracts"`
+}
+
+// NewStandardInput creates a standard-JSON input holding the content of the given
+// Solidity source files, with the optimizer switched on. The source units are
+// named by their paths relative to basePath, which solc resolves imports against.
+func NewStandardInput(basePath string, sourcefiles ...string) (*StandardInput, error) {
+	if len(sourcefiles) == 0 {
+		return nil, errors.New("solc: no source files")
+	}
+	input := &StandardInput{
+		Language: "Solidity",
+		Sources:  make(map[string]StandardSource),
+		Settings: StandardSettings{Optimizer: &OptimizerSettings{Enabled: true, Runs: 200}},
+	}
+	for _, file := range sourcefiles {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		name := file
+		if basePath != "" {
+			if name, err = filepath.Rel(basePath, file); err != nil {
+				return nil, err
+			}
+		}
+		input.Sources[filepath.ToSlash(name)] = StandardSource{Content: string(content)}
+	}
+	return input, nil
+}
+
+// CompileSolidityStandard compiles the standard-JSON input with solc. Imports and
+// source URLs are resolved against basePath, the current directory if empty.
+func CompileSolidityStandard(solc string, input *StandardInput, basePath string) (map[string]*Contract, error) {
+	s, err := SolidityVersion(solc)
+	if err != nil {
+		return nil, err
+	}
+	return s.CompileStandard(input, basePath)
+}
+
+// CompileStandard compiles the standard-JSON input, resolving imports and source
+// URLs against basePath, the current directory if empty.
+func (s *Solidity) CompileStandard(input *StandardInput, basePath string) (map[string]*Contract, error) {
+	if len(input.Sources) == 0 {
+		return nil, errors.New("solc: no sources in standard-json input")
+	}
+	in := *input
+	if in.Language == "" {
+		in.Language = "Solidity"
+	}
+	if in.Settings.OutputSelection == nil {
+		in.Settings.OutputSelection = standardOutputSelection
+	}
+	blob, err := json.Marshal(&in)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"--standard-json"}
+	if basePath != "" {
+		if basePath, err = filepath.Abs(basePath); err != nil {
+			return nil, err
+		}
+		// --base-path is only known to solc 0.6.9 and later, older versions
+		// resolve relative paths against the working directory
+		if s.Major > 0 || s.Minor > 6 || (s.Minor == 6 && s.Patch >= 9) {
+			args = append(args, "--base-path", basePath)
+		}
+		args = append(args, "--allow-paths", basePath)
+	}
+	// solc runs in the base path, a relative path of it would be resolved there
+	solc := s.Path
+	if strings.ContainsRune(solc, filepath.Separator) {
+		if solc, err = filepath.Abs(solc); err != nil {
+			return nil, err
+		}
+	}
+	cmd := exec.Command(solc, args...)
+	cmd.Dir = basePath
+	cmd.Stdin = bytes.NewReader(blob)
+
+	var stderr, stdout bytes.Buffer
+	cmd.Stderr = &stderr
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("solc: %v\n%s", err, stderr.Bytes())
+	}
+	settings, _ := json.Marshal(in.Settings)
+	contracts, err := ParseStandardJSON(stdout.Bytes(), s.Version, s.Version, string(settings))
+	if err != nil {
+		return nil, err
+	}
+	for name, contract := range contracts {
+		file := name[:strings.LastIndex(name, ":")]
+		contract.Info.Source = in.Sources[file].Content
+	}
+	return contracts, nil
+}
+
+// ParseStandardJSON takes the direct output of a solc --standard-json run and
+// parses it into a map of contract name to Contract structs. The contracts are
+// named as in --combined-json output, by the source unit and the contract name
+// separated by a colon. The provided language and compiler version, and compiler
+// options are all passed through into the Contract structs.
+//
+// Returns an error if the JSON is malformed, or if solc reported any errors.
+func ParseStandardJSON(standardJSON []byte, languageVersion string, compilerVersion string, compilerOptions string) (map[string]*Contract, error) {
+	var output solcStandardOutput
+	if err := json.Unmarshal(standardJSON, &output); err != nil {
+		return nil, err
+	}
+	var errs []string
+	for _, e := range output.Errors {
+		if e.Severity != "error" {
+			continue
+		}
+		if e.FormattedMessage != "" {
+			errs = append(errs, strings.TrimSpace(e.FormattedMessage))
+		} else {
+			errs = append(errs, e.Message)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("solc: compilation failed\n%s", strings.Join(errs, "\n"))
+	}
+	contracts := make(map[string]*Contract)
+	for file, units := range output.Contracts {
+		for name, info := range units {
+			contracts[file+":"+name] = &Contract{
+				Code:        "0x" + info.Evm.Bytecode.Object,
+				RuntimeCode: "0x" + info.Evm.DeployedBytecode.Object,
+				Hashes:      info.Evm.MethodIdentifiers,
+				Info: ContractInfo{
+					Language:        "Solidity",
+					LanguageVersion: languageVersion,
+					CompilerVersion: compilerVersion,
+					CompilerOptions: compilerOptions,
+					SrcMap:          info.Evm.Bytecode.SourceMap,
+					SrcMapRuntime:   info.Evm.DeployedBytecode.SourceMap,
+					AbiDefinition:   info.Abi,
+					UserDoc:         info.Userdoc,
+					DeveloperDoc:    info.Devdoc,
+					Metadata:        info.Metadata,
+				},
+			}
+		}
+	}
+	return contracts, nil
+}
+
+// SolidityDirs returns the directories searched for installed solc binaries by
+// FindSolidity: the directories of PATH followed by the install directories of
+// the solc-select, svm and py-solc-x version managers.
+func SolidityDirs() []string {
+	dirs := filepath.SplitList(os.Getenv("PATH"))
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(home, ".solc-select", "artifacts"),
+			filepath.Join(home, ".svm"),
+			filepath.Join(home, ".solcx"),
+		)
+	}
+	return dirs
+}
+
+// FindSolidity picks the locally installed solc binary of the requested version
+// among dirs, or the directories of SolidityDirs if none are given. A full
+// version (0.5.2) has to match exactly, a major and minor version (0.5) selects
+// the latest patch release installed. Binaries are looked up by the names used
+// by the common installers: solc, solc-<version>, solc-v<version> and solc<version>,
+// directly in a directory or in a subdirectory named after the version.
+func FindSolidity(version string, dirs ...string) (*Solidity, error) {
+	version = strings.TrimPrefix(version, "v")
+	if len(dirs) == 0 {
+		dirs = SolidityDirs()
+	}
+	var (
+		found []*Solidity
+		seen  = make(map[string]bool)
+	)
+	for _, dir := range dirs {
+		for _, candidate := range solidityCandidates(dir) {
+			if seen[candidate] {
+				continue
+			}
+			seen[candidate] = true
+			if info, err := os.Stat(candidate); err != nil || info.IsDir() {
+				continue
+			}
+			s, err := SolidityVersion(candidate)
+			if err != nil {
+				continue
+			}
+			if s.Version == version || strings.HasPrefix(s.Version, version+".") {
+				found = append(found, s)
+			}
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("solc %s not installed", version)
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.Major != b.Major {
+			return a.Major > b.Major
+		}
+		if a.Minor != b.Minor {
+			return a.Minor > b.Minor
+		}
+		return a.Patch > b.Patch
+	})
+	return found[0], nil
+}
+
+// solcNameRegexp matches the names of solc binaries of specific versions.
+var solcNameRegexp = regexp.MustCompile(`^solc-?v?[0-9]+\.[0-9]+\.[0-9]+`)
+
+// solidityCandidates lists the files in dir that might be solc binaries.
+func solidityCandidates(dir string) []string {
+	candidates := []string{filepath.Join(dir, "solc")}
+	matches, _ := filepath.Glob(filepath.Join(dir, "solc*"))
+	// per version directories, e.g. ~/.svm/0.5.2/solc-0.5.2
+	nested, _ := filepath.Glob(filepath.Join(dir, "*", "solc*"))
+	for _, match := range nested {
+		if versionRegexp.MatchString(filepath.Base(filepath.Dir(match))) {
+			matches = append(matches, match)
+		}
+	}
+	for _, match := range matches {
+		if solcNameRegexp.MatchString(filepath.Base(match)) {
+			candidates = append(candidates, match)
+		}
+	}
+	return candidates
+}
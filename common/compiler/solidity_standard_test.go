@@ -0,0 +1,164 @@
+package compiler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const testStandardOutput = `{
+  "errors": [{"severity": "warning", "message": "unused variable"}],
+  "contracts": {
+    "lib/Math.sol": {
+      "Math": {
+        "abi": [{"constant":true,"inputs":[{"name":"a","type":"uint256"}],"name":"double","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"pure","type":"function"}],
+        "evm": {"bytecode": {"object": "6001"}, "deployedBytecode": {"object": "6002"}, "methodIdentifiers": {"double(uint256)": "eee97206"}}
+      }
+    },
+    "Test.sol": {
+      "Test": {
+        "abi": [],
+        "metadata": "{}",
+        "evm": {"bytecode": {"object": "73__$0123456789abcdef0123456789abcdef01$__6003", "sourceMap": "1:2:0"}, "deployedBytecode": {"object": "6004"}}
+      }
+    }
+  }
+}`
+
+// writeSolcStub installs a fake solc of the given version into dir. It records
+// its arguments and standard input, and prints the content of output.json.
+func writeSolcStub(t *testing.T, dir, name, version string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("solc stub requires a POSIX shell")
+	}
+	path := filepath.Join(dir, name)
+	script := `#!/bin/sh
+if [ "$1" = "--version" ]; then
+	echo "solc, the solidity compiler commandline interface"
+	echo "Version: ` + version + `+commit.1df8f40c.Linux.g++"
+	exit 0
+fi
+echo "$@" > "` + path + `.args"
+pwd > "` + path + `.dir"
+cat > "` + path + `.input"
+cat "` + filepath.Join(dir, "output.json") + `"
+`
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write solc stub: %v", err)
+	}
+	return path
+}
+
+func TestCompileSolidityStandard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "solc-standard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	solc := writeSolcStub(t, dir, "solc", "0.5.2")
+	if err := ioutil.WriteFile(filepath.Join(dir, "output.json"), []byte(testStandardOutput), 0644); err != nil {
+		t.Fatal(err)
+	}
+	contracts := filepath.Join(dir, "contracts")
+	os.MkdirAll(filepath.Join(contracts, "lib"), 0755)
+	ioutil.WriteFile(filepath.Join(contracts, "Test.sol"), []byte(testSource), 0644)
+	ioutil.WriteFile(filepath.Join(contracts, "lib", "Math.sol"), []byte("library Math {}"), 0644)
+
+	input, err := NewStandardInput(contracts, filepath.Join(contracts, "Test.sol"), filepath.Join(contracts, "lib", "Math.sol"))
+	if err != nil {
+		t.Fatalf("failed to create input: %v", err)
+	}
+	input.Settings.Remappings = []string{"openzeppelin/=node_modules/openzeppelin/"}
+
+	compiled, err := CompileSolidityStandard(solc, input, contracts)
+	if err != nil {
+		t.Fatalf("failed to compile: %v", err)
+	}
+	// check what solc was run with
+	args, _ := ioutil.ReadFile(solc + ".args")
+	if want := "--standard-json --allow-paths " + contracts; strings.TrimSpace(string(args)) != want {
+		t.Errorf("solc run with %q, want %q", args, want)
+	}
+	if wd, _ := ioutil.ReadFile(solc + ".dir"); strings.TrimSpace(string(wd)) != contracts {
+		t.Errorf("solc run in %q, want %q", wd, contracts)
+	}
+	blob, _ := ioutil.ReadFile(solc + ".input")
+	var sent StandardInput
+	if err := json.Unmarshal(blob, &sent); err != nil {
+		t.Fatalf("invalid input sent to solc: %v", err)
+	}
+	if sent.Language != "Solidity" || len(sent.Sources) != 2 || sent.Sources["lib/Math.sol"].Content != "library Math {}" {
+		t.Errorf("unexpected sources: %+v", sent.Sources)
+	}
+	if !sent.Settings.Optimizer.Enabled || len(sent.Settings.Remappings) != 1 || sent.Settings.OutputSelection["*"]["*"] == nil {
+		t.Errorf("unexpected settings: %+v", sent.Settings)
+	}
+	// check the parsed contracts
+	if len(compiled) != 2 {
+		t.Fatalf("2 contracts expected, got %d", len(compiled))
+	}
+	test, math := compiled["Test.sol:Test"], compiled["lib/Math.sol:Math"]
+	if test == nil || math == nil {
+		t.Fatalf("contracts not named by their source units: %v", compiled)
+	}
+	if test.Code != "0x73__$0123456789abcdef0123456789abcdef01$__6003" || test.RuntimeCode != "0x6004" || test.Info.SrcMap != "1:2:0" {
+		t.Errorf("unexpected code of Test: %+v", test)
+	}
+	if test.Info.Source != testSource || test.Info.CompilerVersion != "0.5.2" || test.Info.Metadata != "{}" {
+		t.Errorf("unexpected info of Test: %+v", test.Info)
+	}
+	if math.Hashes["double(uint256)"] != "eee97206" || len(math.Info.AbiDefinition.([]interface{})) != 1 {
+		t.Errorf("unexpected Math: %+v", math)
+	}
+}
+
+func TestParseStandardJSONErrors(t *testing.T) {
+	output := `{"errors": [
+		{"severity": "warning", "message": "unused variable"},
+		{"severity": "error", "message": "Expected ';'", "formattedMessage": "Test.sol:3:1: ParserError: Expected ';'\n"}
+	]}`
+	_, err := ParseStandardJSON([]byte(output), "", "", "")
+	if err == nil || !strings.Contains(err.Error(), "Test.sol:3:1: ParserError") || strings.Contains(err.Error(), "unused") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFindSolidity(t *testing.T) {
+	dir, err := ioutil.TempDir("", "solc-versions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeSolcStub(t, dir, "solc", "0.4.25")
+	writeSolcStub(t, dir, "solc-0.5.2", "0.5.2")
+	os.MkdirAll(filepath.Join(dir, "0.5.10"), 0755)
+	writeSolcStub(t, filepath.Join(dir, "0.5.10"), "solc-0.5.10", "0.5.10")
+	writeSolcStub(t, dir, "solcdata", "0.6.0") // not named like a solc binary
+
+	tests := []struct {
+		version, path string
+	}{
+		{"0.4.25", filepath.Join(dir, "solc")},
+		{"v0.5.2", filepath.Join(dir, "solc-0.5.2")},
+		{"0.5", filepath.Join(dir, "0.5.10", "solc-0.5.10")},
+		{"0.5.1", ""},
+		{"0.6.0", ""},
+	}
+	for _, tt := range tests {
+		s, err := FindSolidity(tt.version, dir)
+		switch {
+		case tt.path == "" && err == nil:
+			t.Errorf("version %s: found %s", tt.version, s.Path)
+		case tt.path != "" && err != nil:
+			t.Errorf("version %s: %v", tt.version, err)
+		case tt.path != "" && s.Path != tt.path:
+			t.Errorf("version %s: found %s, want %s", tt.version, s.Path, tt.path)
+		}
+	}
+}
@@ -80,6 +80,17 @@ const (
     BCOS_VERSION string = ""
 )
 
+// JSON-RPC error codes of sendRawTransaction when the txpool rejects a transaction
+const (
+	RPCBlockLimitCheckFail int = 10001
+	RPCTxPoolIsFull        int = 10002
+	RPCMalformedTx         int = 10003
+	RPCAlreadyInTxPool     int = 10004
+	RPCAlreadyInChain      int = 10006
+	RPCInvalidChainId      int = 10007
+	RPCInvalidGroupId      int = 10008
+)
+
 // GetStatusMessage returns the status message
 func GetStatusMessage(status string) string {
 	var message string 
@@ -22,6 +22,9 @@ func (t rawtxdata) MarshalJSON() ([]byte, error) {
 		Recipient    *common.Address `json:"to"       rlp:"nil"`
 		Amount       *hexutil.Big    `json:"value"    gencodec:"required"`
 		Payload      hexutil.Bytes   `json:"input"    gencodec:"required"`
+		ChainId      *hexutil.Big    `json:"chainId"  gencodec:"required"`
+		GroupId      *hexutil.Big    `json:"groupId"  gencodec:"required"`
+		ExtraData    hexutil.Bytes   `json:"extraData" rlp:"nil"`
 		V            *hexutil.Big    `json:"v" gencodec:"required"`
 		R            *hexutil.Big    `json:"r" gencodec:"required"`
 		S            *hexutil.Big    `json:"s" gencodec:"required"`
@@ -37,6 +40,9 @@ func (t rawtxdata) MarshalJSON() ([]byte, error) {
 	enc.Recipient = t.Recipient
 	enc.Amount = (*hexutil.Big)(t.Amount)
 	enc.Payload = t.Payload
+	enc.ChainId = (*hexutil.Big)(t.ChainId)
+	enc.GroupId = (*hexutil.Big)(t.GroupId)
+	enc.ExtraData = t.ExtraData
 	enc.V = (*hexutil.Big)(t.V)
 	enc.R = (*hexutil.Big)(t.R)
 	enc.S = (*hexutil.Big)(t.S)
@@ -54,6 +60,9 @@ func (t *rawtxdata) UnmarshalJSON(input []byte) error {
 		Recipient    *common.Address `json:"to"       rlp:"nil"`
 		Amount       *hexutil.Big    `json:"value"    gencodec:"required"`
 		Payload      *hexutil.Bytes  `json:"input"    gencodec:"required"`
+		ChainId      *hexutil.Big    `json:"chainId"  gencodec:"required"`
+		GroupId      *hexutil.Big    `json:"groupId"  gencodec:"required"`
+		ExtraData    hexutil.Bytes   `json:"extraData" rlp:"nil"`
 		V            *hexutil.Big    `json:"v" gencodec:"required"`
 		R            *hexutil.Big    `json:"r" gencodec:"required"`
 		S            *hexutil.Big    `json:"s" gencodec:"required"`
@@ -90,6 +99,17 @@ func (t *rawtxdata) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 'input' for rawtxdata")
 	}
 	t.Payload = *dec.Payload
+	if dec.ChainId == nil {
+		return errors.New("missing required field 'chainId' for rawtxdata")
+	}
+	t.ChainId = (*big.Int)(dec.ChainId)
+	if dec.GroupId == nil {
+		return errors.New("missing required field 'groupId' for rawtxdata")
+	}
+	t.GroupId = (*big.Int)(dec.GroupId)
+	if dec.ExtraData != nil {
+		t.ExtraData = dec.ExtraData
+	}
 	if dec.V == nil {
 		return errors.New("missing required field 'v' for rawtxdata")
 	}
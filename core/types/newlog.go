@@ -1,29 +1,83 @@
 package types
 
+import (
+	"fmt"
+
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+)
+
 // NewLog is used for the receipt
 type NewLog struct {
 	// The Removed field is true if this log was reverted due to a chain reorganisation.
 	// You must pay attention to this field if you receive logs through a filter query.
-	Removed            bool `json:"removed"`
+	Removed bool `json:"removed"`
 	// index of the log in the block
-	LogIndex           string `json:"logIndex" `
+	LogIndex string `json:"logIndex" `
 	// index of the transaction in the block
-	TransactionIndex   string `json:"transactionIndex" `
+	TransactionIndex string `json:"transactionIndex" `
 	// hash of the transaction
-	TransactionHash    string `json:"transactionHash"`
+	TransactionHash string `json:"transactionHash"`
 	// hash of the block in which the transaction was included
-	BlockHash          string `json:"blockHash"`
+	BlockHash string `json:"blockHash"`
 	// Derived fields. These fields are filled in by the node
 	// but not secured by consensus.
 	// block in which the transaction was included
-	BlockNumber        string `json:"blockNumber"`
+	BlockNumber string `json:"blockNumber"`
 	// Consensus fields:
 	// address of the contract that generated the event
-	Address            string `json:"address"`
+	Address string `json:"address"`
 	// supplied by the contract, usually ABI-encoded
-	Data               string `json:"data"`
+	Data string `json:"data"`
 	// Type for FISCO BCOS
-	Type               string `json:"type"`
+	Type string `json:"type"`
 	// list of topics provided by the contract.
-	Topics             []interface{} `json:"topics" `
-}
\ No newline at end of file
+	Topics []interface{} `json:"topics" `
+}
+
+// ToLog converts a log of a receipt, whose fields are hex strings, to a Log.
+func (l *NewLog) ToLog() (Log, error) {
+	var (
+		log Log
+		err error
+	)
+	if !common.IsHexAddress(l.Address) {
+		return log, fmt.Errorf("invalid log address %q", l.Address)
+	}
+	log.Address = common.HexToAddress(l.Address)
+	for i, topic := range l.Topics {
+		str, ok := topic.(string)
+		if !ok {
+			return log, fmt.Errorf("invalid log topic %d: %v", i, topic)
+		}
+		log.Topics = append(log.Topics, common.HexToHash(str))
+	}
+	if l.Data != "" {
+		if log.Data, err = hexutil.Decode(l.Data); err != nil {
+			return log, fmt.Errorf("invalid log data: %v", err)
+		}
+	}
+	if l.BlockNumber != "" {
+		if log.BlockNumber, err = hexutil.DecodeUint64(l.BlockNumber); err != nil {
+			return log, fmt.Errorf("invalid log block number: %v", err)
+		}
+	}
+	if l.TransactionIndex != "" {
+		index, err := hexutil.DecodeUint64(l.TransactionIndex)
+		if err != nil {
+			return log, fmt.Errorf("invalid log transaction index: %v", err)
+		}
+		log.TxIndex = uint(index)
+	}
+	if l.LogIndex != "" {
+		index, err := hexutil.DecodeUint64(l.LogIndex)
+		if err != nil {
+			return log, fmt.Errorf("invalid log index: %v", err)
+		}
+		log.Index = uint(index)
+	}
+	log.TxHash = common.HexToHash(l.TransactionHash)
+	log.BlockHash = common.HexToHash(l.BlockHash)
+	log.Removed = l.Removed
+	return log, nil
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"container/heap"
 	"errors"
+	"fmt"
 	"io"
 	"math/big"
 	"sync/atomic"
@@ -18,8 +19,17 @@ import (
 
 var (
 	ErrInvalidRawSig = errors.New("invalid raw transaction v, r, s values")
+	ErrUnsignedRawTx = errors.New("raw transaction is not signed")
+	ErrChainIdMismatch = errors.New("raw transaction chain ID does not match")
+	ErrGroupIdMismatch = errors.New("raw transaction group ID does not match")
+	ErrInvalidBlockLimit = errors.New("raw transaction blockLimit is out of range")
 )
 
+// MaxBlockLimitGap is the number of blocks a transaction's blockLimit may run
+// ahead of the current block number. FISCO BCOS nodes reject transactions
+// outside of that window.
+const MaxBlockLimitGap = 1000
+
 type RawTransaction struct {
 	data rawtxdata
 	// caches
@@ -116,6 +126,54 @@ func newRawTransaction(nonce *big.Int, to *common.Address, amount *big.Int, gasL
 	return &RawTransaction{data: d}
 }
 
+// DecodeRawTransaction decodes a hex encoded, RLP serialized raw transaction as
+// sent to the sendRawTransaction RPC method.
+func DecodeRawTransaction(hexTx string) (*RawTransaction, error) {
+	data, err := hexutil.Decode(hexTx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw transaction hex: %v", err)
+	}
+	tx := new(RawTransaction)
+	if err := rlp.DecodeBytes(data, tx); err != nil {
+		return nil, fmt.Errorf("invalid raw transaction rlp: %v", err)
+	}
+	return tx, nil
+}
+
+// Verify checks that the transaction is signed, that it was built for the given
+// chain and group, and that its blockLimit is valid after the current block
+// blockNumber, see VerifyBlockLimit. It returns the sender recovered from the
+// signature.
+func (tx *RawTransaction) Verify(chainID, groupID, blockNumber *big.Int) (common.Address, error) {
+	if tx.data.R == nil || tx.data.S == nil || (tx.data.R.Sign() == 0 && tx.data.S.Sign() == 0) {
+		return common.Address{}, ErrUnsignedRawTx
+	}
+	from, err := RawSender(HomesteadRawSigner{}, tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if tx.data.ChainId == nil || tx.data.ChainId.Cmp(chainID) != 0 {
+		return from, ErrChainIdMismatch
+	}
+	if tx.data.GroupId == nil || tx.data.GroupId.Cmp(groupID) != 0 {
+		return from, ErrGroupIdMismatch
+	}
+	return from, tx.VerifyBlockLimit(blockNumber)
+}
+
+// VerifyBlockLimit checks that the transaction can still be packed after the
+// given block, i.e. that blockNumber < blockLimit <= blockNumber + MaxBlockLimitGap.
+func (tx *RawTransaction) VerifyBlockLimit(blockNumber *big.Int) error {
+	limit := tx.data.BlockLimit
+	if limit == nil || limit.Cmp(blockNumber) <= 0 {
+		return ErrInvalidBlockLimit
+	}
+	if limit.Cmp(new(big.Int).Add(blockNumber, big.NewInt(MaxBlockLimitGap))) > 0 {
+		return ErrInvalidBlockLimit
+	}
+	return nil
+}
+
 // ChainId returns which chain id this transaction was signed for (if at all)
 func (tx *RawTransaction) ChainId() *big.Int {
 	return deriveChainId(tx.data.V)
@@ -191,6 +249,20 @@ func (tx *RawTransaction) Value() *big.Int    { return new(big.Int).Set(tx.data.
 func (tx *RawTransaction) Nonce() *big.Int      { return tx.data.AccountNonce }
 func (tx *RawTransaction) CheckNonce() bool   { return true }
 
+// BlockLimit returns the highest block number the transaction can be packed into.
+func (tx *RawTransaction) BlockLimit() *big.Int { return tx.data.BlockLimit }
+
+// GroupId returns the group the transaction was created for.
+func (tx *RawTransaction) GroupId() *big.Int { return tx.data.GroupId }
+
+// FiscoChainId returns the FISCO BCOS chain the transaction was created for.
+// Unlike ChainId, it is read from the transaction fields instead of being
+// derived from the signature.
+func (tx *RawTransaction) FiscoChainId() *big.Int { return tx.data.ChainId }
+
+// ExtraData returns the extra data attached to the transaction.
+func (tx *RawTransaction) ExtraData() []byte { return common.CopyBytes(tx.data.ExtraData) }
+
 // To returns the recipient address of the transaction.
 // It returns nil if the transaction is a contract creation.
 func (tx *RawTransaction) To() *common.Address {
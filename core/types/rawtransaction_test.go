@@ -0,0 +1,125 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/crypto"
+	"github.com/KasperLiu/gobcos/rlp"
+)
+
+func signedTestTx(t *testing.T) (*RawTransaction, common.Address) {
+	key, err := crypto.HexToECDSA("145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b58")
+	if err != nil {
+		t.Fatalf("init privateKey failed: %v", err)
+	}
+	to := common.HexToAddress("0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292")
+	gas := big.NewInt(30000000)
+	tx := NewRawTransaction(big.NewInt(7), to, new(big.Int), gas, gas, big.NewInt(510), []byte{0x60, 0xfe, 0x47, 0xb1}, big.NewInt(1), big.NewInt(2), nil)
+	signed, err := SignRawTx(tx, HomesteadRawSigner{}, key)
+	if err != nil {
+		t.Fatalf("sign transaction failed: %v", err)
+	}
+	return signed, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestDecodeRawTransaction(t *testing.T) {
+	tx, sender := signedTestTx(t)
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("rlp encode failed: %v", err)
+	}
+	decoded, err := DecodeRawTransaction(common.ToHex(data))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if decoded.Hash() != tx.Hash() {
+		t.Fatalf("hash mismatch: have %s, want %s", decoded.Hash().Hex(), tx.Hash().Hex())
+	}
+	if decoded.BlockLimit().Int64() != 510 || decoded.GroupId().Int64() != 2 || decoded.FiscoChainId().Int64() != 1 {
+		t.Fatalf("field mismatch: blockLimit %v, groupId %v, chainId %v", decoded.BlockLimit(), decoded.GroupId(), decoded.FiscoChainId())
+	}
+	from, err := decoded.Verify(big.NewInt(1), big.NewInt(2), big.NewInt(10))
+	if err != nil {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if from != sender {
+		t.Fatalf("sender mismatch: have %s, want %s", from.Hex(), sender.Hex())
+	}
+
+	for _, input := range []string{"", "0x", "0xzz", "0xc0", "0x010203"} {
+		if _, err := DecodeRawTransaction(input); err == nil {
+			t.Errorf("decode of %q should fail", input)
+		}
+	}
+}
+
+func TestVerifyRawTransaction(t *testing.T) {
+	tx, _ := signedTestTx(t)
+	tests := []struct {
+		chainID, groupID, blockNumber int64
+		err                           error
+	}{
+		{1, 2, 10, nil},
+		{2, 2, 10, ErrChainIdMismatch},
+		{1, 1, 10, ErrGroupIdMismatch},
+		{1, 2, 510, ErrInvalidBlockLimit}, // expired
+	}
+	for i, test := range tests {
+		if _, err := tx.Verify(big.NewInt(test.chainID), big.NewInt(test.groupID), big.NewInt(test.blockNumber)); err != test.err {
+			t.Errorf("test %d: have error %v, want %v", i, err, test.err)
+		}
+	}
+
+	unsigned := NewRawTransaction(big.NewInt(7), common.Address{}, nil, nil, nil, big.NewInt(510), nil, big.NewInt(1), big.NewInt(2), nil)
+	if _, err := unsigned.Verify(big.NewInt(1), big.NewInt(2), big.NewInt(10)); err != ErrUnsignedRawTx {
+		t.Errorf("unsigned transaction: have error %v, want %v", err, ErrUnsignedRawTx)
+	}
+}
+
+func TestVerifyBlockLimit(t *testing.T) {
+	tx, _ := signedTestTx(t) // blockLimit 510
+	tests := []struct {
+		blockNumber int64
+		err         error
+	}{
+		{0, nil},
+		{10, nil},
+		{509, nil},
+		{510, ErrInvalidBlockLimit},
+		{600, ErrInvalidBlockLimit},
+	}
+	for _, test := range tests {
+		if err := tx.VerifyBlockLimit(big.NewInt(test.blockNumber)); err != test.err {
+			t.Errorf("block %d: have error %v, want %v", test.blockNumber, err, test.err)
+		}
+	}
+	far := NewRawTransaction(big.NewInt(7), common.Address{}, nil, nil, nil, big.NewInt(MaxBlockLimitGap+1), nil, nil, nil, nil)
+	if err := far.VerifyBlockLimit(big.NewInt(0)); err != ErrInvalidBlockLimit {
+		t.Errorf("blockLimit too far ahead: have error %v, want %v", err, ErrInvalidBlockLimit)
+	}
+}
+
+func TestRawTransactionJSON(t *testing.T) {
+	signed, _ := signedTestTx(t)
+	gas := big.NewInt(30000000)
+	unsigned := NewRawContractCreation(big.NewInt(7), new(big.Int), gas, gas, big.NewInt(510), []byte{0x60, 0x80}, big.NewInt(1), big.NewInt(2), []byte("memo"))
+
+	for _, tx := range []*RawTransaction{signed, unsigned} {
+		data, err := tx.MarshalJSON()
+		if err != nil {
+			t.Fatalf("marshal transaction failed: %v", err)
+		}
+		var decoded RawTransaction
+		if err := decoded.UnmarshalJSON(data); err != nil {
+			t.Fatalf("unmarshal transaction failed: %v", err)
+		}
+		if decoded.Hash() != tx.Hash() {
+			t.Fatalf("hash mismatch after JSON round trip: have %s, want %s", decoded.Hash().Hex(), tx.Hash().Hex())
+		}
+		if decoded.FiscoChainId().Cmp(tx.FiscoChainId()) != 0 || decoded.GroupId().Cmp(tx.GroupId()) != 0 || string(decoded.ExtraData()) != string(tx.ExtraData()) {
+			t.Fatalf("FISCO BCOS fields lost after JSON round trip: %s", data)
+		}
+	}
+}
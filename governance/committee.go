@@ -0,0 +1,147 @@
+package governance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/log"
+)
+
+var logger = log.New("module", "governance")
+
+// ErrThresholdNotMet is returned when executing a proposal which lacks
+// approvals of the committee.
+var ErrThresholdNotMet = errors.New("approval threshold not met")
+
+// Committee is the set of the accounts approving the proposals, of which
+// Threshold must approve a proposal before it is executed.
+type Committee struct {
+	Threshold int
+	Members   []common.Address
+}
+
+// NewCommittee returns the committee of members requiring threshold approvals.
+func NewCommittee(threshold int, members ...common.Address) (*Committee, error) {
+	seen := make(map[common.Address]bool)
+	for _, member := range members {
+		if seen[member] {
+			return nil, fmt.Errorf("duplicate committee member %s", member.Hex())
+		}
+		seen[member] = true
+	}
+	if threshold < 1 || threshold > len(members) {
+		return nil, fmt.Errorf("invalid threshold %d of a committee of %d members", threshold, len(members))
+	}
+	return &Committee{Threshold: threshold, Members: append([]common.Address{}, members...)}, nil
+}
+
+// IsMember reports whether addr is a member of c.
+func (c *Committee) IsMember(addr common.Address) bool {
+	for _, member := range c.Members {
+		if member == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Tally is the count of the approvals of a proposal by a committee.
+type Tally struct {
+	Threshold int
+	Approved  []common.Address // members having approved the proposal
+	Pending   []common.Address // members not having approved the proposal
+	Rejected  []error          // approvals which are invalid or not from a member
+}
+
+// Passed reports whether the approvals reach the threshold.
+func (t *Tally) Passed() bool {
+	return len(t.Approved) >= t.Threshold
+}
+
+// Tally counts the approvals of p by the members of c. It fails if p is not
+// valid. Approvals which are not signed by a member are not counted.
+func (c *Committee) Tally(p *Proposal) (*Tally, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	t := &Tally{Threshold: c.Threshold}
+	approved := make(map[common.Address]bool)
+	for _, approval := range p.Approvals {
+		approver, err := p.recover(approval.Signature)
+		switch {
+		case err != nil:
+			t.Rejected = append(t.Rejected, fmt.Errorf("approval of %s: %v", approval.Approver.Hex(), err))
+		case approver != approval.Approver:
+			t.Rejected = append(t.Rejected, fmt.Errorf("approval of %s signed by %s", approval.Approver.Hex(), approver.Hex()))
+		case !c.IsMember(approver):
+			t.Rejected = append(t.Rejected, fmt.Errorf("approval of %s, which is not a committee member", approver.Hex()))
+		default:
+			approved[approver] = true
+		}
+	}
+	for _, member := range c.Members {
+		if approved[member] {
+			t.Approved = append(t.Approved, member)
+		} else {
+			t.Pending = append(t.Pending, member)
+		}
+	}
+	return t, nil
+}
+
+// Verify checks that p is approved by Threshold members of c, has not expired
+// and has not been executed yet.
+func (c *Committee) Verify(p *Proposal) error {
+	t, err := c.Tally(p)
+	if err != nil {
+		return err
+	}
+	if !t.Passed() {
+		return fmt.Errorf("%v: %d of %d approvals", ErrThresholdNotMet, len(t.Approved), t.Threshold)
+	}
+	if p.Expired() {
+		return fmt.Errorf("%v on %s", ErrProposalExpired, p.Expiry.Local().Format("2006-01-02 15:04:05"))
+	}
+	if p.Executed != nil {
+		return fmt.Errorf("%v by the transaction %s", ErrProposalExecuted, p.Executed.Hex())
+	}
+	return nil
+}
+
+// Execute verifies that p is approved by the committee, then builds its
+// transaction with a blockLimit following the current block, checks it against
+// the approved proposal, signs it with auth, e.g. the signer of the node-manager
+// key, and sends it. The hash of the transaction is recorded in p, which must be
+// written back so that the proposal is not executed again.
+func Execute(ctx context.Context, c *Committee, p *Proposal, backend bind.ContractTransactor, auth *bind.TransactOpts) (*types.RawTransaction, error) {
+	if err := c.Verify(p); err != nil {
+		return nil, err
+	}
+	opts, err := bind.WithDefaultGasLimit(auth, bind.PrecompileGasLimit)
+	if err != nil {
+		return nil, err
+	}
+	opts.Context = ctx
+	tx, err := p.Transaction(backend, opts)
+	if err != nil {
+		return nil, fmt.Errorf("build the proposal transaction failed: %v", err)
+	}
+	if err := p.CheckTransaction(tx); err != nil {
+		return nil, err
+	}
+	signed, err := bind.Sign(auth, tx)
+	if err != nil {
+		return nil, fmt.Errorf("sign the proposal transaction failed: %v", err)
+	}
+	if err := backend.SendTransaction(ctx, signed); err != nil {
+		return nil, err
+	}
+	hash := signed.Hash()
+	p.Executed = &hash
+	logger.Info("Proposal executed", "operation", p.Operation, "args", p.Args, "approvals", len(p.Approvals), "hash", signed.Hash())
+	return signed, nil
+}
@@ -0,0 +1,324 @@
+package governance
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KasperLiu/gobcos/accounts"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/accounts/hdwallet"
+	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/crypto"
+	"github.com/KasperLiu/gobcos/precompile/config"
+	"github.com/KasperLiu/gobcos/precompile/consensus"
+)
+
+const testNodeID = "6a8a0f7b27bfb3e5a1ac5d8d9e1e0aa9f2d5dfa3c4e0f5b6a7c8d9e0f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4"
+
+func newTestClient(t *testing.T) (*clienttest.Server, *client.Client) {
+	srv := clienttest.NewServer()
+	c, err := client.Dial(srv.URL, 1)
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	return srv, c
+}
+
+func newKeys(t *testing.T, n int) ([]*ecdsa.PrivateKey, []common.Address) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	addrs := make([]common.Address, n)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i], addrs[i] = key, crypto.PubkeyToAddress(key.PublicKey)
+	}
+	return keys, addrs
+}
+
+func TestProposalWorkflow(t *testing.T) {
+	srv, c := newTestClient(t)
+	defer srv.Close()
+
+	keys, members := newKeys(t, 4)
+	committee, err := NewCommittee(2, members[:3]...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProposal(context.Background(), c, "addSealer", testNodeID)
+	if err != nil {
+		t.Fatalf("NewProposal: %v", err)
+	}
+	if p.Contract != consensus.ConsensusPrecompileAddress || p.GroupID.Int64() != 1 || p.ChainID == nil {
+		t.Fatalf("proposal for %s on chain %v group %v, want the consensus precompile of group 1", p.Contract.Hex(), p.ChainID, p.GroupID)
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the proposal goes through a file between the approvers
+	dir, err := ioutil.TempDir("", "gobcos-governance-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "proposal.json")
+	approve := func(key *ecdsa.PrivateKey) {
+		t.Helper()
+		if err := p.Write(file); err != nil {
+			t.Fatal(err)
+		}
+		if p, err = ReadProposal(file); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Approve(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	auth := bind.NewKeyedTransactor(keys[3])
+	approve(keys[0])
+	approve(keys[0]) // approving twice counts once
+	approve(keys[3]) // not a member
+	tally, err := committee.Tally(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tally.Passed() || len(tally.Approved) != 1 || len(tally.Pending) != 2 || len(tally.Rejected) != 1 {
+		t.Fatalf("tally of 1 approval: approved %v, pending %v, rejected %v", tally.Approved, tally.Pending, tally.Rejected)
+	}
+	if _, err := Execute(context.Background(), committee, p, c, auth); err == nil || !strings.Contains(err.Error(), ErrThresholdNotMet.Error()) {
+		t.Fatalf("executing with 1 approval: got %v, want %v", err, ErrThresholdNotMet)
+	}
+	if n := len(srv.Transactions()); n != 0 {
+		t.Fatalf("%d transactions sent before the threshold", n)
+	}
+
+	approve(keys[2])
+
+	// the approvals outlive any blockLimit of the time they were given
+	for i := 0; i < 1200; i++ {
+		srv.Seal()
+	}
+	tx, err := Execute(context.Background(), committee, p, c, auth)
+	if err != nil {
+		t.Fatalf("executing with 2 approvals: %v", err)
+	}
+	sent := srv.Transactions()
+	if len(sent) != 1 || sent[0].Hash() != tx.Hash() {
+		t.Fatalf("sent transactions %v, want %s", sent, tx.Hash().Hex())
+	}
+	if from, err := types.RawSender(types.HomesteadRawSigner{}, sent[0]); err != nil || from != auth.From {
+		t.Fatalf("transaction sent by %s (%v), want %s", from.Hex(), err, auth.From.Hex())
+	}
+	if limit := tx.BlockLimit().Uint64(); limit <= 1200 {
+		t.Fatalf("transaction sent with the blockLimit %d, not following block 1200", limit)
+	}
+	if err := p.CheckTransaction(tx); err != nil {
+		t.Fatalf("sent transaction does not match the proposal: %v", err)
+	}
+}
+
+func TestExecuteOnce(t *testing.T) {
+	srv, c := newTestClient(t)
+	defer srv.Close()
+
+	keys, members := newKeys(t, 2)
+	committee, err := NewCommittee(1, members[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProposal(context.Background(), c, "setSystemConfig", "tx_count_limit", "2000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Approve(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	auth := bind.NewKeyedTransactor(keys[1])
+	tx, err := Execute(context.Background(), committee, p, c, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Executed == nil || *p.Executed != tx.Hash() {
+		t.Fatalf("executed by %v, want %s", p.Executed, tx.Hash().Hex())
+	}
+
+	// the execution is kept in the proposal file and does not void the approvals
+	dir, err := ioutil.TempDir("", "gobcos-governance-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "proposal.json")
+	if err := p.Write(file); err != nil {
+		t.Fatal(err)
+	}
+	if p, err = ReadProposal(file); err != nil {
+		t.Fatal(err)
+	}
+	if tally, err := committee.Tally(p); err != nil || !tally.Passed() {
+		t.Fatalf("approvals of the executed proposal: %v, %v", tally, err)
+	}
+	for i := 0; i < 10; i++ {
+		srv.Seal()
+	}
+	if _, err := Execute(context.Background(), committee, p, c, auth); err == nil || !strings.Contains(err.Error(), ErrProposalExecuted.Error()) {
+		t.Fatalf("executing again: got %v, want %v", err, ErrProposalExecuted)
+	}
+	if n := len(srv.Transactions()); n != 1 {
+		t.Fatalf("%d transactions sent, want 1", n)
+	}
+}
+
+func TestApproveWithWallet(t *testing.T) {
+	srv, c := newTestClient(t)
+	defer srv.Close()
+
+	wallet, err := hdwallet.NewFromSeed(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := wallet.Derive(accounts.DefaultBaseDerivationPath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committee, err := NewCommittee(1, account.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProposal(context.Background(), c, "setSystemConfig", "tx_count_limit", "2000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Contract != config.SystemConfigPrecompileAddress {
+		t.Fatalf("proposal for %s, want the system config precompile", p.Contract.Hex())
+	}
+	if err := p.ApproveWithWallet(wallet, account); err != nil {
+		t.Fatal(err)
+	}
+	if err := committee.Verify(p); err != nil {
+		t.Fatalf("approved by the wallet account: %v", err)
+	}
+}
+
+func TestTamperedProposal(t *testing.T) {
+	srv, c := newTestClient(t)
+	defer srv.Close()
+
+	keys, members := newKeys(t, 2)
+	committee, err := NewCommittee(1, members[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewProposal(context.Background(), c, "addObserver", testNodeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := bind.NewKeyedTransactor(keys[1])
+
+	// an approval claiming to be from a member must be signed by it
+	if err := p.Approve(keys[1]); err != nil {
+		t.Fatal(err)
+	}
+	p.Approvals[0].Approver = members[0]
+	if err := committee.Verify(p); err == nil {
+		t.Fatal("forged approval accepted")
+	}
+
+	// the approvals do not cover another operation, group or expiry
+	p.Approvals = nil
+	if err := p.Approve(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := committee.Verify(p); err != nil {
+		t.Fatal(err)
+	}
+	tamper := func(name string, change func(p *Proposal)) {
+		t.Helper()
+		tampered := *p
+		change(&tampered)
+		if err := committee.Verify(&tampered); err == nil || !strings.Contains(err.Error(), ErrThresholdNotMet.Error()) {
+			t.Errorf("tampered %s: got %v, want %v", name, err, ErrThresholdNotMet)
+		}
+	}
+	tamper("operation", func(p *Proposal) { p.Operation = "addSealer" })
+	tamper("arguments", func(p *Proposal) { p.Args = []string{strings.Repeat("ab", 64)} })
+	tamper("group", func(p *Proposal) { p.GroupID = big.NewInt(2) })
+	tamper("expiry", func(p *Proposal) { p.Expiry = p.Expiry.Add(time.Hour) })
+
+	tampered := *p
+	tampered.Contract = consensus.ConsensusPrecompileAddress
+	tampered.Operation = "setSystemConfig"
+	tampered.Args = []string{"tx_count_limit", "1"}
+	if err := tampered.Validate(); err == nil {
+		t.Error("operation accepted on the contract of another one")
+	}
+
+	// the transaction must be the approved call
+	tx, err := p.Transaction(c, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tampered.CheckTransaction(tx); err != ErrMismatchedTransaction {
+		t.Errorf("transaction of another proposal: got %v, want %v", err, ErrMismatchedTransaction)
+	}
+	other := *p
+	other.GroupID = big.NewInt(2)
+	if err := other.CheckTransaction(tx); err != ErrMismatchedTransaction {
+		t.Errorf("transaction of another group: got %v, want %v", err, ErrMismatchedTransaction)
+	}
+
+	// an expired proposal is not executed
+	p.Expiry = time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	p.Approvals = nil
+	if err := p.Approve(keys[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Execute(context.Background(), committee, p, c, auth); err == nil || !strings.Contains(err.Error(), ErrProposalExpired.Error()) {
+		t.Fatalf("executing an expired proposal: got %v, want %v", err, ErrProposalExpired)
+	}
+	if n := len(srv.Transactions()); n != 0 {
+		t.Fatalf("%d transactions sent for an expired proposal", n)
+	}
+
+	if _, err := NewProposal(context.Background(), c, "addSealer"); err == nil {
+		t.Fatal("proposal built with missing arguments")
+	}
+	if _, err := NewProposal(context.Background(), c, "selfDestruct"); err == nil {
+		t.Fatal("proposal built for an unknown operation")
+	}
+}
+
+func TestNewCommittee(t *testing.T) {
+	_, members := newKeys(t, 3)
+	tests := []struct {
+		threshold int
+		members   []common.Address
+		ok        bool
+	}{
+		{2, members, true},
+		{3, members, true},
+		{0, members, false},
+		{4, members, false},
+		{1, []common.Address{members[0], members[0]}, false},
+		{1, nil, false},
+	}
+	for i, test := range tests {
+		if _, err := NewCommittee(test.threshold, test.members...); (err == nil) != test.ok {
+			t.Errorf("test %d: %d of %d members: got error %v", i, test.threshold, len(test.members), err)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Package governance implements an M-of-N approval workflow for the sensitive
+// operations of a group, e.g. adding a sealer or changing a system config:
+//
+//   - a proposer describes the operation, its arguments, the precompiled
+//     contract it calls and the chain and group it is meant for in a Proposal,
+//     which is stored in a JSON file;
+//   - the members of a Committee approve the proposal by signing its hash,
+//     which covers this intent along with a random nonce and an expiry date;
+//   - the holder of the node-manager key executes the proposal once the
+//     approvals of the committee reach the threshold, before it expires: the
+//     transaction is only built then, with a fresh blockLimit, checked against
+//     the approved intent, signed and sent.
+//
+// The threshold is enforced by the executor and not by the chain, so the
+// node-manager key should be held by the executor alone.
+//
+//	proposal, _ := governance.NewProposal(ctx, c, "addSealer", nodeID)
+//	proposal.Approve(aliceKey)
+//	proposal.Approve(bobKey)
+//	tx, err := governance.Execute(ctx, committee, proposal, c, auth)
+package governance
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KasperLiu/gobcos/accounts"
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/crypto"
+	"github.com/KasperLiu/gobcos/precompile/config"
+	"github.com/KasperLiu/gobcos/precompile/consensus"
+	"github.com/KasperLiu/gobcos/precompile/permission"
+	"github.com/KasperLiu/gobcos/rlp"
+)
+
+// DefaultProposalLifetime is how long a new proposal may be approved and
+// executed, unless its Expiry is changed before it is approved.
+const DefaultProposalLifetime = 7 * 24 * time.Hour
+
+// Operation is a governance operation, a method of a precompiled contract.
+type Operation struct {
+	Name     string
+	Contract common.Address
+	Method   string
+	Params   []string // names of the string parameters of the method
+
+	abi abi.ABI
+}
+
+// Operations are the governance operations by name.
+var Operations = map[string]*Operation{
+	"addSealer":        newOperation("addSealer", consensus.ConsensusPrecompileAddress, consensus.ConsensusABI, "addSealer"),
+	"addObserver":      newOperation("addObserver", consensus.ConsensusPrecompileAddress, consensus.ConsensusABI, "addObserver"),
+	"removeNode":       newOperation("removeNode", consensus.ConsensusPrecompileAddress, consensus.ConsensusABI, "remove"),
+	"setSystemConfig":  newOperation("setSystemConfig", config.SystemConfigPrecompileAddress, config.ConfigABI, "setValueByKey"),
+	"grantPermission":  newOperation("grantPermission", permission.PermissionPrecompileAddress, permission.PermissionABI, "insert"),
+	"revokePermission": newOperation("revokePermission", permission.PermissionPrecompileAddress, permission.PermissionABI, "remove"),
+}
+
+func newOperation(name string, contract common.Address, abiJSON string, method string) *Operation {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		panic(fmt.Sprintf("invalid ABI of %s: %v", name, err))
+	}
+	op := &Operation{Name: name, Contract: contract, Method: method, abi: parsed}
+	for _, input := range parsed.Methods[method].Inputs {
+		op.Params = append(op.Params, input.Name)
+	}
+	return op
+}
+
+// OperationNames returns the sorted names of the governance operations.
+func OperationNames() []string {
+	names := make([]string, 0, len(Operations))
+	for name := range Operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pack returns the input of the transaction calling op with args.
+func (op *Operation) pack(args []string) ([]byte, error) {
+	if len(args) != len(op.Params) {
+		return nil, fmt.Errorf("%s takes %d arguments (%s), got %d", op.Name, len(op.Params), strings.Join(op.Params, ", "), len(args))
+	}
+	params := make([]interface{}, len(args))
+	for i, arg := range args {
+		params[i] = arg
+	}
+	return op.abi.Pack(op.Method, params...)
+}
+
+var (
+	// ErrUnknownOperation is returned for an operation missing from Operations.
+	ErrUnknownOperation = errors.New("unknown governance operation")
+	// ErrMismatchedTransaction is returned for a transaction which is not the
+	// call of the operation of a proposal with its arguments, on its chain and
+	// group.
+	ErrMismatchedTransaction = errors.New("the transaction does not match the proposal")
+	// ErrProposalExpired is returned when executing a proposal past its expiry.
+	ErrProposalExpired = errors.New("the proposal has expired")
+	// ErrProposalExecuted is returned when executing a proposal again.
+	ErrProposalExecuted = errors.New("the proposal has already been executed")
+)
+
+// Approval is the signature of the hash of a proposal by an approver.
+type Approval struct {
+	Approver  common.Address `json:"approver"`
+	Signature hexutil.Bytes  `json:"signature"`
+	Time      time.Time      `json:"time"`
+}
+
+// Proposal is a governance operation waiting for the approvals of a committee.
+// The approvals cover the operation, its arguments, contract, chain ID, group ID,
+// nonce and expiry, but not the description, which is only informative, nor the
+// hash of the transaction recorded by Execute.
+type Proposal struct {
+	Operation   string         `json:"operation"`
+	Args        []string       `json:"args"`
+	Contract    common.Address `json:"contract"`
+	ChainID     *big.Int       `json:"chainId"`
+	GroupID     *big.Int       `json:"groupId"`
+	Nonce       common.Hash    `json:"nonce"` // random, so that the approvals of a proposal are not valid for another one
+	Expiry      time.Time      `json:"expiry"`
+	Description string         `json:"description,omitempty"`
+	Created     time.Time      `json:"created"`
+	Approvals   []Approval     `json:"approvals"`
+	Executed    *common.Hash   `json:"executed,omitempty"` // hash of the transaction sent by Execute
+}
+
+// NewProposal returns the proposal of an operation called with args on the chain
+// and group of backend, which expires after DefaultProposalLifetime.
+func NewProposal(ctx context.Context, backend bind.ContractTransactor, operation string, args ...string) (*Proposal, error) {
+	op, ok := Operations[operation]
+	if !ok {
+		return nil, fmt.Errorf("%v: %s", ErrUnknownOperation, operation)
+	}
+	if _, err := op.pack(args); err != nil {
+		return nil, err
+	}
+	chainID, err := backend.GetChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get the chain ID failed: %v", err)
+	}
+	var nonce common.Hash
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	created := time.Now().UTC().Truncate(time.Second)
+	return &Proposal{
+		Operation: operation,
+		Args:      append([]string{}, args...),
+		Contract:  op.Contract,
+		ChainID:   chainID,
+		GroupID:   backend.GetGroupID(),
+		Nonce:     nonce,
+		Expiry:    created.Add(DefaultProposalLifetime),
+		Created:   created,
+	}, nil
+}
+
+// ReadProposal reads a proposal from a JSON file.
+func ReadProposal(file string) (*Proposal, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p := new(Proposal)
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("invalid proposal file %s: %v", file, err)
+	}
+	return p, nil
+}
+
+// Write writes p to a JSON file.
+func (p *Proposal) Write(file string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0644)
+}
+
+// Hash returns the hash of the intent of p that is approved.
+func (p *Proposal) Hash() common.Hash {
+	data, _ := rlp.EncodeToBytes([]interface{}{
+		p.Operation,
+		p.Args,
+		p.Contract,
+		p.ChainID,
+		p.GroupID,
+		p.Nonce,
+		uint64(p.Expiry.Unix()),
+	})
+	return crypto.Keccak256Hash(data)
+}
+
+// Validate checks that the operation of p is called with valid arguments on its
+// contract, so that the approvers can rely on them.
+func (p *Proposal) Validate() error {
+	op, ok := Operations[p.Operation]
+	if !ok {
+		return fmt.Errorf("%v: %s", ErrUnknownOperation, p.Operation)
+	}
+	if _, err := op.pack(p.Args); err != nil {
+		return err
+	}
+	switch {
+	case p.Contract != op.Contract:
+		return fmt.Errorf("%s is a method of %s, not of %s", p.Operation, op.Contract.Hex(), p.Contract.Hex())
+	case p.ChainID == nil || p.GroupID == nil:
+		return errors.New("the proposal has no chain ID or group ID")
+	case p.Expiry.Unix() <= 0:
+		return errors.New("the proposal has no expiry")
+	}
+	return nil
+}
+
+// Expired reports whether p may not be executed anymore.
+func (p *Proposal) Expired() bool {
+	return time.Now().After(p.Expiry)
+}
+
+// Transaction builds the unsigned transaction of p with opts, of which the
+// chain ID and group ID are replaced with the ones of p. The blockLimit is taken
+// from opts when set, otherwise from the current block of backend.
+func (p *Proposal) Transaction(backend bind.ContractTransactor, opts *bind.TransactOpts) (*types.RawTransaction, error) {
+	op, ok := Operations[p.Operation]
+	if !ok {
+		return nil, fmt.Errorf("%v: %s", ErrUnknownOperation, p.Operation)
+	}
+	// the precompiled contracts have no code to estimate the gas with
+	buildOpts, err := bind.WithDefaultGasLimit(opts, bind.PrecompileGasLimit)
+	if err != nil {
+		return nil, err
+	}
+	buildOpts.ChainID, buildOpts.GroupID = p.ChainID, p.GroupID
+	params := make([]interface{}, len(p.Args))
+	for i, arg := range p.Args {
+		params[i] = arg
+	}
+	contract := bind.NewBoundContract(p.Contract, op.abi, nil, backend, nil)
+	return contract.BuildTransaction(buildOpts, op.Method, params...)
+}
+
+// CheckTransaction checks that tx calls the operation of p with its arguments on
+// its contract, chain and group.
+func (p *Proposal) CheckTransaction(tx *types.RawTransaction) error {
+	op, ok := Operations[p.Operation]
+	if !ok {
+		return fmt.Errorf("%v: %s", ErrUnknownOperation, p.Operation)
+	}
+	input, err := op.pack(p.Args)
+	if err != nil {
+		return err
+	}
+	to := tx.To()
+	if to == nil || *to != p.Contract || !bytes.Equal(tx.Data(), input) ||
+		p.ChainID == nil || tx.FiscoChainId() == nil || tx.FiscoChainId().Cmp(p.ChainID) != 0 ||
+		p.GroupID == nil || tx.GroupId() == nil || tx.GroupId().Cmp(p.GroupID) != 0 {
+		return ErrMismatchedTransaction
+	}
+	return nil
+}
+
+// approvalHash returns the hash signed by the approvers. The hash of the
+// proposal is prefixed as a signed text, so that an approval is not a valid
+// signature of anything else.
+func (p *Proposal) approvalHash() []byte {
+	return accounts.TextHash(p.Hash().Bytes())
+}
+
+// Approve adds the approval of the owner of key to p, replacing a previous one
+// of the same approver.
+func (p *Proposal) Approve(key *ecdsa.PrivateKey) error {
+	signature, err := crypto.Sign(p.approvalHash(), key)
+	if err != nil {
+		return err
+	}
+	return p.AddApproval(signature)
+}
+
+// ApproveWithWallet adds the approval of an account of an unlocked wallet to p,
+// replacing a previous one of the same approver.
+func (p *Proposal) ApproveWithWallet(wallet accounts.Wallet, account accounts.Account) error {
+	signature, err := wallet.SignText(account, p.Hash().Bytes())
+	if err != nil {
+		return err
+	}
+	return p.AddApproval(signature)
+}
+
+// AddApproval adds an approval signature made elsewhere to p, replacing a
+// previous one of the same approver.
+func (p *Proposal) AddApproval(signature []byte) error {
+	approver, err := p.recover(signature)
+	if err != nil {
+		return err
+	}
+	approval := Approval{Approver: approver, Signature: signature, Time: time.Now().UTC().Truncate(time.Second)}
+	for i := range p.Approvals {
+		if p.Approvals[i].Approver == approver {
+			p.Approvals[i] = approval
+			return nil
+		}
+	}
+	p.Approvals = append(p.Approvals, approval)
+	return nil
+}
+
+// recover returns the approver of an approval signature.
+func (p *Proposal) recover(signature []byte) (common.Address, error) {
+	pub, err := crypto.SigToPub(p.approvalHash(), signature)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid approval signature: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
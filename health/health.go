@@ -0,0 +1,432 @@
+// Package health checks the state of the nodes of a group: their block heights
+// and PBFT views, the connectivity of the sealers and the consistency of the
+// sealer set with the _sys_consensus_ system table.
+//
+//	checker := health.NewChecker(health.Node{Name: url, Client: c})
+//	report := checker.Check(ctx)
+//	for _, problem := range report.Problems {
+//		fmt.Println(problem)
+//	}
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+	"github.com/KasperLiu/gobcos/precompile/crud"
+)
+
+const (
+	// DefaultMaxBlockLag is the default number of blocks a node may be behind
+	// the highest block before it is reported as lagging.
+	DefaultMaxBlockLag = 10
+	// DefaultMaxViewLag is the default number of views the PBFT view of a node
+	// may be behind the highest view before it is reported.
+	DefaultMaxViewLag = 10
+)
+
+// Node is a node of the group to check.
+type Node struct {
+	Name   string // how the node is reported, e.g. its RPC URL
+	Client *client.Client
+}
+
+// NodeStatus is the state of a queried node.
+type NodeStatus struct {
+	Name         string
+	NodeID       string
+	Version      string
+	Sealer       bool
+	BlockNumber  uint64
+	View         int64 // -1 if the node does not report a PBFT view
+	Peers        int
+	Syncing      bool
+	TxPoolSize   int
+	LeaderFailed bool
+	Err          error // the node could not be queried if set
+
+	c         *client.Client
+	sealers   []string          // sealer list reported by the node
+	connected map[string]bool   // node IDs of the node and of its peers
+	blocks    map[string]uint64 // block numbers of the node and of its syncing peers by node ID
+	views     map[string]uint64 // PBFT views of the sealers by node ID
+}
+
+// SealerStatus is the state of a sealer of the group.
+type SealerStatus struct {
+	NodeID      string
+	Connected   bool   // whether the sealer is queried or a peer of a queried node
+	BlockNumber uint64 // reported by the sealer if queried, else the highest one seen by its peers
+	View        int64  // -1 if unknown
+	Lagging     bool
+}
+
+// Report is the result of a check.
+type Report struct {
+	Time         time.Time
+	Nodes        []*NodeStatus
+	Sealers      []*SealerStatus // the sealers reported by most nodes
+	HighestBlock uint64
+	HighestView  int64
+	// ConsensusTable holds the node IDs of the sealers of the _sys_consensus_
+	// table, it is nil if the table could not be read.
+	ConsensusTable []string
+	// Problems describes what is wrong with the group, one problem per entry.
+	Problems []string
+}
+
+// Healthy reports whether no problem was found.
+func (r *Report) Healthy() bool {
+	return len(r.Problems) == 0
+}
+
+// Checker checks the health of the nodes of a group.
+type Checker struct {
+	Nodes       []Node
+	MaxBlockLag uint64 // DefaultMaxBlockLag if 0
+	MaxViewLag  uint64 // DefaultMaxViewLag if 0
+}
+
+// NewChecker creates a Checker of the given nodes.
+func NewChecker(nodes ...Node) *Checker {
+	return &Checker{Nodes: nodes, MaxBlockLag: DefaultMaxBlockLag, MaxViewLag: DefaultMaxViewLag}
+}
+
+// Check queries all nodes concurrently and reports their state along with the
+// problems found.
+func (c *Checker) Check(ctx context.Context) *Report {
+	report := &Report{Time: time.Now(), Nodes: make([]*NodeStatus, len(c.Nodes)), HighestView: -1}
+	var wg sync.WaitGroup
+	for i, node := range c.Nodes {
+		wg.Add(1)
+		go func(i int, node Node) {
+			defer wg.Done()
+			report.Nodes[i] = queryNode(ctx, node)
+		}(i, node)
+	}
+	wg.Wait()
+
+	var reachable []*NodeStatus
+	for _, node := range report.Nodes {
+		if node.Err != nil {
+			report.problem("%s: unreachable: %v", node.Name, node.Err)
+			continue
+		}
+		reachable = append(reachable, node)
+		for _, number := range node.blocks {
+			if number > report.HighestBlock {
+				report.HighestBlock = number
+			}
+		}
+		if node.View > report.HighestView {
+			report.HighestView = node.View
+		}
+	}
+	if len(reachable) == 0 {
+		return report
+	}
+	sealers := c.checkSealerLists(report, reachable)
+	c.checkNodes(report, reachable, sealers)
+	c.checkSealers(report, reachable, sealers)
+
+	report.ConsensusTable = readConsensusTable(ctx, reachable[0].c, reachable[0].BlockNumber)
+	if report.ConsensusTable == nil {
+		report.problem("the %s table could not be read", common.SYS_CONSENSUS)
+	} else if missing, extra := diff(report.ConsensusTable, sealers); len(missing) > 0 || len(extra) > 0 {
+		report.problem("the sealer list does not match the %s table: missing %s, unexpected %s",
+			common.SYS_CONSENSUS, shortIDs(missing), shortIDs(extra))
+	}
+	return report
+}
+
+// checkSealerLists returns the sealer list reported by most nodes, and reports
+// the nodes disagreeing with it.
+func (c *Checker) checkSealerLists(report *Report, nodes []*NodeStatus) []string {
+	votes := make(map[string]int)
+	for _, node := range nodes {
+		votes[strings.Join(node.sealers, ",")]++
+	}
+	var majority string
+	for list, n := range votes {
+		if n > votes[majority] || (n == votes[majority] && list < majority) {
+			majority = list
+		}
+	}
+	for _, node := range nodes {
+		if strings.Join(node.sealers, ",") != majority {
+			report.problem("%s: reports a different sealer list %s", node.Name, shortIDs(node.sealers))
+		}
+	}
+	if majority == "" {
+		return nil
+	}
+	return strings.Split(majority, ",")
+}
+
+func (c *Checker) checkNodes(report *Report, nodes []*NodeStatus, sealers []string) {
+	for _, node := range nodes {
+		node.Sealer = contains(sealers, node.NodeID)
+		if lag := report.HighestBlock - node.BlockNumber; lag > c.maxBlockLag() {
+			report.problem("%s: %d blocks behind the highest block %d", node.Name, lag, report.HighestBlock)
+		}
+		if node.View >= 0 && uint64(report.HighestView-node.View) > c.maxViewLag() {
+			report.problem("%s: view %d is %d views behind the highest view %d", node.Name, node.View, report.HighestView-node.View, report.HighestView)
+		}
+		if node.LeaderFailed {
+			report.problem("%s: the leader failed, a view change is in progress", node.Name)
+		}
+	}
+}
+
+// checkSealers reports the sealers which are neither queried nor connected to a
+// queried node, and the ones which are lagging behind according to their peers.
+func (c *Checker) checkSealers(report *Report, nodes []*NodeStatus, sealers []string) {
+	for _, id := range sealers {
+		sealer := &SealerStatus{NodeID: id, View: -1}
+		known, queried := false, false
+		for _, node := range nodes {
+			if node.NodeID == id {
+				queried, known = true, true
+				sealer.BlockNumber = node.BlockNumber
+			}
+			sealer.Connected = sealer.Connected || node.connected[id]
+			if number, ok := node.blocks[id]; ok && !queried {
+				known = true
+				if number > sealer.BlockNumber {
+					sealer.BlockNumber = number
+				}
+			}
+			if view, ok := node.views[id]; ok && int64(view) > sealer.View {
+				sealer.View = int64(view)
+			}
+			if node.NodeID == id && node.View > sealer.View {
+				sealer.View = node.View
+			}
+		}
+		report.Sealers = append(report.Sealers, sealer)
+		switch lag := report.HighestBlock - sealer.BlockNumber; {
+		case !sealer.Connected:
+			report.problem("sealer %s: disconnected, it is not queried nor a peer of a queried node", ShortID(id))
+		case known && lag > c.maxBlockLag():
+			sealer.Lagging = true
+			if queried {
+				break // reported with the node
+			}
+			report.problem("sealer %s: %d blocks behind the highest block %d", ShortID(id), lag, report.HighestBlock)
+		}
+	}
+}
+
+func (c *Checker) maxBlockLag() uint64 {
+	if c.MaxBlockLag == 0 {
+		return DefaultMaxBlockLag
+	}
+	return c.MaxBlockLag
+}
+
+func (c *Checker) maxViewLag() uint64 {
+	if c.MaxViewLag == 0 {
+		return DefaultMaxViewLag
+	}
+	return c.MaxViewLag
+}
+
+func (r *Report) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// syncStatus is the result of getSyncStatus.
+type syncStatus struct {
+	BlockNumber uint64      `json:"blockNumber"`
+	IsSyncing   bool        `json:"isSyncing"`
+	NodeID      string      `json:"nodeId"`
+	TxPoolSize  json.Number `json:"txPoolSize"`
+	Peers       []struct {
+		BlockNumber uint64 `json:"blockNumber"`
+		NodeID      string `json:"nodeId"`
+	} `json:"peers"`
+}
+
+// queryNode queries the state of a node, stopping at the first failure.
+func queryNode(ctx context.Context, node Node) *NodeStatus {
+	status := &NodeStatus{
+		Name:      node.Name,
+		View:      -1,
+		c:         node.Client,
+		connected: make(map[string]bool),
+		blocks:    make(map[string]uint64),
+		views:     make(map[string]uint64),
+	}
+	status.Err = func() error {
+		raw, err := node.Client.GetClientVersion(ctx)
+		if err != nil {
+			return err
+		}
+		var version map[string]string
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return fmt.Errorf("invalid client version: %v", err)
+		}
+		status.Version = version["FISCO-BCOS Version"]
+
+		if raw, err = node.Client.GetSyncStatus(ctx); err != nil {
+			return err
+		}
+		var sync syncStatus
+		if err := json.Unmarshal(raw, &sync); err != nil {
+			return fmt.Errorf("invalid sync status: %v", err)
+		}
+		status.NodeID, status.BlockNumber, status.Syncing = sync.NodeID, sync.BlockNumber, sync.IsSyncing
+		if size, err := strconv.Atoi(sync.TxPoolSize.String()); err == nil {
+			status.TxPoolSize = size
+		}
+		status.connected[sync.NodeID] = true
+		status.blocks[sync.NodeID] = sync.BlockNumber
+		for _, peer := range sync.Peers {
+			status.connected[peer.NodeID] = true
+			status.blocks[peer.NodeID] = peer.BlockNumber
+		}
+
+		if raw, err = node.Client.GetPeers(ctx); err != nil {
+			return err
+		}
+		var peers []struct {
+			NodeID string `json:"NodeID"`
+		}
+		if err := json.Unmarshal(raw, &peers); err != nil {
+			return fmt.Errorf("invalid peers: %v", err)
+		}
+		status.Peers = len(peers)
+		for _, peer := range peers {
+			status.connected[peer.NodeID] = true
+		}
+
+		if raw, err = node.Client.GetSealerList(ctx); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, &status.sealers); err != nil {
+			return fmt.Errorf("invalid sealer list: %v", err)
+		}
+		sort.Strings(status.sealers)
+
+		// the view and the consensus status are only reported by PBFT nodes
+		if raw, err := node.Client.GetPBFTView(ctx); err == nil {
+			var view hexutil.Uint64
+			if err := json.Unmarshal(raw, &view); err == nil {
+				status.View = int64(view)
+			}
+		}
+		if raw, err := node.Client.GetConsensusStatus(ctx); err == nil {
+			parseConsensusStatus(raw, status)
+		}
+		return nil
+	}()
+	return status
+}
+
+// parseConsensusStatus reads the status of the PBFT consensus, which is made of
+// an object holding the state of the node and of a list of the views of the
+// sealers.
+func parseConsensusStatus(raw []byte, status *NodeStatus) {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(raw, &parts); err != nil || len(parts) == 0 {
+		return
+	}
+	var state struct {
+		LeaderFailed bool `json:"leaderFailed"`
+	}
+	if err := json.Unmarshal(parts[0], &state); err == nil {
+		status.LeaderFailed = state.LeaderFailed
+	}
+	if len(parts) < 2 {
+		return
+	}
+	var views []struct {
+		NodeID string `json:"nodeId"`
+		View   uint64 `json:"view"`
+	}
+	if err := json.Unmarshal(parts[1], &views); err == nil {
+		for _, view := range views {
+			status.views[view.NodeID] = view.View
+		}
+	}
+}
+
+// readConsensusTable returns the sorted node IDs of the sealers of the
+// _sys_consensus_ table enabled at the given block, or nil if it cannot be read.
+// A sealer added at a block only takes part in the consensus from its enable_num.
+func readConsensusTable(ctx context.Context, c *client.Client, number uint64) []string {
+	caller, err := crud.NewCrudCaller(crud.CRUDPrecompileAddress, c)
+	if err != nil {
+		return nil
+	}
+	result, err := caller.Select(&bind.CallOpts{Context: ctx}, common.SYS_CONSENSUS, "node", "{}", "")
+	if err != nil {
+		return nil
+	}
+	var entries []map[string]string
+	if err := json.Unmarshal([]byte(result), &entries); err != nil {
+		return nil
+	}
+	sealers := []string{}
+	for _, entry := range entries {
+		if entry["type"] != "sealer" {
+			continue
+		}
+		if enable, err := strconv.ParseUint(entry["enable_num"], 10, 64); err == nil && enable > number {
+			continue
+		}
+		sealers = append(sealers, entry["node_id"])
+	}
+	sort.Strings(sealers)
+	return sealers
+}
+
+// diff returns the IDs of want missing in have, and the IDs of have not in want.
+func diff(want, have []string) (missing, extra []string) {
+	for _, id := range want {
+		if !contains(have, id) {
+			missing = append(missing, id)
+		}
+	}
+	for _, id := range have {
+		if !contains(want, id) {
+			extra = append(extra, id)
+		}
+	}
+	return missing, extra
+}
+
+func contains(ids []string, id string) bool {
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ShortID abbreviates a node ID for display.
+func ShortID(id string) string {
+	if len(id) <= 16 {
+		return id
+	}
+	return id[:8] + "…" + id[len(id)-8:]
+}
+
+func shortIDs(ids []string) string {
+	short := make([]string, len(ids))
+	for i, id := range ids {
+		short[i] = ShortID(id)
+	}
+	return "[" + strings.Join(short, " ") + "]"
+}
@@ -0,0 +1,254 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/precompile/crud"
+)
+
+// testGroup is a group of mock nodes sharing the same sealers, the first ones
+// of which are queried.
+type testGroup struct {
+	t       *testing.T
+	servers []*clienttest.Server
+	sealers []string
+	nodes   []Node
+	table   []string // sealers of the _sys_consensus_ table
+	pending []string // sealers of the table enabled after block 100
+}
+
+func newTestGroup(t *testing.T, queried int) *testGroup {
+	g := &testGroup{t: t}
+	for i := 0; i < queried; i++ {
+		srv := clienttest.NewServer()
+		c, err := client.Dial(srv.URL, 1)
+		if err != nil {
+			g.close()
+			t.Fatalf("init rpc client failed: %v", err)
+		}
+		g.servers = append(g.servers, srv)
+		g.nodes = append(g.nodes, Node{Name: srv.URL, Client: c})
+	}
+	g.sealers = g.servers[0].SealerList()
+	sort.Strings(g.sealers)
+	g.table = g.sealers
+
+	parsed, err := abi.JSON(strings.NewReader(crud.CrudABI))
+	if err != nil {
+		t.Fatalf("parse ABI failed: %v", err)
+	}
+	selectMethod := parsed.Methods["select"]
+	for _, srv := range g.servers {
+		srv.HandleCall(func(from, to common.Address, data []byte) ([]byte, error) {
+			var entries []map[string]string
+			for _, id := range g.table {
+				entries = append(entries, map[string]string{"name": "node", "node_id": id, "type": "sealer", "enable_num": "0"})
+			}
+			for _, id := range g.pending {
+				entries = append(entries, map[string]string{"name": "node", "node_id": id, "type": "sealer", "enable_num": "101"})
+			}
+			result, _ := json.Marshal(entries)
+			return selectMethod.Outputs.Pack(string(result))
+		})
+	}
+	// every queried node is connected to all the other sealers
+	for i := range g.servers {
+		g.setNode(i, 100, g.others(i)...)
+	}
+	return g
+}
+
+func (g *testGroup) close() {
+	for _, srv := range g.servers {
+		srv.Close()
+	}
+}
+
+// others returns the sealers except the one of the i-th node.
+func (g *testGroup) others(i int) []string {
+	var ids []string
+	for j, id := range g.sealers {
+		if j != i {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// setNode sets the block number of the i-th node and its peers, which are at the
+// same block number.
+func (g *testGroup) setNode(i int, number uint64, peers ...string) {
+	syncPeers := []map[string]interface{}{}
+	nodePeers := []map[string]interface{}{}
+	for _, id := range peers {
+		syncPeers = append(syncPeers, map[string]interface{}{"blockNumber": number, "nodeId": id})
+		nodePeers = append(nodePeers, map[string]interface{}{"NodeID": id, "Topic": []string{}})
+	}
+	g.servers[i].SetResult("getSyncStatus", map[string]interface{}{
+		"blockNumber": number,
+		"isSyncing":   false,
+		"nodeId":      g.sealers[i],
+		"peers":       syncPeers,
+		"txPoolSize":  "0",
+	})
+	g.servers[i].SetResult("getPeers", nodePeers)
+}
+
+func (g *testGroup) check() *Report {
+	report := NewChecker(g.nodes...).Check(context.Background())
+	if len(report.Nodes) != len(g.nodes) {
+		g.t.Fatalf("expected %d node statuses, got %d", len(g.nodes), len(report.Nodes))
+	}
+	return report
+}
+
+func expectProblems(t *testing.T, report *Report, want ...string) {
+	t.Helper()
+	if len(report.Problems) != len(want) {
+		t.Fatalf("expected %d problems, got %d:\n%s", len(want), len(report.Problems), strings.Join(report.Problems, "\n"))
+	}
+	for i, problem := range report.Problems {
+		if !strings.Contains(problem, want[i]) {
+			t.Errorf("problem %d: expected %q in %q", i, want[i], problem)
+		}
+	}
+}
+
+func TestHealthyGroup(t *testing.T) {
+	g := newTestGroup(t, 3)
+	defer g.close()
+
+	report := g.check()
+	expectProblems(t, report)
+	if !report.Healthy() || report.HighestBlock != 100 || report.HighestView != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	for i, node := range report.Nodes {
+		if node.Err != nil || node.NodeID != g.sealers[i] || !node.Sealer || node.BlockNumber != 100 || node.Peers != len(g.sealers)-1 {
+			t.Errorf("node %d: unexpected status %+v", i, node)
+		}
+	}
+	if len(report.Sealers) != len(g.sealers) {
+		t.Fatalf("expected %d sealers, got %d", len(g.sealers), len(report.Sealers))
+	}
+	for _, sealer := range report.Sealers {
+		if !sealer.Connected || sealer.Lagging || sealer.BlockNumber != 100 {
+			t.Errorf("sealer %s: unexpected status %+v", ShortID(sealer.NodeID), sealer)
+		}
+	}
+	if strings.Join(report.ConsensusTable, ",") != strings.Join(g.sealers, ",") {
+		t.Errorf("unexpected consensus table %v", report.ConsensusTable)
+	}
+}
+
+func TestLaggingNodes(t *testing.T) {
+	g := newTestGroup(t, 3)
+	defer g.close()
+
+	// the second node is behind, and sees the fourth sealer even further behind
+	g.servers[1].SetResult("getSyncStatus", map[string]interface{}{
+		"blockNumber": 80,
+		"nodeId":      g.sealers[1],
+		"peers": []map[string]interface{}{
+			{"blockNumber": 100, "nodeId": g.sealers[0]},
+			{"blockNumber": 50, "nodeId": g.sealers[3]},
+		},
+		"txPoolSize": "3",
+	})
+	// which is not known by the other nodes to be at any block
+	g.setNode(0, 100, g.sealers[1], g.sealers[2])
+	g.setNode(2, 100, g.sealers[0], g.sealers[1])
+	g.servers[2].SetResult("getPbftView", "0x20")
+
+	report := g.check()
+	expectProblems(t, report,
+		g.nodes[0].Name+": view 0 is 32 views behind",
+		g.nodes[1].Name+": 20 blocks behind the highest block 100",
+		g.nodes[1].Name+": view 0 is 32 views behind",
+		"sealer "+ShortID(g.sealers[3])+": 50 blocks behind",
+	)
+	if report.HighestView != 32 || report.Nodes[1].TxPoolSize != 3 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if !report.Sealers[1].Lagging || report.Sealers[1].BlockNumber != 80 || !report.Sealers[3].Lagging {
+		t.Errorf("lagging sealers not flagged: %+v %+v", report.Sealers[1], report.Sealers[3])
+	}
+}
+
+func TestDisconnectedSealer(t *testing.T) {
+	g := newTestGroup(t, 3)
+	defer g.close()
+
+	// the second node is down, but still seen by the others, unlike the fourth one
+	g.servers[1].Close()
+	g.setNode(0, 100, g.sealers[1], g.sealers[2])
+	g.setNode(2, 100, g.sealers[0])
+	g.servers[2].SetResult("getConsensusStatus", []interface{}{map[string]interface{}{"leaderFailed": true}})
+
+	report := g.check()
+	expectProblems(t, report,
+		g.nodes[1].Name+": unreachable",
+		g.nodes[2].Name+": the leader failed",
+		"sealer "+ShortID(g.sealers[3])+": disconnected",
+	)
+	if !report.Sealers[1].Connected || report.Sealers[3].Connected {
+		t.Errorf("unexpected sealer statuses: %+v %+v", report.Sealers[1], report.Sealers[3])
+	}
+}
+
+func TestSealerSetMismatch(t *testing.T) {
+	g := newTestGroup(t, 3)
+	defer g.close()
+
+	// the third node has removed the fourth sealer, and the table holds an unknown one
+	g.servers[2].SetSealerList(g.sealers[:3])
+	unknown := strings.Repeat("ab", 64)
+	g.table = []string{g.sealers[0], g.sealers[1], g.sealers[2], unknown}
+
+	report := g.check()
+	expectProblems(t, report,
+		g.nodes[2].Name+": reports a different sealer list",
+		"the sealer list does not match the _sys_consensus_ table: missing ["+ShortID(unknown)+"], unexpected ["+ShortID(g.sealers[3])+"]",
+	)
+
+	// the table cannot be read if the CRUD precompile fails
+	for _, srv := range g.servers {
+		srv.HandleCall(func(from, to common.Address, data []byte) ([]byte, error) {
+			return nil, nil
+		})
+	}
+	report = g.check()
+	if report.ConsensusTable != nil || !strings.Contains(strings.Join(report.Problems, "\n"), "table could not be read") {
+		t.Errorf("unreadable table not reported: %v", report.Problems)
+	}
+}
+
+func TestPendingSealer(t *testing.T) {
+	g := newTestGroup(t, 2)
+	defer g.close()
+
+	// a sealer just added is not a sealer before its enable_num
+	added := strings.Repeat("cd", 64)
+	g.pending = []string{added}
+	report := g.check()
+	expectProblems(t, report)
+	if strings.Join(report.ConsensusTable, ",") != strings.Join(g.sealers, ",") {
+		t.Errorf("unexpected consensus table %v", report.ConsensusTable)
+	}
+
+	for i := range g.servers {
+		g.setNode(i, 101, g.others(i)...)
+	}
+	report = g.check()
+	expectProblems(t, report,
+		"the sealer list does not match the _sys_consensus_ table: missing ["+ShortID(added)+"]",
+	)
+}
@@ -0,0 +1,358 @@
+package indexer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/common"
+)
+
+// Export formats.
+const (
+	FormatJSONL = "jsonl" // a Record in JSON per line
+	FormatCSV   = "csv"   // a row per transaction, see CSVHeader
+)
+
+// CSVHeader is the header row of a CSV export. Decoded arguments, return values
+// and events are JSON encoded in their columns.
+var CSVHeader = []string{
+	"blockNumber", "blockHash", "timestamp", "transactionIndex", "hash", "from", "to",
+	"contractAddress", "status", "method", "args", "input", "output", "outputs", "events",
+}
+
+// DefaultExportConcurrency is the default number of block ranges an Exporter
+// fetches at the same time.
+const DefaultExportConcurrency = 4
+
+// Exporter writes the transactions of a range of blocks, decoded against the
+// known ABIs, to a JSON Lines or CSV file.
+//
+// The progress of an export is kept in a file next to the output, named after it
+// with a ".progress" suffix. It is updated after each range of blocks has been
+// written and synced, so an interrupted export continues from the last range
+// written when it is run again; anything written after it is discarded. The
+// progress file is removed once the export is complete.
+type Exporter struct {
+	client *client.Client
+	abis   ABIRegistry
+
+	Format      string            // FormatJSONL or FormatCSV
+	RangeSize   uint64            // number of blocks fetched at a time
+	Concurrency int               // number of ranges fetched at the same time
+	Progress    func(next uint64) // optional, called after each range is written
+}
+
+// NewExporter creates an exporter reading the chain with c and decoding the
+// transactions against abis, which may be nil.
+func NewExporter(c *client.Client, abis ABIRegistry) *Exporter {
+	return &Exporter{
+		client:      c,
+		abis:        abis,
+		Format:      FormatJSONL,
+		RangeSize:   DefaultRangeSize,
+		Concurrency: DefaultExportConcurrency,
+	}
+}
+
+// exportProgress is the content of a progress file.
+type exportProgress struct {
+	From   uint64 `json:"from"`
+	To     uint64 `json:"to"`
+	Format string `json:"format"`
+	Next   uint64 `json:"next"`   // next block to export
+	Offset int64  `json:"offset"` // size of the output up to block Next
+}
+
+// Export writes the transactions of the blocks from first to last (both
+// inclusive) to the file at path, resuming a previous export of the same blocks
+// and format if its progress file exists.
+func (e *Exporter) Export(ctx context.Context, path string, first, last uint64) error {
+	if e.Format != FormatJSONL && e.Format != FormatCSV {
+		return fmt.Errorf("unsupported export format %q", e.Format)
+	}
+	if first > last {
+		return fmt.Errorf("invalid block range [%d, %d]", first, last)
+	}
+	out, progress, err := e.open(path, first, last)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	w := bufio.NewWriter(out)
+	var cw *csv.Writer
+	if e.Format == FormatCSV {
+		cw = csv.NewWriter(w)
+	}
+	size := e.RangeSize
+	if size == 0 {
+		size = DefaultRangeSize
+	}
+	concurrency := e.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	for progress.Next <= last {
+		// Fetch a window of ranges concurrently and write them in order
+		type result struct {
+			last    uint64
+			records []*Record
+			err     error
+		}
+		var (
+			results []*result
+			wg      sync.WaitGroup
+		)
+		for start := progress.Next; start <= last && len(results) < concurrency; {
+			end := start + size - 1
+			if end > last || end < start {
+				end = last
+			}
+			res := &result{last: end}
+			results = append(results, res)
+			wg.Add(1)
+			go func(start uint64) {
+				defer wg.Done()
+				res.records, res.err = fetchRange(ctx, e.client, e.abis, start, res.last)
+			}(start)
+			if end == last {
+				break
+			}
+			start = end + 1
+		}
+		wg.Wait()
+		for _, res := range results {
+			if res.err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return res.err
+			}
+			for _, record := range res.records {
+				if cw != nil {
+					err = cw.Write(csvRow(record))
+				} else {
+					err = writeJSONLine(w, record)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if cw != nil {
+				cw.Flush()
+				err = cw.Error()
+			}
+			if err == nil {
+				err = w.Flush()
+			}
+			if err == nil {
+				err = out.Sync()
+			}
+			if err != nil {
+				return err
+			}
+			if progress.Offset, err = out.Seek(0, io.SeekCurrent); err != nil {
+				return err
+			}
+			progress.Next = res.last + 1
+			if err := writeProgress(path, progress); err != nil {
+				return err
+			}
+			if e.Progress != nil {
+				e.Progress(progress.Next)
+			}
+		}
+	}
+	return os.Remove(progressPath(path))
+}
+
+// open opens the output of an export, truncated to the recorded progress if the
+// export is resumed or holding only the header otherwise.
+func (e *Exporter) open(path string, first, last uint64) (*os.File, *exportProgress, error) {
+	progress := new(exportProgress)
+	blob, err := ioutil.ReadFile(progressPath(path))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(blob, progress); err != nil {
+			return nil, nil, fmt.Errorf("invalid progress file %s: %v", progressPath(path), err)
+		}
+		if progress.From != first || progress.To != last || progress.Format != e.Format {
+			return nil, nil, fmt.Errorf("progress file %s belongs to an export of blocks [%d, %d] in %s",
+				progressPath(path), progress.From, progress.To, progress.Format)
+		}
+		out, err := os.OpenFile(path, os.O_RDWR, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := out.Truncate(progress.Offset); err != nil {
+			out.Close()
+			return nil, nil, err
+		}
+		if _, err := out.Seek(progress.Offset, io.SeekStart); err != nil {
+			out.Close()
+			return nil, nil, err
+		}
+		return out, progress, nil
+
+	case os.IsNotExist(err):
+		out, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		if e.Format == FormatCSV {
+			cw := csv.NewWriter(out)
+			cw.Write(CSVHeader)
+			if cw.Flush(); cw.Error() != nil {
+				out.Close()
+				return nil, nil, cw.Error()
+			}
+		}
+		*progress = exportProgress{From: first, To: last, Format: e.Format, Next: first}
+		if progress.Offset, err = out.Seek(0, io.SeekCurrent); err == nil {
+			err = writeProgress(path, progress)
+		}
+		if err != nil {
+			out.Close()
+			return nil, nil, err
+		}
+		return out, progress, nil
+
+	default:
+		return nil, nil, err
+	}
+}
+
+func progressPath(path string) string {
+	return path + ".progress"
+}
+
+// writeProgress atomically replaces the progress file of the output at path.
+func writeProgress(path string, progress *exportProgress) error {
+	blob, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	tmp := progressPath(path) + ".tmp"
+	if err := ioutil.WriteFile(tmp, blob, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, progressPath(path))
+}
+
+func writeJSONLine(w io.Writer, record *Record) error {
+	blob, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(blob, '\n'))
+	return err
+}
+
+// csvRow formats a record as a row of CSVHeader.
+func csvRow(record *Record) []string {
+	tx := record.Transaction
+	var to, contract string
+	if tx.To != nil {
+		to = tx.To.Hex()
+	}
+	if tx.ContractAddress != nil {
+		contract = tx.ContractAddress.Hex()
+	}
+	return []string{
+		strconv.FormatUint(tx.BlockNumber, 10),
+		tx.BlockHash.Hex(),
+		strconv.FormatUint(tx.Timestamp, 10),
+		strconv.FormatUint(tx.Index, 10),
+		tx.Hash.Hex(),
+		tx.From.Hex(),
+		to,
+		contract,
+		tx.Status,
+		tx.Method,
+		jsonColumn(tx.Args),
+		tx.Input.String(),
+		tx.Output.String(),
+		jsonColumn(record.Outputs),
+		jsonColumn(record.Events),
+	}
+}
+
+// jsonColumn encodes a list in JSON, or returns an empty string if it is empty.
+func jsonColumn(v interface{}) string {
+	blob, _ := json.Marshal(v)
+	if s := string(blob); s != "null" && s != "[]" {
+		return s
+	}
+	return ""
+}
+
+// ABIDir is an ABIRegistry of the ABI files (*.abi or *.json) in a directory. A
+// file named after a contract address, e.g. 0x27c1b5d9fe3ab035c2e9db7199d4beb139e12292.abi,
+// holds the ABI of that contract only. The methods and events of the other files
+// are used for any other contract, matched by their selectors and topics.
+type ABIDir struct {
+	contracts map[common.Address]*abi.ABI
+	shared    *abi.ABI
+}
+
+// LoadABIDir loads the ABI files of the directory.
+func LoadABIDir(dir string) (*ABIDir, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	d := &ABIDir{
+		contracts: make(map[common.Address]*abi.ABI),
+		shared:    &abi.ABI{Methods: make(map[string]abi.Method), Events: make(map[string]abi.Event)},
+	}
+	for _, file := range files {
+		ext := filepath.Ext(file.Name())
+		if file.IsDir() || (ext != ".abi" && ext != ".json") {
+			continue
+		}
+		blob, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := abi.JSON(strings.NewReader(string(blob)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ABI file %s: %v", file.Name(), err)
+		}
+		if name := strings.TrimSuffix(file.Name(), ext); common.IsHexAddress(name) {
+			d.contracts[common.HexToAddress(name)] = &parsed
+			continue
+		}
+		// Methods and events are only looked up by id, the keys just keep
+		// those of different files apart
+		for name, method := range parsed.Methods {
+			d.shared.Methods[file.Name()+":"+name] = method
+		}
+		for name, event := range parsed.Events {
+			d.shared.Events[file.Name()+":"+name] = event
+		}
+	}
+	return d, nil
+}
+
+// ABI implements ABIRegistry.
+func (d *ABIDir) ABI(address common.Address) *abi.ABI {
+	if parsed, ok := d.contracts[address]; ok {
+		return parsed
+	}
+	if len(d.shared.Methods) == 0 && len(d.shared.Events) == 0 {
+		return nil
+	}
+	return d.shared
+}
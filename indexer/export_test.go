@@ -0,0 +1,103 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	tc := newTestChain(t)
+	defer tc.srv.Close()
+	for i := 0; i < 6; i++ {
+		tc.register(t, "7", strings.Repeat("x", i))
+	}
+	dir, err := ioutil.TempDir("", "export-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	abiDir := filepath.Join(dir, "abi")
+	if err := os.Mkdir(abiDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(abiDir, "Registry.abi"), []byte(registryABI), 0644); err != nil {
+		t.Fatal(err)
+	}
+	abis, err := LoadABIDir(abiDir)
+	if err != nil {
+		t.Fatalf("load ABI directory failed: %v", err)
+	}
+
+	// A complete export of blocks 1 to 7 in one go
+	exporter := NewExporter(tc.client, abis)
+	exporter.RangeSize, exporter.Concurrency = 2, 2
+	want := filepath.Join(dir, "want.jsonl")
+	if err := exporter.Export(context.Background(), want, 1, 7); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	wantBlob, _ := ioutil.ReadFile(want)
+	lines := strings.Split(strings.TrimSpace(string(wantBlob)), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("exported %d transactions, want 7:\n%s", len(lines), wantBlob)
+	}
+	var record Record
+	if err := json.Unmarshal([]byte(lines[6]), &record); err != nil {
+		t.Fatalf("invalid record %s: %v", lines[6], err)
+	}
+	if record.Method != "register" || record.Args[1].Value != "xxxxx" || len(record.Outputs) != 1 || record.Outputs[0].Value != "6" ||
+		len(record.Events) != 2 || record.Events[1].Name != "Counted" {
+		t.Fatalf("unexpected record: %s", lines[6])
+	}
+	if _, err := os.Stat(progressPath(want)); !os.IsNotExist(err) {
+		t.Fatalf("progress file not removed after the export: %v", err)
+	}
+
+	// An export interrupted after the first range continues where it stopped
+	path := filepath.Join(dir, "blocks.jsonl")
+	ctx, cancel := context.WithCancel(context.Background())
+	exporter.Progress = func(next uint64) { cancel() }
+	if err := exporter.Export(ctx, path, 1, 7); err != context.Canceled {
+		t.Fatalf("expected the export to be canceled, have %v", err)
+	}
+	// Data written after the last progress update is discarded
+	out, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	out.WriteString(`{"partial":`)
+	out.Close()
+	if err := NewExporter(tc.client, abis).Export(context.Background(), path, 2, 7); err == nil {
+		t.Fatalf("expected an error for a mismatching progress file")
+	}
+	var progress exportProgress
+	if blob, err := ioutil.ReadFile(progressPath(path)); err != nil || json.Unmarshal(blob, &progress) != nil || progress.Next < 3 {
+		t.Fatalf("unexpected progress file %s (%v)", blob, err)
+	}
+	exporter.Progress = nil
+	tc.srv.ClearRequests()
+	if err := exporter.Export(context.Background(), path, 1, 7); err != nil {
+		t.Fatalf("resumed export failed: %v", err)
+	}
+	if reqs := tc.srv.RequestsFor("getBlockByNumber"); uint64(len(reqs)) != 8-progress.Next {
+		t.Fatalf("resumed export from block %d read %d blocks", progress.Next, len(reqs))
+	}
+	if blob, _ := ioutil.ReadFile(path); !bytes.Equal(blob, wantBlob) {
+		t.Fatalf("resumed export mismatch:\nhave %s\nwant %s", blob, wantBlob)
+	}
+
+	// CSV exports have a header row
+	path = filepath.Join(dir, "blocks.csv")
+	exporter.Format = FormatCSV
+	if err := exporter.Export(context.Background(), path, 2, 3); err != nil {
+		t.Fatalf("CSV export failed: %v", err)
+	}
+	blob, _ := ioutil.ReadFile(path)
+	rows, err := csv.NewReader(bytes.NewReader(blob)).ReadAll()
+	if err != nil || len(rows) != 3 || len(rows[0]) != len(CSVHeader) || rows[2][9] != "register" || rows[2][0] != "3" {
+		t.Fatalf("unexpected CSV export (%v):\n%s", err, blob)
+	}
+}
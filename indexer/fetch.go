@@ -0,0 +1,234 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+	"github.com/KasperLiu/gobcos/core/types"
+)
+
+// ABIRegistry resolves the ABI used to decode the method calls and events of a
+// contract. Both Store and ABIDir are registries.
+type ABIRegistry interface {
+	// ABI returns the ABI of the contract at address, or nil if it is unknown.
+	ABI(address common.Address) *abi.ABI
+}
+
+// Record is a transaction read from the chain along with its decoded return
+// values and its events.
+type Record struct {
+	*Transaction
+	Outputs []Arg    `json:"outputs,omitempty"` // empty if the method is unknown or failed
+	Events  []*Event `json:"events"`
+}
+
+// rpcBlock is the part of a block returned by getBlockByNumber used by the indexer.
+type rpcBlock struct {
+	Number       hexutil.Uint64 `json:"number"`
+	Hash         common.Hash    `json:"hash"`
+	Timestamp    hexutil.Uint64 `json:"timestamp"`
+	Transactions []common.Hash  `json:"transactions"`
+}
+
+// fetchRange reads the transactions of the blocks from first to last (both
+// inclusive) in chain order and decodes them against the registered ABIs, which
+// may be nil.
+func fetchRange(ctx context.Context, c *client.Client, abis ABIRegistry, first, last uint64) ([]*Record, error) {
+	raws, errs, err := c.GetBlocksByNumberRange(ctx, first, last, false)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]*rpcBlock, len(raws))
+	var hashes []common.Hash
+	for i, raw := range raws {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("block %d: %v", first+uint64(i), errs[i])
+		}
+		blocks[i] = new(rpcBlock)
+		if err := json.Unmarshal(raw, blocks[i]); err != nil {
+			return nil, fmt.Errorf("invalid block %d: %v", first+uint64(i), err)
+		}
+		hashes = append(hashes, blocks[i].Transactions...)
+	}
+	receipts, errs, err := c.GetTransactionReceipts(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*Record, 0, len(receipts))
+	for _, block := range blocks {
+		for _, hash := range block.Transactions {
+			i := len(records)
+			if errs[i] != nil {
+				return nil, fmt.Errorf("receipt of transaction %s: %v", hash.Hex(), errs[i])
+			}
+			record, err := decodeReceipt(abis, block, receipts[i])
+			if err != nil {
+				return nil, fmt.Errorf("transaction %s: %v", hash.Hex(), err)
+			}
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// decodeReceipt converts the receipt of a transaction in block to a record.
+func decodeReceipt(abis ABIRegistry, block *rpcBlock, receipt *types.Receipt) (*Record, error) {
+	index, err := hexutil.DecodeUint64(receipt.TransactionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction index %q", receipt.TransactionIndex)
+	}
+	input, err := hexutil.Decode(receipt.Input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input: %v", err)
+	}
+	output, err := hexutil.Decode(receipt.Output)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output: %v", err)
+	}
+	tx := &Transaction{
+		Hash:        common.HexToHash(receipt.TransactionHash),
+		BlockNumber: uint64(block.Number),
+		BlockHash:   block.Hash,
+		Timestamp:   uint64(block.Timestamp),
+		Index:       index,
+		From:        common.HexToAddress(receipt.From),
+		Input:       input,
+		Status:      receipt.Status,
+		Output:      output,
+	}
+	record := &Record{Transaction: tx, Events: make([]*Event, 0, len(receipt.Logs))}
+	if to := common.HexToAddress(receipt.To); to != (common.Address{}) {
+		tx.To = &to
+		if parsed := lookupABI(abis, to); parsed != nil {
+			var method *abi.Method
+			method, tx.Args = decodeCall(parsed, input)
+			if method != nil {
+				tx.Method = method.Name
+				if tx.Status == common.Success {
+					record.Outputs = decodeOutputs(method, output)
+				}
+			}
+		}
+	}
+	if contract := receipt.GetContractAddress(); contract != (common.Address{}) {
+		tx.ContractAddress = &contract
+	}
+	for i, l := range receipt.Logs {
+		log, err := l.ToLog()
+		if err != nil {
+			return nil, fmt.Errorf("log %d: %v", i, err)
+		}
+		logIndex, err := hexutil.DecodeUint64(l.LogIndex)
+		if err != nil {
+			logIndex = uint64(i)
+		}
+		event := &Event{
+			Contract:    log.Address,
+			Topics:      log.Topics,
+			Data:        log.Data,
+			TxHash:      tx.Hash,
+			BlockNumber: tx.BlockNumber,
+			TxIndex:     tx.Index,
+			LogIndex:    logIndex,
+		}
+		if parsed := lookupABI(abis, log.Address); parsed != nil {
+			event.Name, event.Args = decodeEvent(parsed, log.Topics, log.Data)
+		}
+		record.Events = append(record.Events, event)
+	}
+	return record, nil
+}
+
+func lookupABI(abis ABIRegistry, address common.Address) *abi.ABI {
+	if abis == nil {
+		return nil
+	}
+	return abis.ABI(address)
+}
+
+// decodeCall decodes the method call of a transaction input. Inputs which do not
+// match the ABI are left undecoded.
+func decodeCall(parsed *abi.ABI, input []byte) (*abi.Method, []Arg) {
+	if len(input) < 4 {
+		return nil, nil
+	}
+	method, err := parsed.MethodById(input[:4])
+	if err != nil {
+		return nil, nil
+	}
+	values, err := method.Inputs.UnpackValues(input[4:])
+	if err != nil {
+		return method, nil
+	}
+	return method, formatArgs(method.Inputs, values)
+}
+
+// decodeOutputs decodes the return values of a method call, or returns nil if
+// the output does not match them.
+func decodeOutputs(method *abi.Method, output []byte) []Arg {
+	if len(method.Outputs) == 0 {
+		return nil
+	}
+	values, err := method.Outputs.UnpackValues(output)
+	if err != nil {
+		return nil
+	}
+	return formatArgs(method.Outputs, values)
+}
+
+func formatArgs(arguments abi.Arguments, values []interface{}) []Arg {
+	args := make([]Arg, len(values))
+	for i, value := range values {
+		args[i] = Arg{Name: arguments[i].Name, Type: arguments[i].Type.String(), Value: abi.FormatValue(arguments[i].Type, value)}
+	}
+	return args
+}
+
+// decodeEvent decodes a log of an event in the ABI. Indexed arguments of dynamic
+// or composite types are only available as the hash in their topic.
+func decodeEvent(parsed *abi.ABI, topics []common.Hash, data []byte) (string, []Arg) {
+	if len(topics) == 0 {
+		return "", nil
+	}
+	event, err := parsed.EventByID(topics[0])
+	if err != nil {
+		return "", nil
+	}
+	values, err := event.Inputs.NonIndexed().UnpackValues(data)
+	if err != nil {
+		return event.Name, nil
+	}
+	args := make([]Arg, 0, len(event.Inputs))
+	topics = topics[1:]
+	for _, input := range event.Inputs {
+		arg := Arg{Name: input.Name, Type: input.Type.String()}
+		if !input.Indexed {
+			arg.Value = abi.FormatValue(input.Type, values[0])
+			values = values[1:]
+		} else if len(topics) == 0 {
+			return event.Name, nil
+		} else {
+			arg.Value = formatTopic(input.Type, topics[0])
+			topics = topics[1:]
+		}
+		args = append(args, arg)
+	}
+	return event.Name, args
+}
+
+// formatTopic formats an indexed argument.
+func formatTopic(typ abi.Type, topic common.Hash) string {
+	switch typ.T {
+	case abi.IntTy, abi.UintTy, abi.BoolTy, abi.AddressTy, abi.FixedBytesTy:
+		values, err := abi.Arguments{{Type: typ}}.UnpackValues(topic.Bytes())
+		if err == nil {
+			return abi.FormatValue(typ, values[0])
+		}
+	}
+	return topic.Hex()
+}
@@ -0,0 +1,115 @@
+// Package indexer follows a FISCO BCOS chain and indexes its transactions and
+// events into an embedded LevelDB store, so that they can be looked up by sender,
+// by contract and by event without scanning the chain.
+//
+// Blocks are indexed in order and the checkpoint is written atomically with the
+// entries of each range of blocks, so an indexer picks up where it stopped after
+// a restart. FISCO BCOS blocks are final once sealed by PBFT, hence indexed
+// blocks are never revisited.
+//
+// The same decoding backs the Exporter, which dumps ranges of blocks to JSON
+// Lines or CSV files for audits.
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+)
+
+const (
+	// DefaultPollInterval is the default interval of checking for new blocks once
+	// the indexer has caught up with the chain.
+	DefaultPollInterval = 2 * time.Second
+	// DefaultRangeSize is the default number of blocks indexed at a time.
+	DefaultRangeSize = 100
+)
+
+// Indexer indexes the blocks of the chain a client is connected to.
+type Indexer struct {
+	client *client.Client
+	store  *Store
+
+	PollInterval time.Duration // interval of checking for new blocks
+	RangeSize    uint64        // number of blocks fetched and written at a time
+}
+
+// New creates an indexer writing the blocks read with c to store.
+func New(c *client.Client, store *Store) *Indexer {
+	return &Indexer{
+		client:       c,
+		store:        store,
+		PollInterval: DefaultPollInterval,
+		RangeSize:    DefaultRangeSize,
+	}
+}
+
+// Run follows the chain until ctx is done, which is the only case it returns
+// ctx.Err(). Other errors are returned as they happen and the indexer can be
+// run again to resume from the checkpoint.
+func (ix *Indexer) Run(ctx context.Context) error {
+	for {
+		if _, err := ix.Sync(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-time.After(ix.PollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Sync indexes the blocks from the checkpoint up to the current block number of
+// the chain and returns the new checkpoint.
+func (ix *Indexer) Sync(ctx context.Context) (uint64, error) {
+	next, err := ix.store.Checkpoint()
+	if err != nil {
+		return 0, err
+	}
+	raw, err := ix.client.GetBlockNumber(ctx)
+	if err != nil {
+		return next, err
+	}
+	var number hexutil.Uint64
+	if err := json.Unmarshal(raw, &number); err != nil {
+		return next, fmt.Errorf("invalid block number %s: %v", raw, err)
+	}
+	head := uint64(number)
+	for next <= head {
+		last := next + ix.RangeSize - 1
+		if ix.RangeSize == 0 || last > head {
+			last = head
+		}
+		if err := ix.indexRange(ctx, next, last); err != nil {
+			return next, err
+		}
+		next = last + 1
+	}
+	return next, nil
+}
+
+// indexRange indexes the blocks from first to last (both inclusive) and moves the
+// checkpoint past them.
+func (ix *Indexer) indexRange(ctx context.Context, first, last uint64) error {
+	records, err := fetchRange(ctx, ix.client, ix.store, first, last)
+	if err != nil {
+		return err
+	}
+	batch := new(blockBatch)
+	for _, record := range records {
+		if err := batch.putTransaction(record.Transaction); err != nil {
+			return err
+		}
+		for _, event := range record.Events {
+			if err := batch.putEvent(event); err != nil {
+				return err
+			}
+		}
+	}
+	return ix.store.commit(batch, last+1)
+}
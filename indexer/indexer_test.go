@@ -0,0 +1,189 @@
+package indexer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
+	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/crypto"
+)
+
+const registryABI = `[
+	{"constant":false,"inputs":[{"name":"id","type":"uint256"},{"name":"name","type":"string"}],"name":"register","outputs":[{"name":"count","type":"uint64"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+	{"anonymous":false,"inputs":[{"indexed":true,"name":"owner","type":"address"},{"indexed":false,"name":"id","type":"uint256"},{"indexed":true,"name":"name","type":"string"}],"name":"Registered","type":"event"},
+	{"anonymous":false,"inputs":[{"indexed":false,"name":"count","type":"uint64"}],"name":"Counted","type":"event"}
+]`
+
+// testChain is a mock node with a deployed registry contract, whose register
+// method emits a Registered and a Counted event.
+type testChain struct {
+	srv      *clienttest.Server
+	client   *client.Client
+	auth     *bind.TransactOpts
+	abi      abi.ABI
+	address  common.Address
+	deployTx common.Hash
+	contract *bind.DynamicContract
+}
+
+func newTestChain(t *testing.T) *testChain {
+	srv := clienttest.NewServer()
+	c, err := client.Dial(srv.URL, 1)
+	if err != nil {
+		srv.Close()
+		t.Fatalf("init rpc client failed: %v", err)
+	}
+	key, err := crypto.HexToECDSA("145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b58")
+	if err != nil {
+		t.Fatalf("init privateKey failed: %v", err)
+	}
+	auth := bind.NewKeyedTransactor(key)
+	parsed, err := abi.JSON(strings.NewReader(registryABI))
+	if err != nil {
+		t.Fatalf("parse ABI failed: %v", err)
+	}
+	registered, counted := parsed.Events["Registered"], parsed.Events["Counted"]
+	count := uint64(0)
+	srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+		if tx.To() == nil {
+			return &clienttest.Execution{}
+		}
+		count++
+		args, _ := parsed.Methods["register"].Inputs.UnpackValues(tx.Data()[4:])
+		data, _ := registered.Inputs.NonIndexed().Pack(args[0])
+		countData, _ := counted.Inputs.Pack(count)
+		output, _ := parsed.Methods["register"].Outputs.Pack(count)
+		return &clienttest.Execution{Output: output, Logs: []*types.NewLog{
+			{Data: hexutil.Encode(data), Topics: []interface{}{
+				registered.Id().Hex(), common.BytesToHash(from.Bytes()).Hex(), crypto.Keccak256Hash([]byte(args[1].(string))).Hex(),
+			}},
+			{Data: hexutil.Encode(countData), Topics: []interface{}{counted.Id().Hex()}},
+		}}
+	})
+	address, tx, _, err := bind.DeployContract(auth, parsed, common.FromHex("0x6080604052"), c)
+	if err != nil {
+		srv.Close()
+		t.Fatalf("deploy contract failed: %v", err)
+	}
+	return &testChain{srv, c, auth, parsed, address, tx.Hash(), bind.NewDynamicContract(address, parsed, c)}
+}
+
+// register sends a register transaction.
+func (tc *testChain) register(t *testing.T, id, name string) common.Hash {
+	tx, err := tc.contract.Transact(tc.auth, "register", id, name)
+	if err != nil {
+		t.Fatalf("transact failed: %v", err)
+	}
+	return tx.Hash()
+}
+
+func TestIndexer(t *testing.T) {
+	tc := newTestChain(t)
+	defer tc.srv.Close()
+	srv, c, auth, address, counted := tc.srv, tc.client, tc.auth, tc.address, tc.abi.Events["Counted"]
+	calls := []common.Hash{tc.register(t, "7", "alice"), tc.register(t, "7", "bob")}
+
+	dir, err := ioutil.TempDir("", "indexer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := OpenStore(dir)
+	if err != nil {
+		t.Fatalf("open store failed: %v", err)
+	}
+	if err := store.RegisterABI(address, registryABI); err != nil {
+		t.Fatalf("register ABI failed: %v", err)
+	}
+	ix := New(c, store)
+	ix.RangeSize = 2
+	if next, err := ix.Sync(context.Background()); err != nil || next != 4 {
+		t.Fatalf("sync failed: checkpoint %d: %v", next, err)
+	}
+
+	// Transactions by sender and by contract
+	txs, err := store.FindTransactions(TxFilter{From: &auth.From})
+	if err != nil {
+		t.Fatalf("find transactions failed: %v", err)
+	}
+	if len(txs) != 3 || txs[0].Hash != tc.deployTx || txs[1].Hash != calls[0] || txs[2].Hash != calls[1] {
+		t.Fatalf("unexpected transactions of the sender: %+v", txs)
+	}
+	if txs[0].To != nil || txs[0].ContractAddress == nil || *txs[0].ContractAddress != address || txs[0].BlockNumber != 1 {
+		t.Fatalf("unexpected contract creation: %+v", txs[0])
+	}
+	wantArgs := []Arg{{Name: "id", Type: "uint256", Value: "7"}, {Name: "name", Type: "string", Value: "bob"}}
+	if tx := txs[2]; tx.Method != "register" || !reflect.DeepEqual(tx.Args, wantArgs) || tx.BlockNumber != 3 {
+		t.Fatalf("unexpected method call: %+v", tx)
+	}
+	txs, err = store.FindTransactions(TxFilter{To: &address, Limit: 2})
+	if err != nil || len(txs) != 2 || txs[0].Hash != tc.deployTx || txs[1].Hash != calls[0] {
+		t.Fatalf("unexpected transactions of the contract: %+v (%v)", txs, err)
+	}
+	other := common.HexToAddress("0x01")
+	if txs, err := store.FindTransactions(TxFilter{From: &auth.From, To: &other}); err != nil || len(txs) != 0 {
+		t.Fatalf("unexpected transactions of another contract: %+v (%v)", txs, err)
+	}
+	if _, err := store.FindTransactions(TxFilter{}); err == nil {
+		t.Fatalf("expected an error for an empty filter")
+	}
+	if _, err := store.Transaction(common.HexToHash("0x01")); err != ErrNotFound {
+		t.Fatalf("unexpected error for a missing transaction: %v", err)
+	}
+
+	// Events by name and by topic
+	events, err := store.FindEvents(EventFilter{Contract: address, Event: "Registered"})
+	if err != nil || len(events) != 2 {
+		t.Fatalf("unexpected events: %+v (%v)", events, err)
+	}
+	wantArgs = []Arg{
+		{Name: "owner", Type: "address", Value: auth.From.Hex()},
+		{Name: "id", Type: "uint256", Value: "7"},
+		{Name: "name", Type: "string", Value: crypto.Keccak256Hash([]byte("alice")).Hex()},
+	}
+	if ev := events[0]; ev.Name != "Registered" || !reflect.DeepEqual(ev.Args, wantArgs) || ev.TxHash != calls[0] || ev.LogIndex != 0 {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+	events, err = store.FindEvents(EventFilter{Contract: address, Event: counted.Id().Hex(), Limit: 1})
+	if err != nil || len(events) != 1 || events[0].Name != "Counted" || events[0].Args[0].Value != "1" {
+		t.Fatalf("unexpected events by topic: %+v (%v)", events, err)
+	}
+	if events, err := store.FindEvents(EventFilter{Contract: address}); err != nil || len(events) != 4 {
+		t.Fatalf("unexpected events of the contract: %+v (%v)", events, err)
+	}
+	if _, err := store.FindEvents(EventFilter{Contract: address, Event: "Unknown"}); err == nil {
+		t.Fatalf("expected an error for an unknown event")
+	}
+
+	// A restarted indexer resumes from the checkpoint with the registered ABIs
+	store.Close()
+	if store, err = OpenStore(dir); err != nil {
+		t.Fatalf("reopen store failed: %v", err)
+	}
+	defer store.Close()
+	tc.register(t, "8", "carol")
+	srv.ClearRequests()
+	if next, err := New(c, store).Sync(context.Background()); err != nil || next != 5 {
+		t.Fatalf("resync failed: checkpoint %d: %v", next, err)
+	}
+	reqs := srv.RequestsFor("getBlockByNumber")
+	var number string
+	if len(reqs) != 1 || reqs[0].Param(1, &number) != nil || number != "0x4" {
+		t.Fatalf("unexpected block requests after the restart: %v", reqs)
+	}
+	txs, err = store.FindTransactions(TxFilter{To: &address})
+	if err != nil || len(txs) != 4 || txs[3].Method != "register" || txs[3].Args[1].Value != "carol" {
+		t.Fatalf("unexpected transactions after the restart: %+v (%v)", txs, err)
+	}
+}
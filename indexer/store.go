@@ -0,0 +1,320 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/common/hexutil"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// ErrNotFound is returned when a transaction is not in the store.
+var ErrNotFound = errors.New("not found")
+
+// Database layout. Numbers are big endian so that the entries of an index are
+// iterated in chain order.
+//
+//	checkpoint                                   -> next block number to index
+//	abi-  address                                -> ABI JSON
+//	tx-   hash                                   -> Transaction JSON
+//	from- sender   block txIndex                 -> transaction hash
+//	to-   contract block txIndex                 -> transaction hash
+//	ev-   contract topic0 block txIndex logIndex -> Event JSON
+var (
+	checkpointKey = []byte("checkpoint")
+	abiPrefix     = []byte("abi-")
+	txPrefix      = []byte("tx-")
+	fromPrefix    = []byte("from-")
+	toPrefix      = []byte("to-")
+	eventPrefix   = []byte("ev-")
+)
+
+// Arg is a decoded argument of a method call or an event.
+type Arg struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"` // in the format of abi.FormatValue
+}
+
+// Transaction is an indexed transaction along with its receipt.
+type Transaction struct {
+	Hash            common.Hash     `json:"hash"`
+	BlockNumber     uint64          `json:"blockNumber"`
+	BlockHash       common.Hash     `json:"blockHash"`
+	Timestamp       uint64          `json:"timestamp"` // block timestamp in milliseconds
+	Index           uint64          `json:"transactionIndex"`
+	From            common.Address  `json:"from"`
+	To              *common.Address `json:"to"`                        // nil for contract creations
+	ContractAddress *common.Address `json:"contractAddress,omitempty"` // the created contract
+	Input           hexutil.Bytes   `json:"input"`
+	Status          string          `json:"status"`
+	Output          hexutil.Bytes   `json:"output"`
+	Method          string          `json:"method,omitempty"` // empty if the ABI of To is not registered
+	Args            []Arg           `json:"args,omitempty"`
+}
+
+// Event is an indexed contract event.
+type Event struct {
+	Contract    common.Address `json:"contract"`
+	Name        string         `json:"name,omitempty"` // empty if the ABI of Contract is not registered
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+	Args        []Arg          `json:"args,omitempty"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	BlockNumber uint64         `json:"blockNumber"`
+	TxIndex     uint64         `json:"transactionIndex"`
+	LogIndex    uint64         `json:"logIndex"`
+}
+
+// TxFilter selects indexed transactions. At least one of From and To must be set.
+type TxFilter struct {
+	From  *common.Address // sender
+	To    *common.Address // called or created contract
+	Limit int             // maximum number of results, 0 for no limit
+}
+
+// EventFilter selects indexed events of a contract.
+type EventFilter struct {
+	Contract common.Address
+	// Event is the name of the event in the registered ABI of Contract or the
+	// hex encoded topic of the event. All events are selected if it is empty.
+	Event string
+	Limit int // maximum number of results, 0 for no limit
+}
+
+// Store is the embedded LevelDB database the indexer writes to. It is safe for
+// concurrent use.
+type Store struct {
+	db *leveldb.DB
+
+	mu   sync.RWMutex
+	abis map[common.Address]*abi.ABI // registered ABIs
+}
+
+// OpenStore opens the store at the given directory, creating it if needed.
+func OpenStore(path string) (*Store, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db, abis: make(map[common.Address]*abi.ABI)}
+	it := db.NewIterator(util.BytesPrefix(abiPrefix), nil)
+	defer it.Release()
+	for it.Next() {
+		parsed, err := abi.JSON(strings.NewReader(string(it.Value())))
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("invalid ABI of %x in the store: %v", it.Key()[len(abiPrefix):], err)
+		}
+		s.abis[common.BytesToAddress(it.Key()[len(abiPrefix):])] = &parsed
+	}
+	if err := it.Error(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// RegisterABI records the ABI of the contract at address, which is used to
+// decode the method calls and events of the blocks indexed afterwards.
+func (s *Store) RegisterABI(address common.Address, abiJSON string) error {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return err
+	}
+	if err := s.db.Put(append(common.CopyBytes(abiPrefix), address.Bytes()...), []byte(abiJSON), nil); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.abis[address] = &parsed
+	s.mu.Unlock()
+	return nil
+}
+
+// ABI returns the registered ABI of the contract at address, or nil.
+func (s *Store) ABI(address common.Address) *abi.ABI {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.abis[address]
+}
+
+// Checkpoint returns the number of the next block to index.
+func (s *Store) Checkpoint() (uint64, error) {
+	blob, err := s.db.Get(checkpointKey, nil)
+	if err == leveldb.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(blob), nil
+}
+
+// Transaction returns the indexed transaction with the given hash.
+func (s *Store) Transaction(hash common.Hash) (*Transaction, error) {
+	blob, err := s.db.Get(append(common.CopyBytes(txPrefix), hash.Bytes()...), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	tx := new(Transaction)
+	if err := json.Unmarshal(blob, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// FindTransactions returns the indexed transactions matching the filter, in chain
+// order.
+func (s *Store) FindTransactions(f TxFilter) ([]*Transaction, error) {
+	var prefix []byte
+	switch {
+	case f.From != nil:
+		prefix = append(common.CopyBytes(fromPrefix), f.From.Bytes()...)
+	case f.To != nil:
+		prefix = append(common.CopyBytes(toPrefix), f.To.Bytes()...)
+	default:
+		return nil, errors.New("no sender or contract given")
+	}
+	it := s.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+
+	var txs []*Transaction
+	for it.Next() && (f.Limit <= 0 || len(txs) < f.Limit) {
+		tx, err := s.Transaction(common.BytesToHash(it.Value()))
+		if err != nil {
+			return nil, err
+		}
+		if f.From != nil && f.To != nil && !matchesContract(tx, *f.To) {
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	return txs, it.Error()
+}
+
+// FindEvents returns the indexed events matching the filter, in chain order.
+func (s *Store) FindEvents(f EventFilter) ([]*Event, error) {
+	prefix := append(common.CopyBytes(eventPrefix), f.Contract.Bytes()...)
+	if f.Event != "" {
+		topic, err := s.eventTopic(f.Contract, f.Event)
+		if err != nil {
+			return nil, err
+		}
+		prefix = append(prefix, topic.Bytes()...)
+	}
+	it := s.db.NewIterator(util.BytesPrefix(prefix), nil)
+	defer it.Release()
+
+	var events []*Event
+	for it.Next() && (f.Limit <= 0 || len(events) < f.Limit) {
+		event := new(Event)
+		if err := json.Unmarshal(it.Value(), event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, it.Error()
+}
+
+// eventTopic resolves an event name of the registered ABI of contract or a hex
+// encoded topic.
+func (s *Store) eventTopic(contract common.Address, event string) (common.Hash, error) {
+	if strings.HasPrefix(event, "0x") {
+		blob, err := hexutil.Decode(event)
+		if err != nil || len(blob) != common.HashLength {
+			return common.Hash{}, fmt.Errorf("invalid event topic %q", event)
+		}
+		return common.BytesToHash(blob), nil
+	}
+	parsed := s.ABI(contract)
+	if parsed == nil {
+		return common.Hash{}, fmt.Errorf("no ABI registered for contract %s", contract.Hex())
+	}
+	ev, ok := parsed.Events[event]
+	if !ok {
+		return common.Hash{}, fmt.Errorf("event '%s' not found in the ABI of %s", event, contract.Hex())
+	}
+	return ev.Id(), nil
+}
+
+// matchesContract reports whether the transaction called or created contract.
+func matchesContract(tx *Transaction, contract common.Address) bool {
+	return (tx.To != nil && *tx.To == contract) || (tx.ContractAddress != nil && *tx.ContractAddress == contract)
+}
+
+// blockBatch collects the entries of indexed blocks to be written atomically
+// along with the checkpoint.
+type blockBatch struct {
+	batch leveldb.Batch
+}
+
+func (b *blockBatch) putTransaction(tx *Transaction) error {
+	blob, err := json.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	b.batch.Put(append(common.CopyBytes(txPrefix), tx.Hash.Bytes()...), blob)
+	position := positionKey(tx.BlockNumber, tx.Index)
+	b.batch.Put(concat(fromPrefix, tx.From.Bytes(), position), tx.Hash.Bytes())
+	if tx.To != nil {
+		b.batch.Put(concat(toPrefix, tx.To.Bytes(), position), tx.Hash.Bytes())
+	}
+	if tx.ContractAddress != nil {
+		b.batch.Put(concat(toPrefix, tx.ContractAddress.Bytes(), position), tx.Hash.Bytes())
+	}
+	return nil
+}
+
+func (b *blockBatch) putEvent(event *Event) error {
+	blob, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var topic common.Hash // anonymous events without topics are kept under the zero topic
+	if len(event.Topics) > 0 {
+		topic = event.Topics[0]
+	}
+	var logIndex [8]byte
+	binary.BigEndian.PutUint64(logIndex[:], event.LogIndex)
+	b.batch.Put(concat(eventPrefix, event.Contract.Bytes(), topic.Bytes(), positionKey(event.BlockNumber, event.TxIndex), logIndex[:]), blob)
+	return nil
+}
+
+// commit writes the collected entries and moves the checkpoint to next.
+func (s *Store) commit(b *blockBatch, next uint64) error {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], next)
+	b.batch.Put(checkpointKey, blob[:])
+	return s.db.Write(&b.batch, nil)
+}
+
+// positionKey encodes the position of a transaction in the chain.
+func positionKey(number, index uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, number)
+	binary.BigEndian.PutUint64(key[8:], index)
+	return key
+}
+
+func concat(parts ...[]byte) []byte {
+	var key []byte
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key
+}
@@ -0,0 +1,135 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	termTimeFormat = "01-02|15:04:05.000"
+	termMsgJust    = 40
+)
+
+// Format turns a record into a line of text.
+type Format interface {
+	Format(r *Record) []byte
+}
+
+// FormatFunc returns a Format formatting the records with fn.
+func FormatFunc(fn func(r *Record) []byte) Format {
+	return formatFunc(fn)
+}
+
+type formatFunc func(r *Record) []byte
+
+func (f formatFunc) Format(r *Record) []byte {
+	return f(r)
+}
+
+// TerminalFormat returns a Format meant for humans reading a terminal:
+//
+//	INFO [10-19|15:04:05.000] Transaction sent                        hash=0x4a1f…
+func TerminalFormat() Format {
+	return FormatFunc(func(r *Record) []byte {
+		b := new(bytes.Buffer)
+		fmt.Fprintf(b, "%-5s[%s] %s", strings.ToUpper(r.Lvl.String()), r.Time.Format(termTimeFormat), r.Msg)
+		if len(r.Ctx) > 0 && len(r.Msg) < termMsgJust {
+			b.Write(bytes.Repeat([]byte{' '}, termMsgJust-len(r.Msg)))
+		}
+		for i := 0; i < len(r.Ctx); i += 2 {
+			b.WriteByte(' ')
+			b.WriteString(formatKey(r.Ctx[i]))
+			b.WriteByte('=')
+			b.WriteString(formatValue(r.Ctx[i+1]))
+		}
+		b.WriteByte('\n')
+		return b.Bytes()
+	})
+}
+
+// JSONFormat returns a Format writing a JSON object per record, holding its time
+// as "t", its level as "lvl", its message as "msg" and its context.
+func JSONFormat() Format {
+	return FormatFunc(func(r *Record) []byte {
+		props := map[string]interface{}{
+			"t":   r.Time.Format(time.RFC3339Nano),
+			"lvl": r.Lvl.String(),
+			"msg": r.Msg,
+		}
+		for i := 0; i < len(r.Ctx); i += 2 {
+			value := evaluate(r.Ctx[i+1])
+			if _, ok := value.(json.Marshaler); !ok {
+				value = jsonValue(value)
+			}
+			props[formatKey(r.Ctx[i])] = value
+		}
+		b, err := json.Marshal(props)
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{"LOG_ERROR": err.Error()})
+		}
+		return append(b, '\n')
+	})
+}
+
+func formatKey(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+// evaluate computes a Lazy value, and turns a nil pointer into nil so that the
+// methods of its type are not called on it.
+func evaluate(value interface{}) interface{} {
+	if lazy, ok := value.(Lazy); ok {
+		value = lazy.Fn()
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil
+	}
+	return value
+}
+
+// jsonValue returns the value to encode in JSON, the text of errors and
+// Stringers.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}
+
+// formatValue formats a value of the terminal format, quoting it if needed.
+func formatValue(value interface{}) string {
+	var s string
+	switch v := evaluate(value).(type) {
+	case nil:
+		return "nil"
+	case time.Time:
+		s = v.Format(time.RFC3339)
+	case time.Duration:
+		s = v.String()
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		s = fmt.Sprintf("%+v", v)
+	}
+	if s == "" || strings.ContainsAny(s, " =\"\n\t") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"io"
+	"sync"
+)
+
+// Handler writes the records of a logger somewhere. It must be safe for
+// concurrent use.
+type Handler interface {
+	Log(r *Record) error
+}
+
+// FuncHandler returns a Handler logging the records with fn.
+func FuncHandler(fn func(r *Record) error) Handler {
+	return funcHandler(fn)
+}
+
+type funcHandler func(r *Record) error
+
+func (h funcHandler) Log(r *Record) error {
+	return h(r)
+}
+
+// DiscardHandler returns a Handler dropping all records.
+func DiscardHandler() Handler {
+	return FuncHandler(func(r *Record) error {
+		return nil
+	})
+}
+
+// LvlFilterHandler returns a Handler passing the records of maxLvl or a more
+// important level to h, e.g. only the warn, error and crit records for LvlWarn.
+func LvlFilterHandler(maxLvl Lvl, h Handler) Handler {
+	return FuncHandler(func(r *Record) error {
+		if r.Lvl > maxLvl {
+			return nil
+		}
+		return h.Log(r)
+	})
+}
+
+// StreamHandler returns a Handler writing the records to w in the given format.
+// The writes of the records are serialized.
+func StreamHandler(w io.Writer, fmtr Format) Handler {
+	var mu sync.Mutex
+	return FuncHandler(func(r *Record) error {
+		b := fmtr.Format(r)
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := w.Write(b)
+		return err
+	})
+}
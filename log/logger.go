@@ -0,0 +1,208 @@
+// Package log is a leveled and structured logger. A record is made of a message
+// and of alternating keys and values:
+//
+//	log.Info("Transaction sent", "hash", tx.Hash(), "to", tx.To())
+//
+// The records of the loggers of gobcos are handed to the Handler of the root
+// logger, which discards them unless another one is set, e.g.:
+//
+//	log.Root().SetHandler(log.LvlFilterHandler(log.LvlDebug, log.StreamHandler(os.Stderr, log.TerminalFormat())))
+//
+// A Handler can also forward the records to another logging library.
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Lvl is the level of a record, the lower the more important.
+type Lvl int
+
+// The levels of the records.
+const (
+	LvlCrit Lvl = iota
+	LvlError
+	LvlWarn
+	LvlInfo
+	LvlDebug
+	LvlTrace
+)
+
+// String returns the name of the level.
+func (l Lvl) String() string {
+	switch l {
+	case LvlTrace:
+		return "trace"
+	case LvlDebug:
+		return "debug"
+	case LvlInfo:
+		return "info"
+	case LvlWarn:
+		return "warn"
+	case LvlError:
+		return "error"
+	case LvlCrit:
+		return "crit"
+	default:
+		return "unknown"
+	}
+}
+
+// LvlFromString returns the level of the given name, e.g. "debug".
+func LvlFromString(name string) (Lvl, error) {
+	switch strings.ToLower(name) {
+	case "trace":
+		return LvlTrace, nil
+	case "debug":
+		return LvlDebug, nil
+	case "info":
+		return LvlInfo, nil
+	case "warn", "warning":
+		return LvlWarn, nil
+	case "error":
+		return LvlError, nil
+	case "crit":
+		return LvlCrit, nil
+	default:
+		return LvlDebug, fmt.Errorf("unknown log level %q, it should be one of trace, debug, info, warn, error and crit", name)
+	}
+}
+
+// Record is a log record.
+type Record struct {
+	Time time.Time
+	Lvl  Lvl
+	Msg  string
+	Ctx  []interface{} // alternating keys and values
+}
+
+// Lazy is a value which is only computed when a record holding it is formatted,
+// e.g. to keep the records which are discarded cheap.
+type Lazy struct {
+	Fn func() interface{}
+}
+
+// Logger writes records with a context of keys and values, which is prepended
+// to the ones of each record.
+type Logger interface {
+	// New returns a logger with the context of this one and the given keys and
+	// values.
+	New(ctx ...interface{}) Logger
+
+	// GetHandler returns the handler of the logger.
+	GetHandler() Handler
+	// SetHandler sets the handler of the logger, and of the loggers created from
+	// it which have not been given their own.
+	SetHandler(h Handler)
+
+	Trace(msg string, ctx ...interface{})
+	Debug(msg string, ctx ...interface{})
+	Info(msg string, ctx ...interface{})
+	Warn(msg string, ctx ...interface{})
+	Error(msg string, ctx ...interface{})
+	Crit(msg string, ctx ...interface{})
+}
+
+type logger struct {
+	ctx []interface{}
+	h   *swapHandler
+}
+
+func (l *logger) write(msg string, lvl Lvl, ctx []interface{}) {
+	l.h.Log(&Record{
+		Time: time.Now(),
+		Lvl:  lvl,
+		Msg:  msg,
+		Ctx:  newContext(l.ctx, ctx),
+	})
+}
+
+func (l *logger) New(ctx ...interface{}) Logger {
+	child := &logger{newContext(l.ctx, ctx), new(swapHandler)}
+	child.SetHandler(l.h)
+	return child
+}
+
+func (l *logger) GetHandler() Handler {
+	return l.h.Get()
+}
+
+func (l *logger) SetHandler(h Handler) {
+	l.h.Swap(h)
+}
+
+func (l *logger) Trace(msg string, ctx ...interface{}) { l.write(msg, LvlTrace, ctx) }
+func (l *logger) Debug(msg string, ctx ...interface{}) { l.write(msg, LvlDebug, ctx) }
+func (l *logger) Info(msg string, ctx ...interface{})  { l.write(msg, LvlInfo, ctx) }
+func (l *logger) Warn(msg string, ctx ...interface{})  { l.write(msg, LvlWarn, ctx) }
+func (l *logger) Error(msg string, ctx ...interface{}) { l.write(msg, LvlError, ctx) }
+func (l *logger) Crit(msg string, ctx ...interface{})  { l.write(msg, LvlCrit, ctx) }
+
+// newContext appends ctx to prefix, adding a nil value to a key missing one.
+func newContext(prefix []interface{}, ctx []interface{}) []interface{} {
+	normalized := make([]interface{}, len(prefix), len(prefix)+len(ctx)+1)
+	copy(normalized, prefix)
+	normalized = append(normalized, ctx...)
+	if len(ctx)%2 != 0 {
+		normalized = append(normalized, nil)
+	}
+	return normalized
+}
+
+// swapHandler is a Handler which can be swapped concurrently with its use.
+type swapHandler struct {
+	handler atomic.Value
+}
+
+type handlerBox struct{ Handler }
+
+func (h *swapHandler) Log(r *Record) error {
+	return h.Get().Log(r)
+}
+
+func (h *swapHandler) Get() Handler {
+	return h.handler.Load().(handlerBox).Handler
+}
+
+func (h *swapHandler) Swap(newHandler Handler) {
+	h.handler.Store(handlerBox{newHandler})
+}
+
+var root = &logger{nil, new(swapHandler)}
+
+func init() {
+	root.SetHandler(DiscardHandler())
+}
+
+// Root returns the root logger, which discards the records until another
+// handler is set.
+func Root() Logger {
+	return root
+}
+
+// New returns a logger with the context of the root logger and the given keys
+// and values.
+func New(ctx ...interface{}) Logger {
+	return root.New(ctx...)
+}
+
+// Trace writes a record with the trace level with the root logger.
+func Trace(msg string, ctx ...interface{}) { root.write(msg, LvlTrace, ctx) }
+
+// Debug writes a record with the debug level with the root logger.
+func Debug(msg string, ctx ...interface{}) { root.write(msg, LvlDebug, ctx) }
+
+// Info writes a record with the info level with the root logger.
+func Info(msg string, ctx ...interface{}) { root.write(msg, LvlInfo, ctx) }
+
+// Warn writes a record with the warn level with the root logger.
+func Warn(msg string, ctx ...interface{}) { root.write(msg, LvlWarn, ctx) }
+
+// Error writes a record with the error level with the root logger.
+func Error(msg string, ctx ...interface{}) { root.write(msg, LvlError, ctx) }
+
+// Crit writes a record with the crit level with the root logger.
+func Crit(msg string, ctx ...interface{}) { root.write(msg, LvlCrit, ctx) }
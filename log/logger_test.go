@@ -0,0 +1,91 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KasperLiu/gobcos/common"
+)
+
+func TestLogger(t *testing.T) {
+	var records []*Record
+	collect := FuncHandler(func(r *Record) error {
+		records = append(records, r)
+		return nil
+	})
+
+	parent := Root().New("a", 1)
+	child := parent.New("b")
+	// the handler set on an ancestor later on is the one of the child
+	Root().SetHandler(LvlFilterHandler(LvlInfo, collect))
+	defer Root().SetHandler(DiscardHandler())
+
+	child.Debug("dropped")
+	child.Info("kept", "c", 3)
+	Warn("root")
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if r := records[0]; r.Msg != "kept" || r.Lvl != LvlInfo || len(r.Ctx) != 6 || r.Ctx[3] != nil || r.Ctx[5] != 3 {
+		t.Fatalf("unexpected record %+v", r)
+	}
+
+	// a child with its own handler does not change its parent's
+	var own int
+	child.SetHandler(FuncHandler(func(r *Record) error { own++; return nil }))
+	child.Info("own")
+	parent.Info("parent")
+	if own != 1 || len(records) != 3 {
+		t.Fatalf("unexpected handlers: own %d, records %d", own, len(records))
+	}
+}
+
+func TestFormats(t *testing.T) {
+	computed := false
+	var nilAddr *common.Address
+	r := &Record{
+		Time: time.Date(2019, 10, 19, 15, 4, 5, 0, time.UTC),
+		Lvl:  LvlWarn,
+		Msg:  "Transaction failed",
+		Ctx: []interface{}{
+			"hash", common.HexToHash("0x01"),
+			"to", nilAddr,
+			"err", errors.New("out of gas"),
+			"t", 1500 * time.Millisecond,
+			"lazy", Lazy{Fn: func() interface{} { computed = true; return "value" }},
+		},
+	}
+	var out bytes.Buffer
+	StreamHandler(&out, TerminalFormat()).Log(r)
+	want := "WARN [10-19|15:04:05.000] Transaction failed                       hash=0x0000000000000000000000000000000000000000000000000000000000000001 to=nil err=\"out of gas\" t=1.5s lazy=value\n"
+	if out.String() != want {
+		t.Fatalf("terminal format mismatch:\nhave %q\nwant %q", out.String(), want)
+	}
+	if !computed {
+		t.Fatalf("lazy value not computed")
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(JSONFormat().Format(r), &obj); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if obj["lvl"] != "warn" || obj["msg"] != "Transaction failed" || obj["to"] != nil || obj["err"] != "out of gas" ||
+		obj["lazy"] != "value" || !strings.HasSuffix(obj["hash"].(string), "01") {
+		t.Fatalf("unexpected JSON record %v", obj)
+	}
+}
+
+func TestLvlFromString(t *testing.T) {
+	for name, want := range map[string]Lvl{"trace": LvlTrace, "DEBUG": LvlDebug, "warning": LvlWarn, "crit": LvlCrit} {
+		if lvl, err := LvlFromString(name); err != nil || lvl != want {
+			t.Errorf("%s: have %v, %v, want %v", name, lvl, err, want)
+		}
+	}
+	if _, err := LvlFromString("verbose"); err == nil {
+		t.Errorf("expected an error for an unknown level")
+	}
+}
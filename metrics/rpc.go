@@ -0,0 +1,163 @@
+// Package metrics collects the measurements of the RPC client and exposes them
+// in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/KasperLiu/gobcos/rpc"
+)
+
+// Histogram buckets of the collected measurements.
+var (
+	// LatencyBuckets are the upper bounds, in seconds, of the latency histograms.
+	LatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	// SizeBuckets are the upper bounds, in bytes, of the payload size histograms.
+	SizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+)
+
+// ioErrorCode is the code label of errors which are not JSON-RPC errors, e.g.
+// connection failures and timeouts.
+const ioErrorCode = "io"
+
+// RPCMetrics is an rpc.Instrumentation that keeps the measurements of the calls
+// made by RPC clients. It is an http.Handler serving them in the Prometheus text
+// exposition format, e.g. on /metrics:
+//
+//	m := metrics.NewRPCMetrics()
+//	c.SetInstrumentation(m)
+//	http.Handle("/metrics", m)
+//
+// The following metrics are exposed:
+//
+//	gobcos_rpc_calls_in_flight                gauge      calls being made
+//	gobcos_rpc_call_duration_seconds          histogram  latency of the calls by method
+//	gobcos_rpc_call_errors_total              counter    failed calls by method and error code
+//	gobcos_rpc_call_retries_total             counter    retried calls by method
+//	gobcos_rpc_http_request_duration_seconds  histogram  latency of the HTTP requests
+//	gobcos_rpc_http_request_size_bytes        histogram  size of the HTTP request bodies
+//	gobcos_rpc_http_response_size_bytes       histogram  size of the HTTP response bodies
+//	gobcos_rpc_http_errors_total              counter    failed HTTP requests
+//
+// The code label of an error is its JSON-RPC error code, or "io" for the errors
+// which occurred while sending the request or reading the response.
+type RPCMetrics struct {
+	mu            sync.Mutex
+	inFlight      int64
+	calls         map[string]*histogram // by method
+	errors        map[[2]string]uint64  // by method and code
+	retries       map[string]uint64     // by method
+	requests      *histogram
+	requestSizes  *histogram
+	responseSizes *histogram
+	requestErrors uint64
+}
+
+// NewRPCMetrics creates an empty collection of RPC metrics.
+func NewRPCMetrics() *RPCMetrics {
+	return &RPCMetrics{
+		calls:         make(map[string]*histogram),
+		errors:        make(map[[2]string]uint64),
+		retries:       make(map[string]uint64),
+		requests:      newHistogram(LatencyBuckets),
+		requestSizes:  newHistogram(SizeBuckets),
+		responseSizes: newHistogram(SizeBuckets),
+	}
+}
+
+// CallStarted implements rpc.Instrumentation.
+func (m *RPCMetrics) CallStarted(method string) {
+	m.mu.Lock()
+	m.inFlight++
+	m.mu.Unlock()
+}
+
+// CallFinished implements rpc.Instrumentation.
+func (m *RPCMetrics) CallFinished(method string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inFlight--
+	h, ok := m.calls[method]
+	if !ok {
+		h = newHistogram(LatencyBuckets)
+		m.calls[method] = h
+	}
+	h.observe(duration.Seconds())
+	if err != nil {
+		m.errors[[2]string{method, errorCode(err)}]++
+	}
+}
+
+// CallRetried implements rpc.Instrumentation.
+func (m *RPCMetrics) CallRetried(method string) {
+	m.mu.Lock()
+	m.retries[method]++
+	m.mu.Unlock()
+}
+
+// RequestFinished implements rpc.Instrumentation.
+func (m *RPCMetrics) RequestFinished(duration time.Duration, requestSize, responseSize int, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests.observe(duration.Seconds())
+	m.requestSizes.observe(float64(requestSize))
+	if err != nil {
+		m.requestErrors++
+		return
+	}
+	m.responseSizes.observe(float64(responseSize))
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *RPCMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	m.write(bw)
+	bw.Flush()
+}
+
+func (m *RPCMetrics) write(w *bufio.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	header(w, "gobcos_rpc_calls_in_flight", "gauge", "Number of JSON-RPC calls being made.")
+	sample(w, "gobcos_rpc_calls_in_flight", nil, float64(m.inFlight))
+
+	header(w, "gobcos_rpc_call_duration_seconds", "histogram", "Latency of the JSON-RPC calls by method.")
+	for _, method := range sortedKeys(m.calls) {
+		m.calls[method].write(w, "gobcos_rpc_call_duration_seconds", []string{"method", method})
+	}
+
+	header(w, "gobcos_rpc_call_errors_total", "counter", "Failed JSON-RPC calls by method and error code.")
+	for _, key := range sortedPairs(m.errors) {
+		sample(w, "gobcos_rpc_call_errors_total", []string{"method", key[0], "code", key[1]}, float64(m.errors[key]))
+	}
+
+	header(w, "gobcos_rpc_call_retries_total", "counter", "Retried JSON-RPC calls by method.")
+	for _, method := range sortedKeys(m.retries) {
+		sample(w, "gobcos_rpc_call_retries_total", []string{"method", method}, float64(m.retries[method]))
+	}
+
+	header(w, "gobcos_rpc_http_request_duration_seconds", "histogram", "Latency of the HTTP requests.")
+	m.requests.write(w, "gobcos_rpc_http_request_duration_seconds", nil)
+	header(w, "gobcos_rpc_http_request_size_bytes", "histogram", "Size of the HTTP request bodies.")
+	m.requestSizes.write(w, "gobcos_rpc_http_request_size_bytes", nil)
+	header(w, "gobcos_rpc_http_response_size_bytes", "histogram", "Size of the HTTP response bodies.")
+	m.responseSizes.write(w, "gobcos_rpc_http_response_size_bytes", nil)
+	header(w, "gobcos_rpc_http_errors_total", "counter", "Failed HTTP requests.")
+	sample(w, "gobcos_rpc_http_errors_total", nil, float64(m.requestErrors))
+}
+
+// errorCode returns the code label of an error.
+func errorCode(err error) string {
+	if rpcErr, ok := err.(rpc.Error); ok {
+		return strconv.Itoa(rpcErr.ErrorCode())
+	}
+	return ioErrorCode
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/rpc"
+)
+
+type spanKey struct{}
+
+// testTracer records the spans and marks the context of each call.
+type testTracer struct {
+	mu       sync.Mutex
+	started  []string
+	finished []error
+}
+
+func (t *testTracer) StartSpan(ctx context.Context, method string) context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.started = append(t.started, method)
+	return context.WithValue(ctx, spanKey{}, method)
+}
+
+func (t *testTracer) FinishSpan(ctx context.Context, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ctx.Value(spanKey{}) == nil {
+		panic("span context lost")
+	}
+	t.finished = append(t.finished, err)
+}
+
+// spanTransport propagates the span in a request header.
+type spanTransport struct{}
+
+func (spanTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if span, ok := req.Context().Value(spanKey{}).(string); ok {
+		r := *req
+		r.Header = make(http.Header)
+		for key, values := range req.Header {
+			r.Header[key] = values
+		}
+		r.Header.Set("X-Span", span)
+		req = &r
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestRPCMetrics(t *testing.T) {
+	srv := clienttest.NewServer()
+	defer srv.Close()
+	// The node is reached through a proxy recording the propagated spans
+	target, _ := url.Parse(srv.URL)
+	forward := httputil.NewSingleHostReverseProxy(target)
+	var headers []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Get("X-Span"))
+		forward.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	c, err := rpc.DialHTTPWithClient(proxy.URL, &http.Client{Transport: spanTransport{}})
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	m, tracer := NewRPCMetrics(), new(testTracer)
+	c.SetInstrumentation(m)
+	c.SetTracer(tracer)
+	srv.SetError("getPbftView", -40007, "Only pbft consensus supports the view property")
+
+	var number string
+	for i := 0; i < 2; i++ {
+		if err := c.CallContext(context.Background(), &number, "getBlockNumber", 1); err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+	}
+	if err := c.CallContext(context.Background(), nil, "getPbftView", 1); err == nil {
+		t.Fatalf("expected an error")
+	}
+	batch := []rpc.BatchElem{
+		{Method: "getBlockNumber", Args: []interface{}{1}, Result: &number},
+		{Method: "unknown", Result: new(string)},
+	}
+	if err := c.BatchCallContext(context.Background(), batch); err != nil {
+		t.Fatalf("batch call failed: %v", err)
+	}
+	m.CallRetried("getBlockNumber")
+	proxy.Close()
+	if err := c.CallContext(context.Background(), nil, "getBlockNumber", 1); err == nil {
+		t.Fatalf("expected an error after the server is closed")
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE gobcos_rpc_call_duration_seconds histogram\n",
+		"gobcos_rpc_calls_in_flight 0\n",
+		`gobcos_rpc_call_duration_seconds_count{method="getBlockNumber"} 4` + "\n",
+		`gobcos_rpc_call_duration_seconds_bucket{method="getPbftView",le="+Inf"} 1` + "\n",
+		`gobcos_rpc_call_errors_total{method="getBlockNumber",code="io"} 1` + "\n",
+		`gobcos_rpc_call_errors_total{method="getPbftView",code="-40007"} 1` + "\n",
+		`gobcos_rpc_call_errors_total{method="unknown",code="-32601"} 1` + "\n",
+		`gobcos_rpc_call_retries_total{method="getBlockNumber"} 1` + "\n",
+		"gobcos_rpc_http_request_duration_seconds_count 5\n",
+		"gobcos_rpc_http_response_size_bytes_count 4\n",
+		"gobcos_rpc_http_errors_total 1\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metric %q missing in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "gobcos_rpc_http_response_size_bytes_sum 0\n") {
+		t.Errorf("response sizes not recorded:\n%s", out)
+	}
+
+	wantSpans := []string{"getBlockNumber", "getBlockNumber", "getPbftView", rpc.BatchMethod, "getBlockNumber"}
+	if strings.Join(tracer.started, ",") != strings.Join(wantSpans, ",") || len(tracer.finished) != len(wantSpans) {
+		t.Fatalf("unexpected spans: started %v, finished %v", tracer.started, tracer.finished)
+	}
+	if tracer.finished[0] != nil || tracer.finished[2] == nil || tracer.finished[4] == nil {
+		t.Fatalf("unexpected span errors: %v", tracer.finished)
+	}
+	if strings.Join(headers, ",") != strings.Join(wantSpans[:4], ",") {
+		t.Fatalf("span not propagated to the requests: %v", headers)
+	}
+}
@@ -2,8 +2,6 @@ package cns
 
 import (
 	"fmt"
-	"crypto/ecdsa"
-	"math/big"
 	"strings"
 	"encoding/json"
 
@@ -11,6 +9,7 @@ import (
 	"github.com/KasperLiu/gobcos/common"
 	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/log"
 )
 
 // CnsService is a precompile contract service.
@@ -24,15 +23,20 @@ const maxVersionLength = 40
 // contract address
 var cnsPrecompileAddress common.Address = common.HexToAddress("0x0000000000000000000000000000000000001004")
 
-// NewCnsService returns ptr of CnsService
-func NewCnsService(client *client.Client, privateKey *ecdsa.PrivateKey) (*CnsService, error) {
+var logger = log.New("precompile", "cns")
+
+// NewCnsService returns ptr of CnsService, whose transactions are signed by auth,
+// e.g. created by bind.NewKeyedTransactor or bind.NewKeyStoreTransactor
+func NewCnsService(client *client.Client, auth *bind.TransactOpts) (*CnsService, error) {
 	instance, err := NewCns(cnsPrecompileAddress, client)
 	if err != nil {
 		return nil, fmt.Errorf("construct CnsService failed: %+v", err)
 	}
-	auth := bind.NewKeyedTransactor(privateKey)
-	auth.GasLimit = big.NewInt(30000000)
-    return &CnsService{cns:instance, cnsAuth:auth}, nil
+	opts, err := bind.WithDefaultGasLimit(auth, bind.PrecompileGasLimit)
+	if err != nil {
+		return nil, fmt.Errorf("construct CnsService failed: %+v", err)
+	}
+    return &CnsService{cns:instance, cnsAuth:opts}, nil
 }
 
 // SelectByName returns the cns information according to the name string.
@@ -106,8 +110,10 @@ func (service *CnsService) RegisterCns(name string, version string, addr string,
 	}
 	tx, err := service.cns.Insert(service.cnsAuth, name, version, addr, abi)
     if err != nil {
+        logger.Warn("Failed to register the contract", "name", name, "version", version, "address", addr, "err", err)
         return nil, fmt.Errorf("CnsService RegisterCns failed: %+v", err)
 	}
+	logger.Info("Registering the contract", "name", name, "version", version, "address", addr, "hash", tx.Hash())
 	return tx, nil
 }
 
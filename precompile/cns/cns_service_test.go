@@ -1,18 +1,83 @@
 package cns
 
 import (
-	"testing"
 	"context"
 	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
 
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
 	"github.com/KasperLiu/gobcos/crypto"
-	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 )
 
-func GetClient(t *testing.T) *client.Client {
+// newTestNode starts a mock node whose CNS precompile keeps the registered
+// contracts in memory.
+func newTestNode(t *testing.T) *clienttest.Server {
+	parsed, err := abi.JSON(strings.NewReader(CnsABI))
+	if err != nil {
+		t.Fatalf("parse CnsABI failed: %v", err)
+	}
+	var (
+		mu       sync.Mutex
+		registry []CnsInfo
+	)
+	srv := clienttest.NewServer()
+	srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+		method, err := parsed.MethodById(tx.Data())
+		if err != nil || method.Name != "insert" {
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		args, err := method.Inputs.UnpackValues(tx.Data()[4:])
+		if err != nil {
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		mu.Lock()
+		registry = append(registry, CnsInfo{Name: args[0].(string), Version: args[1].(string), Address: args[2].(string), Abi: args[3].(string)})
+		mu.Unlock()
+		output, _ := method.Outputs.Pack(big.NewInt(1))
+		return &clienttest.Execution{Output: output}
+	})
+	srv.HandleCall(func(from, to common.Address, data []byte) ([]byte, error) {
+		method, err := parsed.MethodById(data)
+		if err != nil {
+			return nil, err
+		}
+		args, err := method.Inputs.UnpackValues(data[4:])
+		if err != nil {
+			return nil, err
+		}
+		if method.Name != "selectByName" && method.Name != "selectByNameAndVersion" {
+			return nil, errors.New("unexpected call to " + method.Name)
+		}
+		mu.Lock()
+		found := []CnsInfo{}
+		for _, info := range registry {
+			if info.Name == args[0].(string) && (len(args) == 1 || info.Version == args[1].(string)) {
+				found = append(found, info)
+			}
+		}
+		mu.Unlock()
+		result, err := json.Marshal(found)
+		if err != nil {
+			return nil, err
+		}
+		return method.Outputs.Pack(string(result))
+	})
+	return srv
+}
+
+func GetClient(t *testing.T, srv *clienttest.Server) *client.Client {
 	groupID := uint(1)
-	rpc, err := client.Dial("http://localhost:8545", groupID)
+	rpc, err := client.Dial(srv.URL, groupID)
 	if err != nil {
 		t.Fatalf("init rpc client failed: %+v", err)
 	}
@@ -21,16 +86,15 @@ func GetClient(t *testing.T) *client.Client {
 
 func GenerateKey(t *testing.T) *ecdsa.PrivateKey {
 	privateKey, err := crypto.HexToECDSA("145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b58")
-    if err != nil {
-        t.Fatalf("init privateKey failed: %+v", err)
+	if err != nil {
+		t.Fatalf("init privateKey failed: %+v", err)
 	}
 	return privateKey
 }
 
-func GetService(t *testing.T) *CnsService {
-	rpc := GetClient(t)
+func GetService(t *testing.T, rpc *client.Client) *CnsService {
 	privateKey := GenerateKey(t)
-	service, err := NewCnsService(rpc, privateKey)
+	service, err := NewCnsService(rpc, bind.NewKeyedTransactor(privateKey))
 	if err != nil {
 		t.Fatalf("init CnsService failed: %+v", err)
 	}
@@ -122,8 +186,10 @@ func TestAll(t *testing.T) {
 			"type": "event"
 		}
 	]`
-	rpc := GetClient(t)
-	service := GetService(t)
+	srv := newTestNode(t)
+	defer srv.Close()
+	rpc := GetClient(t, srv)
+	service := GetService(t, rpc)
 
 	// test RegisterCns
 	tx, err := service.RegisterCns(name, version, address, abi)
@@ -131,18 +197,21 @@ func TestAll(t *testing.T) {
 		t.Fatalf("CnsService RegisterCns failed: %+v\n", err)
 	}
 	// wait for the mining
-    receipt, err := bind.WaitMined(context.Background(), rpc, tx)
-    if err != nil {
-        t.Fatalf("tx mining error:%v\n", err)
+	receipt, err := bind.WaitMined(context.Background(), rpc, tx)
+	if err != nil {
+		t.Fatalf("tx mining error:%v\n", err)
 	}
 	t.Logf("transaction hash: %s\n", receipt.GetTransactionHash())
-	
+
 	// test GetAddressByContractNameAndVersion
-	addr, err := service.GetAddressByContractNameAndVersion(name + ":" +version)
+	addr, err := service.GetAddressByContractNameAndVersion(name + ":" + version)
 	if err != nil {
 		t.Fatalf("GetAddressByContractNameAndVersion failed: %v", err)
 	}
 	t.Logf("address: %s", addr)
+	if !strings.EqualFold(addr, address) {
+		t.Fatalf("address mismatch: have %s, want %s", addr, address)
+	}
 
 	// test QueryCnsByNameAndVersion
 	cnsInfo, err := service.QueryCnsByNameAndVersion(name, version)
@@ -151,10 +220,10 @@ func TestAll(t *testing.T) {
 	}
 	t.Logf("QueryCnsByNameAndVersion: %s", cnsInfo[0].String())
 
-    // test QueryCnsByNameAndVersion
+	// test QueryCnsByNameAndVersion
 	cnsInfoByName, err := service.QueryCnsByName(name)
 	if err != nil {
 		t.Fatalf("QueryCnsByName failed: %v\n", err)
 	}
 	t.Logf("QueryCnsByName: %s", cnsInfoByName[0].String())
-}
\ No newline at end of file
+}
@@ -2,13 +2,12 @@ package config
 
 import (
 	"fmt"
-	"crypto/ecdsa"
-	"math/big"
 
 	"github.com/KasperLiu/gobcos/client"
 	"github.com/KasperLiu/gobcos/common"
 	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/log"
 )
 
 // SystemConfigService is a precompile contract service.
@@ -17,25 +16,32 @@ type SystemConfigService struct {
 	systemConfigAuth *bind.TransactOpts
 }
 
-// contract address
-var systemConfigPrecompileAddress common.Address = common.HexToAddress("0x0000000000000000000000000000000000001000")
+// SystemConfigPrecompileAddress is the contract address of SystemConfig
+var SystemConfigPrecompileAddress common.Address = common.HexToAddress("0x0000000000000000000000000000000000001000")
 
-// NewSystemConfigService returns ptr of SystemConfigService
-func NewSystemConfigService(client *client.Client, privateKey *ecdsa.PrivateKey) (*SystemConfigService, error) {
-	instance, err := NewConfig(systemConfigPrecompileAddress, client)
+var logger = log.New("precompile", "config")
+
+// NewSystemConfigService returns ptr of SystemConfigService, whose transactions are signed by auth,
+// e.g. created by bind.NewKeyedTransactor or bind.NewKeyStoreTransactor
+func NewSystemConfigService(client *client.Client, auth *bind.TransactOpts) (*SystemConfigService, error) {
+	instance, err := NewConfig(SystemConfigPrecompileAddress, client)
+	if err != nil {
+		return nil, fmt.Errorf("construct SystemConfigService failed: %+v", err)
+	}
+	opts, err := bind.WithDefaultGasLimit(auth, bind.PrecompileGasLimit)
 	if err != nil {
 		return nil, fmt.Errorf("construct SystemConfigService failed: %+v", err)
 	}
-	auth := bind.NewKeyedTransactor(privateKey)
-	auth.GasLimit = big.NewInt(30000000)
-    return &SystemConfigService{systemConfig:instance, systemConfigAuth:auth}, nil
+    return &SystemConfigService{systemConfig:instance, systemConfigAuth:opts}, nil
 }
 
 // SetValueByKey returns a raw transaction if there is no error occured.
 func (service *SystemConfigService) SetValueByKey(key string ,value string) (*types.RawTransaction, error) {
 	tx, err := service.systemConfig.SetValueByKey(service.systemConfigAuth, key, value)
     if err != nil {
+        logger.Warn("Failed to set the system config", "key", key, "value", value, "err", err)
         return nil, fmt.Errorf("SystemConfigService setValueByKey failed: %+v", err)
 	}
+	logger.Info("Setting the system config", "key", key, "value", value, "hash", tx.Hash())
 	return tx, nil
 }
\ No newline at end of file
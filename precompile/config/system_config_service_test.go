@@ -1,31 +1,64 @@
 package config
 
 import (
-	"testing"
 	"context"
+	"math/big"
+	"strings"
+	"testing"
 
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
 	"github.com/KasperLiu/gobcos/crypto"
-	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 )
 
+// newTestNode starts a mock node whose system config precompile stores the
+// values set through setValueByKey.
+func newTestNode(t *testing.T) *clienttest.Server {
+	parsed, err := abi.JSON(strings.NewReader(ConfigABI))
+	if err != nil {
+		t.Fatalf("parse ConfigABI failed: %v", err)
+	}
+	srv := clienttest.NewServer()
+	srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+		method, err := parsed.MethodById(tx.Data())
+		if err != nil || method.Name != "setValueByKey" {
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		args, err := method.Inputs.UnpackValues(tx.Data()[4:])
+		if err != nil {
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		srv.SetSystemConfig(args[0].(string), args[1].(string))
+		output, _ := method.Outputs.Pack(big.NewInt(1))
+		return &clienttest.Execution{Output: output}
+	})
+	return srv
+}
+
 func TestSetValueByKey(t *testing.T) {
+	srv := newTestNode(t)
+	defer srv.Close()
+
 	groupID := uint(1)
-	rpc, err := client.Dial("http://localhost:8545", groupID)
+	rpc, err := client.Dial(srv.URL, groupID)
 	if err != nil {
 		t.Fatalf("init rpc client failed: %+v", err)
 	}
 
 	privateKey, err := crypto.HexToECDSA("145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b58")
-    if err != nil {
-        t.Fatalf("init privateKey failed: %+v", err)
+	if err != nil {
+		t.Fatalf("init privateKey failed: %+v", err)
 	}
 
-	service, err := NewSystemConfigService(rpc, privateKey)
+	service, err := NewSystemConfigService(rpc, bind.NewKeyedTransactor(privateKey))
 	if err != nil {
 		t.Fatalf("init SystemConfigService failed: %+v", err)
 	}
-	
+
 	key := "tx_count_limit"
 	value := "30000000"
 	tx, err := service.SetValueByKey(key, value)
@@ -33,11 +66,11 @@ func TestSetValueByKey(t *testing.T) {
 		t.Fatalf("SystemConfigService SetValueByKey failed: %+v", err)
 	}
 	// wait for the mining
-    _, err = bind.WaitMined(context.Background(), rpc, tx)
-    if err != nil {
-        t.Fatalf("tx mining error:%v\n", err)
+	_, err = bind.WaitMined(context.Background(), rpc, tx)
+	if err != nil {
+		t.Fatalf("tx mining error:%v\n", err)
 	}
-	
+
 	result, err := rpc.GetSystemConfigByKey(context.Background(), key)
 	if err != nil {
 		t.Fatalf("GetSystemConfigByKey failed: %v", err)
@@ -47,4 +80,4 @@ func TestSetValueByKey(t *testing.T) {
 		t.Fatalf("SetValueByKey failed!")
 	}
 	t.Logf("transaction hash: %s", tx.Hash().Hex())
-}
\ No newline at end of file
+}
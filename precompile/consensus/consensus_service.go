@@ -2,8 +2,6 @@ package consensus
 
 import (
 	"fmt"
-	"crypto/ecdsa"
-	"math/big"
 	"context"
 	"encoding/json"
 
@@ -11,6 +9,7 @@ import (
 	"github.com/KasperLiu/gobcos/common"
 	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/log"
 )
 
 // ConsensusService is a precompile contract service.
@@ -20,18 +19,23 @@ type ConsensusService struct {
 	client *client.Client
 }
 
-// contract address
-var consensusPrecompileAddress common.Address = common.HexToAddress("0x0000000000000000000000000000000000001003")
+// ConsensusPrecompileAddress is the contract address of Consensus
+var ConsensusPrecompileAddress common.Address = common.HexToAddress("0x0000000000000000000000000000000000001003")
 
-// NewConsensusService returns ptr of ConsensusService
-func NewConsensusService(client *client.Client, privateKey *ecdsa.PrivateKey) (*ConsensusService, error) {
-	instance, err := NewConsensus(consensusPrecompileAddress, client)
+var logger = log.New("precompile", "consensus")
+
+// NewConsensusService returns ptr of ConsensusService, whose transactions are signed by auth,
+// e.g. created by bind.NewKeyedTransactor or bind.NewKeyStoreTransactor
+func NewConsensusService(client *client.Client, auth *bind.TransactOpts) (*ConsensusService, error) {
+	instance, err := NewConsensus(ConsensusPrecompileAddress, client)
+	if err != nil {
+		return nil, fmt.Errorf("construct ConsensusService failed: %+v", err)
+	}
+	opts, err := bind.WithDefaultGasLimit(auth, bind.PrecompileGasLimit)
 	if err != nil {
 		return nil, fmt.Errorf("construct ConsensusService failed: %+v", err)
 	}
-	auth := bind.NewKeyedTransactor(privateKey)
-	auth.GasLimit = big.NewInt(30000000)
-    return &ConsensusService{consensus:instance, consensusAuth:auth, client: client}, nil
+    return &ConsensusService{consensus:instance, consensusAuth:opts, client: client}, nil
 }
 
 // AddObserver add a new observe node according to the node ID
@@ -61,8 +65,10 @@ func (service *ConsensusService) AddObserver(nodeID string) (*types.RawTransacti
 	}
 	tx, err := service.consensus.AddObserver(service.consensusAuth, nodeID)
     if err != nil {
+        logger.Warn("Failed to add the observer", "node", nodeID, "err", err)
         return nil, fmt.Errorf("ConsensusService addObserver failed: %+v", err)
 	}
+	logger.Info("Adding the observer", "node", nodeID, "hash", tx.Hash())
 	return tx, nil
 }
 
@@ -94,8 +100,10 @@ func (service *ConsensusService) AddSealer(nodeID string) (*types.RawTransaction
 
 	tx, err := service.consensus.AddSealer(service.consensusAuth, nodeID)
     if err != nil {
+        logger.Warn("Failed to add the sealer", "node", nodeID, "err", err)
         return nil, fmt.Errorf("ConsensusService addSealer failed: %+v", err)
 	}
+	logger.Info("Adding the sealer", "node", nodeID, "hash", tx.Hash())
 
 	return tx, nil
 }
@@ -128,8 +136,10 @@ func (service *ConsensusService) RemoveNode(nodeID string) (*types.RawTransactio
 	// maybe will occur something wrong 
 	// when request the receipt from the SDK since the connected node of SDK is removed
     if err != nil {
+        logger.Warn("Failed to remove the node", "node", nodeID, "err", err)
         return nil, fmt.Errorf("ConsensusService Remove failed: %+v", err)
 	}
+	logger.Info("Removing the node", "node", nodeID, "hash", tx.Hash())
 	return tx, nil
 }
 
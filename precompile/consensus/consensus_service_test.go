@@ -1,19 +1,74 @@
 package consensus
 
 import (
-	"testing"
 	"context"
 	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"testing"
 
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
 	"github.com/KasperLiu/gobcos/crypto"
-	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 )
 
+const nodeID = "da72d42af7228b7fcbd0c2ca1128a9cf5b1a3a648c64878ebba4177a751507a0e1d686c2a6ccdfdadcfc60c1d6ec6d5d07797880f2f6a1f176d480b98ed5a13c"
+
+// newTestNode starts a mock node connected to nodeID whose consensus
+// precompile moves nodes between the sealer and the observer list.
+func newTestNode(t *testing.T) *clienttest.Server {
+	parsed, err := abi.JSON(strings.NewReader(ConsensusABI))
+	if err != nil {
+		t.Fatalf("parse ConsensusABI failed: %v", err)
+	}
+	srv := clienttest.NewServer()
+	srv.SetNodeIDList(append(srv.SealerList(), nodeID))
+	srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+		method, err := parsed.MethodById(tx.Data())
+		if err != nil {
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		args, err := method.Inputs.UnpackValues(tx.Data()[4:])
+		if err != nil {
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		id := args[0].(string)
+		sealers, observers := without(srv.SealerList(), id), without(srv.ObserverList(), id)
+		switch method.Name {
+		case "addSealer":
+			sealers = append(sealers, id)
+		case "addObserver":
+			observers = append(observers, id)
+		}
+		srv.SetSealerList(sealers)
+		srv.SetObserverList(observers)
+		output, _ := method.Outputs.Pack(big.NewInt(1))
+		return &clienttest.Execution{Output: output}
+	})
+	return srv
+}
 
-func GetClient(t *testing.T) *client.Client {
+func without(nodeIDs []string, nodeID string) []string {
+	var left []string
+	for _, id := range nodeIDs {
+		if id != nodeID {
+			left = append(left, id)
+		}
+	}
+	return left
+}
+
+func contains(list []byte, nodeID string) bool {
+	return strings.Contains(string(list), nodeID)
+}
+
+func GetClient(t *testing.T, srv *clienttest.Server) *client.Client {
 	groupID := uint(1)
-	rpc, err := client.Dial("http://localhost:8545", groupID)
+	rpc, err := client.Dial(srv.URL, groupID)
 	if err != nil {
 		t.Fatalf("init rpc client failed: %+v", err)
 	}
@@ -22,25 +77,34 @@ func GetClient(t *testing.T) *client.Client {
 
 func GenerateKey(t *testing.T) *ecdsa.PrivateKey {
 	privateKey, err := crypto.HexToECDSA("145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b58")
-    if err != nil {
-        t.Fatalf("init privateKey failed: %+v", err)
+	if err != nil {
+		t.Fatalf("init privateKey failed: %+v", err)
 	}
 	return privateKey
 }
 
-func GetService(t *testing.T) *ConsensusService {
-	rpc := GetClient(t)
+func GetService(t *testing.T, rpc *client.Client) *ConsensusService {
 	privateKey := GenerateKey(t)
-	service, err := NewConsensusService(rpc, privateKey)
+	service, err := NewConsensusService(rpc, bind.NewKeyedTransactor(privateKey))
 	if err != nil {
 		t.Fatalf("init ConsensusService failed: %+v", err)
 	}
 	return service
 }
 
+func TestNilTransactOpts(t *testing.T) {
+	srv := newTestNode(t)
+	defer srv.Close()
+	if _, err := NewConsensusService(GetClient(t, srv), nil); err == nil {
+		t.Fatal("ConsensusService created without transaction options")
+	}
+}
+
 func TestAddObserver(t *testing.T) {
-	rpc := GetClient(t)
-	service := GetService(t)
+	srv := newTestNode(t)
+	defer srv.Close()
+	rpc := GetClient(t, srv)
+	service := GetService(t, rpc)
 
 	observer, err := rpc.GetObserverList(context.Background())
 	if err != nil {
@@ -48,28 +112,36 @@ func TestAddObserver(t *testing.T) {
 	}
 	t.Logf("Observer list: %s\n", observer)
 
-	nodeID := "da72d42af7228b7fcbd0c2ca1128a9cf5b1a3a648c64878ebba4177a751507a0e1d686c2a6ccdfdadcfc60c1d6ec6d5d07797880f2f6a1f176d480b98ed5a13c"
 	tx, err := service.AddObserver(nodeID)
 	if err != nil {
 		t.Fatalf("ConsensusService AddObserver failed: %+v\n", err)
 	}
 	// wait for the mining
-    receipt, err := bind.WaitMined(context.Background(), rpc, tx)
-    if err != nil {
-        t.Fatalf("tx mining error:%v\n", err)
+	receipt, err := bind.WaitMined(context.Background(), rpc, tx)
+	if err != nil {
+		t.Fatalf("tx mining error:%v\n", err)
 	}
 	t.Logf("transaction hash: %s", receipt.GetTransactionHash())
 
-    observer, err = rpc.GetObserverList(context.Background())
+	observer, err = rpc.GetObserverList(context.Background())
 	if err != nil {
 		t.Fatalf("ConsensusService invoke GetObserverList second time failed: %+v\n", err)
 	}
 	t.Logf("Observer list: %s\n", observer)
+	if !contains(observer, nodeID) {
+		t.Fatalf("the node is not in the observer list")
+	}
+
+	if _, err := service.AddObserver(nodeID); err == nil {
+		t.Fatalf("ConsensusService AddObserver should fail for an observer")
+	}
 }
 
 func TestAddSealer(t *testing.T) {
-	rpc := GetClient(t)
-	service := GetService(t)
+	srv := newTestNode(t)
+	defer srv.Close()
+	rpc := GetClient(t, srv)
+	service := GetService(t, rpc)
 
 	observer, err := rpc.GetSealerList(context.Background())
 	if err != nil {
@@ -77,28 +149,33 @@ func TestAddSealer(t *testing.T) {
 	}
 	t.Logf("Sealer list: %s\n", observer)
 
-	nodeID := "da72d42af7228b7fcbd0c2ca1128a9cf5b1a3a648c64878ebba4177a751507a0e1d686c2a6ccdfdadcfc60c1d6ec6d5d07797880f2f6a1f176d480b98ed5a13c"
 	tx, err := service.AddSealer(nodeID)
 	if err != nil {
 		t.Fatalf("ConsensusService AddSealer failed: %+v\n", err)
 	}
 	// wait for the mining
-    receipt, err := bind.WaitMined(context.Background(), rpc, tx)
-    if err != nil {
-        t.Fatalf("tx mining error:%v\n", err)
+	receipt, err := bind.WaitMined(context.Background(), rpc, tx)
+	if err != nil {
+		t.Fatalf("tx mining error:%v\n", err)
 	}
 	t.Logf("transaction hash: %s", receipt.GetTransactionHash())
 
-    observer, err = rpc.GetSealerList(context.Background())
+	observer, err = rpc.GetSealerList(context.Background())
 	if err != nil {
 		t.Fatalf("ConsensusService invoke GetSealerList second time failed: %+v\n", err)
 	}
 	t.Logf("Sealer list: %s\n", observer)
+	if !contains(observer, nodeID) {
+		t.Fatalf("the node is not in the sealer list")
+	}
 }
 
 func TestRemove(t *testing.T) {
-	rpc := GetClient(t)
-	service := GetService(t)
+	srv := newTestNode(t)
+	defer srv.Close()
+	srv.SetObserverList([]string{nodeID})
+	rpc := GetClient(t, srv)
+	service := GetService(t, rpc)
 
 	observer, err := rpc.GetSealerList(context.Background())
 	if err != nil {
@@ -106,21 +183,28 @@ func TestRemove(t *testing.T) {
 	}
 	t.Logf("Sealer list: %s\n", observer)
 
-	nodeID := "da72d42af7228b7fcbd0c2ca1128a9cf5b1a3a648c64878ebba4177a751507a0e1d686c2a6ccdfdadcfc60c1d6ec6d5d07797880f2f6a1f176d480b98ed5a13c"
 	tx, err := service.RemoveNode(nodeID)
 	if err != nil {
 		t.Fatalf("ConsensusService Remove failed: %+v\n", err)
 	}
 	// wait for the mining
-    receipt, err := bind.WaitMined(context.Background(), rpc, tx)
-    if err != nil {
-        t.Fatalf("tx mining error:%v\n", err)
+	receipt, err := bind.WaitMined(context.Background(), rpc, tx)
+	if err != nil {
+		t.Fatalf("tx mining error:%v\n", err)
 	}
 	t.Logf("transaction hash: %s", receipt.GetTransactionHash())
 
-    observer, err = rpc.GetSealerList(context.Background())
+	observer, err = rpc.GetSealerList(context.Background())
 	if err != nil {
 		t.Fatalf("ConsensusService invoke GetSealerList second time failed: %+v\n", err)
 	}
 	t.Logf("Sealer list: %s\n", observer)
-}
\ No newline at end of file
+
+	peers, err := rpc.GetGroupPeers(context.Background())
+	if err != nil {
+		t.Fatalf("GetGroupPeers failed: %+v\n", err)
+	}
+	if contains(peers, nodeID) {
+		t.Fatalf("the node is still a group peer")
+	}
+}
@@ -2,7 +2,6 @@ package crud
 
 import (
 	"fmt"
-	"crypto/ecdsa"
 	"math/big"
 	"context"
 	"encoding/json"
@@ -11,6 +10,7 @@ import (
 	"github.com/KasperLiu/gobcos/common"
 	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/log"
 )
 
 const (
@@ -32,8 +32,11 @@ var TableFactoryPrecompileAddress common.Address = common.HexToAddress("0x000000
 // CRUDPrecompileAddress is the contract address of CRUD
 var CRUDPrecompileAddress common.Address = common.HexToAddress("0x0000000000000000000000000000000000001002")
 
-// NewCRUDService returns ptr of CRUDService
-func NewCRUDService(client *client.Client, privateKey *ecdsa.PrivateKey) (*CRUDService, error) {
+var logger = log.New("precompile", "crud")
+
+// NewCRUDService returns ptr of CRUDService, whose transactions are signed by auth,
+// e.g. created by bind.NewKeyedTransactor or bind.NewKeyStoreTransactor
+func NewCRUDService(client *client.Client, auth *bind.TransactOpts) (*CRUDService, error) {
 	crudInstance, err := NewCrud(CRUDPrecompileAddress, client)
 	if err != nil {
 		return nil, fmt.Errorf("construct CRUD failed: %+v", err)
@@ -42,9 +45,11 @@ func NewCRUDService(client *client.Client, privateKey *ecdsa.PrivateKey) (*CRUDS
 	if err != nil {
 		return nil, fmt.Errorf("construct TableFactor failed: %+v", err)
 	}
-	auth := bind.NewKeyedTransactor(privateKey)
-	auth.GasLimit = big.NewInt(30000000)
-    return &CRUDService{crud:crudInstance, tableFactory:tableInstance, crudAuth:auth, client: client}, nil
+	opts, err := bind.WithDefaultGasLimit(auth, bind.PrecompileGasLimit)
+	if err != nil {
+		return nil, fmt.Errorf("construct CRUDService failed: %+v", err)
+	}
+    return &CRUDService{crud:crudInstance, tableFactory:tableInstance, crudAuth:opts, client: client}, nil
 }
 
 // CreateTable returns the status of the creating that 0 represents succeed 
@@ -53,6 +58,7 @@ func (service *CRUDService) CreateTable(table *Table) (int, error) {
 	if err != nil {
 		return -1, fmt.Errorf("CRUDService CreateTable failed: %v", err)
 	}
+	logger.Info("Creating the table", "table", table.GetTableName(), "hash", tx.Hash())
 	// wait for the mining
 	receipt, err := bind.WaitMined(context.Background(), service.client, tx)
 	if err != nil {
@@ -77,6 +83,7 @@ func (service *CRUDService) Insert(table *Table, entry *Entry) (int, error) {
 	if err != nil {
 		return -1, fmt.Errorf("CRUDService Insert failed: %v", err)
 	}
+	logger.Debug("Inserting the entry", "table", table.GetTableName(), "key", table.GetKey(), "hash", tx.Hash())
 	// wait for the mining
 	receipt, err := bind.WaitMined(context.Background(), service.client, tx)
 	if err != nil {
@@ -105,6 +112,7 @@ func (service *CRUDService) Update(table *Table, entry *Entry, condition *Condit
 	if err != nil {
 		return -1, fmt.Errorf("CRUDService Update failed: %v", err)
 	}
+	logger.Debug("Updating the entries", "table", table.GetTableName(), "key", table.GetKey(), "hash", tx.Hash())
 	// wait for the mining
 	receipt, err := bind.WaitMined(context.Background(), service.client, tx)
 	if err != nil {
@@ -128,6 +136,7 @@ func (service *CRUDService) Remove(table *Table, condition *Condition) (int, err
 	if err != nil {
 		return -1, fmt.Errorf("CRUDService Remove failed: %v", err)
 	}
+	logger.Debug("Removing the entries", "table", table.GetTableName(), "key", table.GetKey(), "hash", tx.Hash())
 	// wait for the mining
 	receipt, err := bind.WaitMined(context.Background(), service.client, tx)
 	if err != nil {
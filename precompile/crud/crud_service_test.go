@@ -1,18 +1,185 @@
 package crud
 
 import (
-	"testing"
 	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
 	"math/rand"
 	"strconv"
+	"strings"
+	"sync"
+	"testing"
 
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
 	"github.com/KasperLiu/gobcos/crypto"
 )
 
-func GetClient(t *testing.T) *client.Client {
+// memTable is an in-memory user table of the mock node.
+type memTable struct {
+	keyField string
+	rows     []map[string]string
+}
+
+// match returns the indexes of the rows of the given key satisfying the
+// eq conditions and the limit of conditionJSON.
+func (table *memTable) match(key string, conditionJSON string) ([]int, error) {
+	var conditions map[string]map[EnumOP]string
+	if err := json.Unmarshal([]byte(conditionJSON), &conditions); err != nil {
+		return nil, err
+	}
+	offset, count := 0, len(table.rows)
+	if limit, ok := conditions["limit"]; ok {
+		fmt.Sscanf(limit[Limit], "%d,%d", &offset, &count)
+		delete(conditions, "limit")
+	}
+	var matched []int
+	for i, row := range table.rows {
+		ok := row[table.keyField] == key
+		for field, cond := range conditions {
+			if value, isEQ := cond[EQ]; isEQ && row[field] != value {
+				ok = false
+			}
+		}
+		if ok {
+			matched = append(matched, i)
+		}
+	}
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	if offset+count < len(matched) {
+		matched = matched[:offset+count]
+	}
+	return matched[offset:], nil
+}
+
+// newTestNode starts a mock node whose TableFactory and CRUD precompiles
+// keep the tables in memory.
+func newTestNode(t *testing.T) *clienttest.Server {
+	var methods []*abi.Method
+	for _, def := range []string{TableFactoryABI, CrudABI} {
+		parsed, err := abi.JSON(strings.NewReader(def))
+		if err != nil {
+			t.Fatalf("parse ABI failed: %v", err)
+		}
+		for name := range parsed.Methods {
+			method := parsed.Methods[name]
+			methods = append(methods, &method)
+		}
+	}
+	decode := func(data []byte) (*abi.Method, []string, error) {
+		for _, method := range methods {
+			if len(data) >= 4 && string(method.Id()) == string(data[:4]) {
+				values, err := method.Inputs.UnpackValues(data[4:])
+				if err != nil {
+					return nil, nil, err
+				}
+				args := make([]string, len(values))
+				for i, v := range values {
+					args[i] = v.(string)
+				}
+				return method, args, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("no method with id: %x", data)
+	}
+	var (
+		mu     sync.Mutex
+		tables = make(map[string]*memTable)
+	)
+	// execute runs a CRUD operation and returns the number of affected rows.
+	execute := func(method string, args []string) (int64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if method == "createTable" {
+			tables[args[0]] = &memTable{keyField: args[1]}
+			return 0, nil
+		}
+		table, ok := tables[args[0]]
+		if !ok {
+			return 0, fmt.Errorf("table %s does not exist", args[0])
+		}
+		switch method {
+		case "insert":
+			row := map[string]string{table.keyField: args[1]}
+			if err := json.Unmarshal([]byte(args[2]), &row); err != nil {
+				return 0, err
+			}
+			table.rows = append(table.rows, row)
+			return 1, nil
+		case "update":
+			var entry map[string]string
+			if err := json.Unmarshal([]byte(args[2]), &entry); err != nil {
+				return 0, err
+			}
+			matched, err := table.match(args[1], args[3])
+			for _, i := range matched {
+				for field, value := range entry {
+					table.rows[i][field] = value
+				}
+			}
+			return int64(len(matched)), err
+		case "remove":
+			matched, err := table.match(args[1], args[2])
+			for n, i := range matched {
+				table.rows = append(table.rows[:i-n], table.rows[i-n+1:]...)
+			}
+			return int64(len(matched)), err
+		}
+		return 0, fmt.Errorf("unexpected transaction to %s", method)
+	}
+
+	srv := clienttest.NewServer()
+	srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+		method, args, err := decode(tx.Data())
+		if err != nil {
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		affected, err := execute(method.Name, args)
+		if err != nil {
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		output, _ := method.Outputs.Pack(big.NewInt(affected))
+		return &clienttest.Execution{Output: output}
+	})
+	srv.HandleCall(func(from, to common.Address, data []byte) ([]byte, error) {
+		method, args, err := decode(data)
+		if err != nil {
+			return nil, err
+		}
+		if method.Name != "select" {
+			return nil, fmt.Errorf("unexpected call to %s", method.Name)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		results := []map[string]string{}
+		if table, ok := tables[args[0]]; ok {
+			matched, err := table.match(args[1], args[2])
+			if err != nil {
+				return nil, err
+			}
+			for _, i := range matched {
+				results = append(results, table.rows[i])
+			}
+		}
+		result, err := json.Marshal(results)
+		if err != nil {
+			return nil, err
+		}
+		return method.Outputs.Pack(string(result))
+	})
+	return srv
+}
+
+func GetClient(t *testing.T, srv *clienttest.Server) *client.Client {
 	groupID := uint(1)
-	rpc, err := client.Dial("http://localhost:8545", groupID)
+	rpc, err := client.Dial(srv.URL, groupID)
 	if err != nil {
 		t.Fatalf("init rpc client failed: %+v", err)
 	}
@@ -21,16 +188,15 @@ func GetClient(t *testing.T) *client.Client {
 
 func GenerateKey(t *testing.T) *ecdsa.PrivateKey {
 	privateKey, err := crypto.HexToECDSA("145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b58")
-    if err != nil {
-        t.Fatalf("init privateKey failed: %+v", err)
+	if err != nil {
+		t.Fatalf("init privateKey failed: %+v", err)
 	}
 	return privateKey
 }
 
-func GetService(t *testing.T) *CRUDService {
-	rpc := GetClient(t)
+func GetService(t *testing.T, rpc *client.Client) *CRUDService {
 	privateKey := GenerateKey(t)
-	service, err := NewCRUDService(rpc, privateKey)
+	service, err := NewCRUDService(rpc, bind.NewKeyedTransactor(privateKey))
 	if err != nil {
 		t.Fatalf("init CRUDService failed: %+v", err)
 	}
@@ -39,11 +205,13 @@ func GetService(t *testing.T) *CRUDService {
 
 func TestCRUD(t *testing.T) {
 	tableName := "t_test" + strconv.Itoa(rand.Intn(100000))
-  	key := "name"
-  	valueFields  := "item_id, item_name"
-	table :=  &Table{TableName:tableName, Key:key, ValueFields:valueFields}
+	key := "name"
+	valueFields := "item_id, item_name"
+	table := &Table{TableName: tableName, Key: key, ValueFields: valueFields}
 
-	service := GetService(t)
+	srv := newTestNode(t)
+	defer srv.Close()
+	service := GetService(t, GetClient(t, srv))
 
 	// create table
 	resultCreate, err := service.CreateTable(table)
@@ -54,10 +222,10 @@ func TestCRUD(t *testing.T) {
 
 	// insert records
 	var insertResults int
-	for i:=1; i <= 5; i++ {
+	for i := 1; i <= 5; i++ {
 		insertEnrty := table.GetEntry()
 		insertEnrty.Put("item_id", "1")
-		insertEnrty.Put("item_name", "apple" + strconv.Itoa(i))
+		insertEnrty.Put("item_name", "apple"+strconv.Itoa(i))
 		table.SetKey("fruit")
 		insertResult, err := service.Insert(table, insertEnrty)
 		if err != nil {
@@ -66,12 +234,15 @@ func TestCRUD(t *testing.T) {
 		insertResults += insertResult
 	}
 	t.Logf("insertResults: %d\n", insertResults)
+	if insertResults != 5 {
+		t.Fatalf("insertResults mismatch: have %d, want 5", insertResults)
+	}
 
 	// select records
 	condition1 := table.GetCondition()
 	condition1.EQ("item_id", "1")
 	condition1.Limit(1)
-		
+
 	resultSelect1, err := service.Select(table, condition1)
 	if err != nil {
 		t.Fatalf("select table faied: %v", err)
@@ -97,7 +268,7 @@ func TestCRUD(t *testing.T) {
 	condition2 := table.GetCondition()
 	condition2.EQ("item_id", "1")
 	condition2.Limit(1)
-		
+
 	resultSelect2, err := service.Select(table, condition2)
 	if err != nil {
 		t.Fatalf("select table faied: %v", err)
@@ -106,13 +277,16 @@ func TestCRUD(t *testing.T) {
 	t.Logf("%s\n", resultSelect2[0]["name"])
 	t.Logf("%s\n", resultSelect2[0]["item_id"])
 	t.Logf("%s\n", resultSelect2[0]["item_name"])
+	if resultSelect2[0]["item_name"] != "orange" {
+		t.Fatalf("item_name mismatch: have %s, want orange", resultSelect2[0]["item_name"])
+	}
 
 	// remove records
 	removeCondition := table.GetCondition()
 	removeCondition.EQ("item_id", "1")
-	removeResult,err := service.Remove(table, removeCondition)
+	removeResult, err := service.Remove(table, removeCondition)
 	if err != nil {
 		t.Fatalf("remove table faied: %v", err)
 	}
 	t.Logf("removeResult: %d\n", removeResult)
-}
\ No newline at end of file
+}
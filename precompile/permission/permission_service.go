@@ -2,8 +2,6 @@ package permission
 
 import (
 	"fmt"
-	"crypto/ecdsa"
-	"math/big"
 	"context"
 	"encoding/json"
 
@@ -11,6 +9,7 @@ import (
 	"github.com/KasperLiu/gobcos/common"
 	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/core/types"
+	"github.com/KasperLiu/gobcos/log"
 	"github.com/KasperLiu/gobcos/precompile/crud"
 )
 
@@ -26,26 +25,30 @@ type PermissionService struct {
 	permission *Permission
 	permissionAuth *bind.TransactOpts
 	client *client.Client
-	privateKey *ecdsa.PrivateKey
 }
 
 // PermissionPrecompileAddress is the contract address of Permission
 var PermissionPrecompileAddress common.Address = common.HexToAddress("0x0000000000000000000000000000000000001005")
 
-// NewPermissionService returns ptr of PermissionService
-func NewPermissionService(client *client.Client, privateKey *ecdsa.PrivateKey) (*PermissionService, error) {
+var logger = log.New("precompile", "permission")
+
+// NewPermissionService returns ptr of PermissionService, whose transactions are signed by auth,
+// e.g. created by bind.NewKeyedTransactor or bind.NewKeyStoreTransactor
+func NewPermissionService(client *client.Client, auth *bind.TransactOpts) (*PermissionService, error) {
 	instance, err := NewPermission(PermissionPrecompileAddress, client)
 	if err != nil {
 		return nil, fmt.Errorf("construct PermissionService failed: %+v", err)
 	}
-	auth := bind.NewKeyedTransactor(privateKey)
-	auth.GasLimit = big.NewInt(30000000)
-    return &PermissionService{permission:instance, permissionAuth:auth, client: client, privateKey:privateKey}, nil
+	opts, err := bind.WithDefaultGasLimit(auth, bind.PrecompileGasLimit)
+	if err != nil {
+		return nil, fmt.Errorf("construct PermissionService failed: %+v", err)
+	}
+    return &PermissionService{permission:instance, permissionAuth:opts, client: client}, nil
 }
 
 // GrantUserTableManager grants the info by the table name and user address
 func (service *PermissionService) GrantUserTableManager(tableName string, grantress string) (string, error) {
-	crudService,err := crud.NewCRUDService(service.client, service.privateKey)
+	crudService,err := crud.NewCRUDService(service.client, service.permissionAuth)
 	if err != nil {
 		return "", fmt.Errorf("PermissionService create CRUDService failed: %v", err)
 	}
@@ -147,6 +150,7 @@ func (service *PermissionService) grant(tableName string, grantress string) (str
 	if err != nil {
 		return "", fmt.Errorf("PermissionService grant failed: %v", err)
 	}
+	logger.Info("Granting the permission", "table", tableName, "account", grantress, "hash", tx.Hash())
 	// wait for the mining
 	receipt, err := bind.WaitMined(context.Background(), service.client, tx)
 	if err != nil {
@@ -160,6 +164,7 @@ func (service *PermissionService) revoke(tableName string, address string) (stri
 	if err != nil {
 		return "", fmt.Errorf("PermissionService revoke failed: %v", err)
 	}
+	logger.Info("Revoking the permission", "table", tableName, "account", address, "hash", tx.Hash())
 	// wait for the mining
 	receipt, err := bind.WaitMined(context.Background(), service.client, tx)
 	if err != nil {
@@ -1,22 +1,114 @@
 package permission
 
 import (
-	"testing"
 	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
 
+	"github.com/KasperLiu/gobcos/accounts/abi"
+	"github.com/KasperLiu/gobcos/accounts/abi/bind"
 	"github.com/KasperLiu/gobcos/client"
+	"github.com/KasperLiu/gobcos/client/clienttest"
+	"github.com/KasperLiu/gobcos/common"
+	"github.com/KasperLiu/gobcos/core/types"
 	"github.com/KasperLiu/gobcos/crypto"
+	"github.com/KasperLiu/gobcos/precompile/crud"
 )
 
 const (
-	success = "{\"code\":0,\"msg\":\"success\"}"
-	tableName = "t_test"
+	success        = "{\"code\":0,\"msg\":\"success\"}"
+	tableName      = "t_test"
 	permisstionAdd = "0xFbb18d54e9Ee57529cda8c7c52242EFE879f064F"
 )
 
-func GetClient(t *testing.T) *client.Client {
+// newTestNode starts a mock node whose Permission precompile keeps the
+// granted addresses in memory. The CRUD precompile describes every user table.
+func newTestNode(t *testing.T) *clienttest.Server {
+	permissionABI, err := abi.JSON(strings.NewReader(PermissionABI))
+	if err != nil {
+		t.Fatalf("parse PermissionABI failed: %v", err)
+	}
+	crudABI, err := abi.JSON(strings.NewReader(crud.CrudABI))
+	if err != nil {
+		t.Fatalf("parse CrudABI failed: %v", err)
+	}
+	var (
+		mu     sync.Mutex
+		grants []PermissionInfo
+	)
+	srv := clienttest.NewServer()
+	srv.HandleTransaction(func(tx *types.RawTransaction, from common.Address) *clienttest.Execution {
+		method, err := permissionABI.MethodById(tx.Data())
+		if err != nil {
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		args, err := method.Inputs.UnpackValues(tx.Data()[4:])
+		if err != nil {
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		table, address := args[0].(string), args[1].(string)
+		mu.Lock()
+		defer mu.Unlock()
+		var kept []PermissionInfo
+		for _, info := range grants {
+			if info.TableName != table || !strings.EqualFold(info.Address, address) {
+				kept = append(kept, info)
+			}
+		}
+		switch method.Name {
+		case "insert":
+			kept = append(kept, PermissionInfo{Address: address, EnableNum: "1", TableName: table})
+		case "remove":
+		default:
+			return &clienttest.Execution{Status: "0x1a"}
+		}
+		grants = kept
+		output, _ := method.Outputs.Pack(big.NewInt(1))
+		return &clienttest.Execution{Output: output}
+	})
+	srv.HandleCall(func(from, to common.Address, data []byte) ([]byte, error) {
+		if to == crud.CRUDPrecompileAddress {
+			method, err := crudABI.MethodById(data)
+			if err != nil {
+				return nil, err
+			}
+			return method.Outputs.Pack(`[{"key_field":"name","value_field":"item_id,item_name"}]`)
+		}
+		method, err := permissionABI.MethodById(data)
+		if err != nil {
+			return nil, err
+		}
+		if method.Name != "queryByName" {
+			return nil, fmt.Errorf("unexpected call to %s", method.Name)
+		}
+		args, err := method.Inputs.UnpackValues(data[4:])
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		found := []PermissionInfo{}
+		for _, info := range grants {
+			if info.TableName == args[0].(string) {
+				found = append(found, info)
+			}
+		}
+		mu.Unlock()
+		result, err := json.Marshal(found)
+		if err != nil {
+			return nil, err
+		}
+		return method.Outputs.Pack(string(result))
+	})
+	return srv
+}
+
+func GetClient(t *testing.T, srv *clienttest.Server) *client.Client {
 	groupID := uint(1)
-	rpc, err := client.Dial("http://localhost:8545", groupID)
+	rpc, err := client.Dial(srv.URL, groupID)
 	if err != nil {
 		t.Fatalf("init rpc client failed: %+v", err)
 	}
@@ -25,16 +117,16 @@ func GetClient(t *testing.T) *client.Client {
 
 func GenerateKey(t *testing.T) *ecdsa.PrivateKey {
 	privateKey, err := crypto.HexToECDSA("145e247e170ba3afd6ae97e88f00dbc976c2345d511b0f6713355d19d8b80b58")
-    if err != nil {
-        t.Fatalf("init privateKey failed: %+v", err)
+	if err != nil {
+		t.Fatalf("init privateKey failed: %+v", err)
 	}
 	return privateKey
 }
 
-func GetService(t *testing.T) *PermissionService {
-	rpc := GetClient(t)
+func GetService(t *testing.T, srv *clienttest.Server) *PermissionService {
+	rpc := GetClient(t, srv)
 	privateKey := GenerateKey(t)
-	service, err := NewPermissionService(rpc, privateKey)
+	service, err := NewPermissionService(rpc, bind.NewKeyedTransactor(privateKey))
 	if err != nil {
 		t.Fatalf("init PermissionService failed: %+v", err)
 	}
@@ -42,7 +134,9 @@ func GetService(t *testing.T) *PermissionService {
 }
 
 func TestGrant(t *testing.T) {
-	service := GetService(t)
+	srv := newTestNode(t)
+	defer srv.Close()
+	service := GetService(t, srv)
 	// grant permission
 	result, err := service.GrantPermissionManager(permisstionAdd)
 	if err != nil {
@@ -55,6 +149,9 @@ func TestGrant(t *testing.T) {
 		t.Fatalf("ListPermissionManager failed: %v", err)
 	}
 	t.Logf("ListPermissionManager: %+v", listResult)
+	if len(listResult) != 1 || listResult[0].GetAddress() != permisstionAdd {
+		t.Fatalf("ListPermissionManager mismatch: %+v", listResult)
+	}
 
 	result, err = service.RevokePermissionManager(permisstionAdd)
 	if err != nil {
@@ -67,95 +164,109 @@ func TestGrant(t *testing.T) {
 		t.Fatalf("ListPermissionManager failed: %v", err)
 	}
 	t.Logf("ListPermissionManager: %v", listResult)
+	if len(listResult) != 0 {
+		t.Fatalf("ListPermissionManager mismatch: %+v", listResult)
+	}
 }
 
+func TestUserTableManager(t *testing.T) {
+	srv := newTestNode(t)
+	defer srv.Close()
+	service := GetService(t, srv)
 
-// func TestUserTableManager(t *testing.T) {
-// 	service := GetService(t)
-
-// 	result, err := service.GrantUserTableManager(tableName, permisstionAdd)
-// 	if err != nil {
-// 		t.Fatalf("TestUserTableManager failed: %v", err)
-// 	}
-// 	t.Logf("TestUserTableManager: %v", result)
-// 	revokeResult, err := service.RevokeUserTableManager(tableName, permisstionAdd)
-// 	if err != nil {
-// 		t.Fatalf("TestUserTableManager failed: %v", err)
-// 	}
-// 	t.Logf("TestUserTableManager revoke result: %v", revokeResult)
-// }
-
-// func TestDeployAndCreateManager(t *testing.T) {
-// 	service := GetService(t)
-
-// 	result, err := service.GrantDeployAndCreateManager(permisstionAdd)
-// 	if err != nil {
-// 		t.Fatalf("TestDeployAndCreateManager failed: %v", err)
-// 	}
-// 	t.Logf("TestDeployAndCreateManager: %v", result)
-
-// 	revokeResult, err := service.RevokeDeployAndCreateManager(permisstionAdd)
-// 	if err != nil {
-// 		t.Fatalf("TestDeployAndCreateManager failed: %v", err)
-// 	}
-// 	t.Logf("TestDeployAndCreateManager revoke result: %v", revokeResult)
-// }
-
-// func TestNodeManager(t *testing.T) {
-// 	service := GetService(t)
-
-// 	result, err := service.GrantNodeManager(permisstionAdd)
-// 	if err != nil {
-// 		t.Fatalf("TestNodeManager failed: %v", err)
-// 	}
-// 	t.Logf("TestNodeManager: %v", result)
-
-// 	revokeResult, err := service.RevokeNodeManager(permisstionAdd)
-// 	if err != nil {
-// 		t.Fatalf("TestNodeManager failed: %v", err)
-// 	}
-// 	t.Logf("TestNodeManager revoke result: %v", revokeResult)
-// }
-
-// func TestCNSManager(t *testing.T) {
-// 	service := GetService(t)
-
-// 	result, err := service.GrantCNSManager(permisstionAdd)
-// 	if err != nil {
-// 		t.Fatalf("TestCNSManager failed: %v", err)
-// 	}
-// 	t.Logf("TestCNSManager: %v", result)
-
-// 	revokeResult, err := service.RevokeCNSManager(permisstionAdd)
-// 	if err != nil {
-// 		t.Fatalf("TestCNSManager failed: %v", err)
-// 	}
-// 	t.Logf("TestCNSManager revoke result: %v", revokeResult)
-// }
-
-// func TestSysConfigManager(t *testing.T) {
-// 	service := GetService(t)
-
-// 	result, err := service.GrantSysConfigManager(permisstionAdd)
-// 	if err != nil {
-// 		t.Fatalf("TestSysConfigManager failed: %v", err)
-// 	}
-// 	t.Logf("TestSysConfigManager: %v", result)
-
-// 	revokeResult, err := service.RevokeSysConfigManager(permisstionAdd)
-// 	if err != nil {
-// 		t.Fatalf("TestSysConfigManager failed: %v", err)
-// 	}
-// 	t.Logf("TestSysConfigManager revoke result: %v", revokeResult)
-// 	t.Logf("Success result: %s", success)
-// }
-
-// func TestListUser(t *testing.T) {
-// 	service := GetService(t)
-
-// 	result, err := service.ListUserTableManager(tableName)
-// 	if err != nil {
-// 		t.Fatalf("ListUserTableManager failed: %v", err)
-// 	}
-// 	t.Logf("ListUserTableManager: %v", result)
-// }
\ No newline at end of file
+	result, err := service.GrantUserTableManager(tableName, permisstionAdd)
+	if err != nil {
+		t.Fatalf("TestUserTableManager failed: %v", err)
+	}
+	t.Logf("TestUserTableManager: %v", result)
+	revokeResult, err := service.RevokeUserTableManager(tableName, permisstionAdd)
+	if err != nil {
+		t.Fatalf("TestUserTableManager failed: %v", err)
+	}
+	t.Logf("TestUserTableManager revoke result: %v", revokeResult)
+}
+
+func TestDeployAndCreateManager(t *testing.T) {
+	srv := newTestNode(t)
+	defer srv.Close()
+	service := GetService(t, srv)
+
+	result, err := service.GrantDeployAndCreateManager(permisstionAdd)
+	if err != nil {
+		t.Fatalf("TestDeployAndCreateManager failed: %v", err)
+	}
+	t.Logf("TestDeployAndCreateManager: %v", result)
+
+	revokeResult, err := service.RevokeDeployAndCreateManager(permisstionAdd)
+	if err != nil {
+		t.Fatalf("TestDeployAndCreateManager failed: %v", err)
+	}
+	t.Logf("TestDeployAndCreateManager revoke result: %v", revokeResult)
+}
+
+func TestNodeManager(t *testing.T) {
+	srv := newTestNode(t)
+	defer srv.Close()
+	service := GetService(t, srv)
+
+	result, err := service.GrantNodeManager(permisstionAdd)
+	if err != nil {
+		t.Fatalf("TestNodeManager failed: %v", err)
+	}
+	t.Logf("TestNodeManager: %v", result)
+
+	revokeResult, err := service.RevokeNodeManager(permisstionAdd)
+	if err != nil {
+		t.Fatalf("TestNodeManager failed: %v", err)
+	}
+	t.Logf("TestNodeManager revoke result: %v", revokeResult)
+}
+
+func TestCNSManager(t *testing.T) {
+	srv := newTestNode(t)
+	defer srv.Close()
+	service := GetService(t, srv)
+
+	result, err := service.GrantCNSManager(permisstionAdd)
+	if err != nil {
+		t.Fatalf("TestCNSManager failed: %v", err)
+	}
+	t.Logf("TestCNSManager: %v", result)
+
+	revokeResult, err := service.RevokeCNSManager(permisstionAdd)
+	if err != nil {
+		t.Fatalf("TestCNSManager failed: %v", err)
+	}
+	t.Logf("TestCNSManager revoke result: %v", revokeResult)
+}
+
+func TestSysConfigManager(t *testing.T) {
+	srv := newTestNode(t)
+	defer srv.Close()
+	service := GetService(t, srv)
+
+	result, err := service.GrantSysConfigManager(permisstionAdd)
+	if err != nil {
+		t.Fatalf("TestSysConfigManager failed: %v", err)
+	}
+	t.Logf("TestSysConfigManager: %v", result)
+
+	revokeResult, err := service.RevokeSysConfigManager(permisstionAdd)
+	if err != nil {
+		t.Fatalf("TestSysConfigManager failed: %v", err)
+	}
+	t.Logf("TestSysConfigManager revoke result: %v", revokeResult)
+	t.Logf("Success result: %s", success)
+}
+
+func TestListUser(t *testing.T) {
+	srv := newTestNode(t)
+	defer srv.Close()
+	service := GetService(t, srv)
+
+	result, err := service.ListUserTableManager(tableName)
+	if err != nil {
+		t.Fatalf("ListUserTableManager failed: %v", err)
+	}
+	t.Logf("ListUserTableManager: %v", result)
+}
@@ -27,6 +27,8 @@ import (
 	"strconv"
 	"sync/atomic"
 	"time"
+
+	"github.com/KasperLiu/gobcos/log"
 )
 
 var (
@@ -97,6 +99,11 @@ type Client struct {
 	reqInit     chan *requestOp  // register response IDs, takes write lock
 	reqSent     chan error       // signals write completion, releases write lock
 	reqTimeout  chan *requestOp  // removes response IDs when call timeout expires
+
+	instrumentation Instrumentation // optional receiver of request measurements
+	tracer          Tracer          // optional tracer of the calls
+	retry           RetryPolicy     // retries of the HTTP calls failing transiently
+	breaker         breaker         // circuit breaker of the HTTP endpoint
 }
 
 type reconnectFunc func(ctx context.Context) (ServerCodec, error)
@@ -263,7 +270,10 @@ func (c *Client) Call(result interface{}, method string, args ...interface{}) er
 //
 // The result must be a pointer so that package json can unmarshal into it. You
 // can also pass nil, in which case the result is ignored.
-func (c *Client) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+func (c *Client) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) (err error) {
+	ctx, finish := c.startCall(ctx, method, method)
+	defer func() { finish(err) }()
+
 	msg, err := c.newMessage(method, args...)
 	if err != nil {
 		return err
@@ -271,7 +281,9 @@ func (c *Client) CallContext(ctx context.Context, result interface{}, method str
 	op := &requestOp{ids: []json.RawMessage{msg.ID}, resp: make(chan *jsonrpcMessage, 1)}
 
 	if c.isHTTP {
-		err = c.sendHTTP(ctx, op, msg)
+		err = c.sendWithRetry(ctx, []string{method}, func() error {
+			return c.sendHTTP(ctx, op, msg)
+		})
 	} else {
 		err = c.send(ctx, op, msg)
 	}
@@ -313,7 +325,20 @@ func (c *Client) BatchCall(b []BatchElem) error {
 // Error field of the corresponding BatchElem.
 //
 // Note that batch calls may not be executed atomically on the server side.
-func (c *Client) BatchCallContext(ctx context.Context, b []BatchElem) error {
+func (c *Client) BatchCallContext(ctx context.Context, b []BatchElem) (err error) {
+	methods := make([]string, len(b))
+	for i := range b {
+		methods[i] = b[i].Method
+	}
+	ctx, finish := c.startCall(ctx, BatchMethod, methods...)
+	defer func() {
+		errs := make([]error, len(b))
+		for i := range b {
+			errs[i] = b[i].Error
+		}
+		finish(err, errs...)
+	}()
+
 	msgs := make([]*jsonrpcMessage, len(b))
 	op := &requestOp{
 		ids:  make([]json.RawMessage, len(b)),
@@ -328,9 +353,10 @@ func (c *Client) BatchCallContext(ctx context.Context, b []BatchElem) error {
 		op.ids[i] = msg.ID
 	}
 
-	var err error
 	if c.isHTTP {
-		err = c.sendBatchHTTP(ctx, op, msgs)
+		err = c.sendWithRetry(ctx, methods, func() error {
+			return c.sendBatchHTTP(ctx, op, msgs)
+		})
 	} else {
 		err = c.send(ctx, op, msgs)
 	}
@@ -490,7 +516,7 @@ func (c *Client) reconnect(ctx context.Context) error {
 	}
 	newconn, err := c.reconnectFunc(ctx)
 	if err != nil {
-		// log.Trace("RPC client reconnect failed", "err", err)
+		log.Trace("RPC client reconnect failed", "err", err)
 		return err
 	}
 	select {
@@ -539,13 +565,13 @@ func (c *Client) dispatch(codec ServerCodec) {
 			}
 
 		case err := <-c.readErr:
-			// conn.handler.log.Debug("RPC connection read error", "err", err)
+			conn.handler.log.Debug("RPC connection read error", "err", err)
 			conn.close(err, lastOp)
 			reading = false
 
 		// Reconnect:
 		case newcodec := <-c.reconnected:
-			// log.Debug("RPC client reconnected", "reading", reading, "conn", newcodec.RemoteAddr())
+			log.Debug("RPC client reconnected", "reading", reading, "conn", newcodec.RemoteAddr())
 			if reading {
 				// Wait for the previous read loop to exit. This is a rare case which
 				// happens if this loop isn't notified in time after the connection breaks.
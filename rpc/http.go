@@ -20,13 +20,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/KasperLiu/gobcos/log"
 )
 
 const (
@@ -119,33 +120,32 @@ func DialHTTP(endpoint string) (*Client, error) {
 	return DialHTTPWithClient(endpoint, new(http.Client))
 }
 
-func (c *Client) sendHTTP(ctx context.Context, op *requestOp, msg interface{}) error {
+func (c *Client) sendHTTP(ctx context.Context, op *requestOp, msg *jsonrpcMessage) error {
 	hc := c.writeConn.(*httpConn)
-	respBody, err := hc.doRequest(ctx, msg)
-	if respBody != nil {
-		defer respBody.Close()
-	}
-
+	logRequests(hc, msg)
+	start := time.Now()
+	respBody, size, err := hc.doRequest(ctx, msg)
+	respBody = c.observeRequest(start, size, respBody, err)
 	if err != nil {
-		if respBody != nil {
-			buf := new(bytes.Buffer)
-			if _, err2 := buf.ReadFrom(respBody); err2 == nil {
-				return fmt.Errorf("%v %v", err, buf.String())
-			}
-		}
 		return err
 	}
+	defer respBody.Close()
+
 	var respmsg jsonrpcMessage
 	if err := json.NewDecoder(respBody).Decode(&respmsg); err != nil {
 		return err
 	}
+	logResponse(hc, &respmsg, start)
 	op.resp <- &respmsg
 	return nil
 }
 
 func (c *Client) sendBatchHTTP(ctx context.Context, op *requestOp, msgs []*jsonrpcMessage) error {
 	hc := c.writeConn.(*httpConn)
-	respBody, err := hc.doRequest(ctx, msgs)
+	logRequests(hc, msgs...)
+	start := time.Now()
+	respBody, size, err := hc.doRequest(ctx, msgs)
+	respBody = c.observeRequest(start, size, respBody, err)
 	if err != nil {
 		return err
 	}
@@ -155,15 +155,45 @@ func (c *Client) sendBatchHTTP(ctx context.Context, op *requestOp, msgs []*jsonr
 		return err
 	}
 	for i := 0; i < len(respmsgs); i++ {
+		logResponse(hc, &respmsgs[i], start)
 		op.resp <- &respmsgs[i]
 	}
 	return nil
 }
 
-func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadCloser, error) {
+// RedactedMethods are the methods whose parameters are not logged, as they carry
+// signed transactions.
+var RedactedMethods = map[string]bool{"sendRawTransaction": true}
+
+// logRequests logs the messages sent to the endpoint at the trace level.
+func logRequests(hc *httpConn, msgs ...*jsonrpcMessage) {
+	for _, msg := range msgs {
+		params := msg.Params
+		var value interface{} = log.Lazy{Fn: func() interface{} { return string(params) }}
+		if RedactedMethods[msg.Method] {
+			value = fmt.Sprintf("<redacted %d bytes>", len(params))
+		}
+		log.Trace("Sending RPC request", "url", hc.RemoteAddr(), "reqid", idForLog{msg.ID}, "method", msg.Method, "params", value)
+	}
+}
+
+// logResponse logs a response of the endpoint at the trace level.
+func logResponse(hc *httpConn, msg *jsonrpcMessage, start time.Time) {
+	if msg.Error != nil {
+		log.Trace("Received RPC error", "url", hc.RemoteAddr(), "reqid", idForLog{msg.ID}, "t", time.Since(start), "err", msg.Error.Message)
+		return
+	}
+	result := msg.Result
+	log.Trace("Received RPC response", "url", hc.RemoteAddr(), "reqid", idForLog{msg.ID}, "t", time.Since(start),
+		"result", log.Lazy{Fn: func() interface{} { return string(result) }})
+}
+
+// doRequest posts msg and returns the response body along with the size of the
+// request body. A non 2xx response is returned as a *HTTPError holding its body.
+func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadCloser, int, error) {
 	body, err := json.Marshal(msg)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	req := hc.req.WithContext(ctx)
 	req.Body = ioutil.NopCloser(bytes.NewReader(body))
@@ -171,12 +201,14 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 
 	resp, err := hc.client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, len(body), err
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return resp.Body, errors.New(resp.Status)
+		defer resp.Body.Close()
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxRequestContentLength))
+		return nil, len(body), &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: respBody}
 	}
-	return resp.Body, nil
+	return resp.Body, len(body), nil
 }
 
 // httpServerConn turns a HTTP connection into a Conn.
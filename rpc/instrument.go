@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// BatchMethod is the method name a batch request is traced with.
+const BatchMethod = "batch"
+
+// Instrumentation receives measurements of the requests made by a Client, e.g. to
+// export them as metrics. Its methods are called concurrently by the goroutines
+// making calls and must not block.
+type Instrumentation interface {
+	// CallStarted is called before a call of method is sent.
+	CallStarted(method string)
+	// CallFinished is called when a call of method has finished. The error is
+	// either a JSON-RPC error implementing Error or an I/O error. Every element
+	// of a batch is reported as a call taking as long as the whole batch.
+	CallFinished(method string, duration time.Duration, err error)
+	// CallRetried is called when a call of method is sent again after a failure.
+	CallRetried(method string)
+	// RequestFinished is called after each HTTP request with the sizes of the
+	// request and response bodies.
+	RequestFinished(duration time.Duration, requestSize, responseSize int, err error)
+}
+
+// Tracer creates spans for the calls of a Client for distributed tracing.
+type Tracer interface {
+	// StartSpan is called before a call of method, or of BatchMethod for a batch,
+	// is sent. The returned context is the one the call is made with and the one
+	// passed to FinishSpan, so it can carry the span. Over HTTP, it is also the
+	// context of the http.Request, which allows a custom http.RoundTripper to
+	// propagate the span in the request headers.
+	StartSpan(ctx context.Context, method string) context.Context
+	// FinishSpan is called when the call has finished.
+	FinishSpan(ctx context.Context, err error)
+}
+
+// SetInstrumentation sets the receiver of the measurements of the requests made
+// by the client. It must be called before the client is used.
+func (c *Client) SetInstrumentation(in Instrumentation) {
+	c.instrumentation = in
+}
+
+// Instrumentation returns the receiver of the measurements of the client, or nil.
+func (c *Client) Instrumentation() Instrumentation {
+	return c.instrumentation
+}
+
+// SetTracer sets the tracer of the calls made by the client. It must be called
+// before the client is used.
+func (c *Client) SetTracer(tracer Tracer) {
+	c.tracer = tracer
+}
+
+// startCall reports the start of a call of the methods and returns the context to
+// make it with along with the function to report its end.
+func (c *Client) startCall(ctx context.Context, span string, methods ...string) (context.Context, func(err error, errs ...error)) {
+	if c.instrumentation == nil && c.tracer == nil {
+		return ctx, func(error, ...error) {}
+	}
+	if c.tracer != nil {
+		ctx = c.tracer.StartSpan(ctx, span)
+	}
+	if c.instrumentation != nil {
+		for _, method := range methods {
+			c.instrumentation.CallStarted(method)
+		}
+	}
+	start := time.Now()
+	return ctx, func(err error, errs ...error) {
+		if c.instrumentation != nil {
+			duration := time.Since(start)
+			for i, method := range methods {
+				callErr := err
+				if callErr == nil && i < len(errs) {
+					callErr = errs[i]
+				}
+				c.instrumentation.CallFinished(method, duration, callErr)
+			}
+		}
+		if c.tracer != nil {
+			c.tracer.FinishSpan(ctx, err)
+		}
+	}
+}
+
+// observeRequest reports a HTTP request to the instrumentation once its response
+// body has been read and closed.
+func (c *Client) observeRequest(start time.Time, requestSize int, body io.ReadCloser, err error) io.ReadCloser {
+	if c.instrumentation == nil {
+		return body
+	}
+	if body == nil || err != nil {
+		c.instrumentation.RequestFinished(time.Since(start), requestSize, 0, err)
+		return body
+	}
+	return &observedBody{ReadCloser: body, report: func(n int) {
+		c.instrumentation.RequestFinished(time.Since(start), requestSize, n, nil)
+	}}
+}
+
+// observedBody counts the bytes read from a response body and reports them when
+// it is closed.
+type observedBody struct {
+	io.ReadCloser
+	n      int
+	once   sync.Once
+	report func(n int)
+}
+
+func (b *observedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += n
+	return n, err
+}
+
+func (b *observedBody) Close() error {
+	b.once.Do(func() { b.report(b.n) })
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,246 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/KasperLiu/gobcos/log"
+)
+
+// ErrCircuitOpen is returned without sending the request when the circuit breaker
+// of the endpoint is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open, the endpoint is failing")
+
+// HTTPError is returned when the endpoint responds with a non 2xx HTTP status.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (err *HTTPError) Error() string {
+	if len(err.Body) == 0 {
+		return err.Status
+	}
+	return err.Status + " " + string(err.Body)
+}
+
+// RetryPolicy configures how the calls failing with a transient error are sent
+// again. Transient errors are connection failures and the 502, 503 and 504 HTTP
+// statuses, the calls are never retried after a JSON-RPC error.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a call is sent at most, including the
+	// first one. Calls are not retried if it is below 2.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, 0 for no cap.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows by after each retry, 2 if below 1.
+	Multiplier float64
+	// Jitter is the fraction of each delay, between 0 and 1, which is randomly
+	// taken off it so that clients failing together do not retry together.
+	Jitter float64
+	// Retryable reports whether a method is idempotent and may be sent again. A
+	// batch is only retried when all of its methods are. No call is retried if
+	// it is nil.
+	Retryable func(method string) bool
+}
+
+// backoff returns the delay before the given retry, starting at 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		delay *= multiplier
+		if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * p.Jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// retryable reports whether the calls of the methods may be sent again.
+func (p *RetryPolicy) retryable(methods []string) bool {
+	if p.MaxAttempts < 2 || p.Retryable == nil {
+		return false
+	}
+	for _, method := range methods {
+		if !p.Retryable(method) {
+			return false
+		}
+	}
+	return true
+}
+
+// BreakerPolicy configures the circuit breaker of the endpoint of a client. After
+// FailureThreshold consecutive requests failed with a transient error or ran into
+// the deadline of their context, the breaker opens and the calls fail immediately with ErrCircuitOpen. Once
+// OpenTimeout has elapsed, a single request is let through to probe the endpoint:
+// the breaker closes if it succeeds and opens again if it fails.
+type BreakerPolicy struct {
+	// FailureThreshold is the number of consecutive failures opening the
+	// breaker, 0 disables it.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before probing the endpoint.
+	OpenTimeout time.Duration
+}
+
+// breaker is the state of a circuit breaker.
+type breaker struct {
+	mu        sync.Mutex
+	policy    BreakerPolicy
+	failures  int       // consecutive transient failures
+	openUntil time.Time // end of the open state
+	probing   bool      // whether the probe of a half-open breaker is in flight
+}
+
+// allow reports whether a request may be sent, and whether it is the probe of a
+// half-open breaker.
+func (b *breaker) allow() (ok, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.policy.FailureThreshold < 1 || b.failures < b.policy.FailureThreshold {
+		return true, false
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// done records the outcome of a request let through by allow, and reports whether
+// it opened or closed the breaker.
+func (b *breaker) done(probe, failed bool) (opened, closed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+	if b.policy.FailureThreshold < 1 {
+		return false, false
+	}
+	wasOpen := b.failures >= b.policy.FailureThreshold
+	if !failed {
+		b.failures = 0
+		return false, wasOpen
+	}
+	b.failures++
+	if b.failures >= b.policy.FailureThreshold {
+		b.openUntil = time.Now().Add(b.policy.OpenTimeout)
+	}
+	return !wasOpen && b.failures >= b.policy.FailureThreshold, false
+}
+
+// cancel records a request let through by allow that was cancelled by the
+// caller, which leaves the state of the breaker unchanged.
+func (b *breaker) cancel(probe bool) {
+	if !probe {
+		return
+	}
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// SetRetryPolicy sets how the calls failing with a transient error are retried.
+// It must be called before the client is used.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// SetCircuitBreaker sets the circuit breaker policy of the endpoint of the client.
+// It must be called before the client is used.
+func (c *Client) SetCircuitBreaker(policy BreakerPolicy) {
+	c.breaker = breaker{policy: policy}
+}
+
+// sendWithRetry sends the calls of the methods with send, retrying it according to
+// the retry policy and guarded by the circuit breaker.
+func (c *Client) sendWithRetry(ctx context.Context, methods []string, send func() error) error {
+	retryable := c.retry.retryable(methods)
+	for attempt := 1; ; attempt++ {
+		ok, probe := c.breaker.allow()
+		if !ok {
+			return ErrCircuitOpen
+		}
+		err := send()
+		if err != nil && ctx.Err() == context.Canceled {
+			// the caller giving up tells nothing about the endpoint
+			c.breaker.cancel(probe)
+			return err
+		}
+		// a request running into the deadline of the caller timed out
+		failed := err != nil && (isTransient(err) || ctx.Err() == context.DeadlineExceeded && !isRPCError(err))
+		switch opened, closed := c.breaker.done(probe, failed); {
+		case opened:
+			log.Warn("RPC endpoint failing, circuit breaker opened", "url", c.writeConn.RemoteAddr(), "failures", c.breaker.policy.FailureThreshold, "err", err)
+		case closed:
+			log.Info("RPC endpoint recovered, circuit breaker closed", "url", c.writeConn.RemoteAddr())
+		}
+		if ctx.Err() != nil || !failed || !retryable || attempt >= c.retry.MaxAttempts {
+			return err
+		}
+		if c.instrumentation != nil {
+			for _, method := range methods {
+				c.instrumentation.CallRetried(method)
+			}
+		}
+		backoff := c.retry.backoff(attempt)
+		log.Debug("Retrying RPC call", "url", c.writeConn.RemoteAddr(), "methods", methods, "attempt", attempt+1, "backoff", backoff, "err", err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// isTransient reports whether a request failed with an error which may not occur
+// when it is sent again.
+func isTransient(err error) bool {
+	switch err := err.(type) {
+	case Error:
+		return false
+	case *HTTPError:
+		switch err.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	case *url.Error:
+		// the request failed before getting a response, e.g. because of a
+		// connection or TLS failure, only the former may be transient
+		return isTransient(err.Err)
+	case net.Error:
+		return true
+	}
+	return err == io.EOF || err == io.ErrUnexpectedEOF
+}
+
+// isRPCError reports whether err is a JSON-RPC error returned by the endpoint.
+func isRPCError(err error) bool {
+	_, ok := err.(Error)
+	return ok
+}
@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newHangingServer returns a client, guarded by a breaker opening after 2
+// failures, of an endpoint never responding until the returned function stops it.
+func newHangingServer(t *testing.T, openTimeout time.Duration) (*Client, func()) {
+	quit := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-quit:
+		}
+	}))
+	c, err := DialHTTP(srv.URL)
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	c.SetCircuitBreaker(BreakerPolicy{FailureThreshold: 2, OpenTimeout: openTimeout})
+	return c, func() {
+		close(quit)
+		c.Close()
+		srv.Close()
+	}
+}
+
+// callWithTimeout calls the endpoint until the deadline of its context.
+func callWithTimeout(c *Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var result string
+	return c.CallContext(ctx, &result, "getBlockNumber", 1)
+}
+
+// callCancelled calls the endpoint until the caller cancels the call.
+func callCancelled(c *Client, after time.Duration) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(after, cancel)
+	var result string
+	return c.CallContext(ctx, &result, "getBlockNumber", 1)
+}
+
+func TestBreakerOpensOnTimeouts(t *testing.T) {
+	c, stop := newHangingServer(t, time.Minute)
+	defer stop()
+
+	for i := 0; i < 2; i++ {
+		if err := callWithTimeout(c, 50*time.Millisecond); err == nil || err == ErrCircuitOpen {
+			t.Fatalf("call %d: got %v, want a timeout", i, err)
+		}
+	}
+	start := time.Now()
+	if err := callWithTimeout(c, time.Second); err != ErrCircuitOpen {
+		t.Fatalf("call after 2 timeouts: got %v, want %v", err, ErrCircuitOpen)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("open breaker took %v to fail the call", elapsed)
+	}
+}
+
+func TestBreakerIgnoresCancel(t *testing.T) {
+	c, stop := newHangingServer(t, 100*time.Millisecond)
+	defer stop()
+
+	// a cancelled call neither counts as a failure nor resets the failures
+	if err := callWithTimeout(c, 50*time.Millisecond); err == nil {
+		t.Fatal("call to the hanging endpoint succeeded")
+	}
+	for i := 0; i < 3; i++ {
+		if err := callCancelled(c, 20*time.Millisecond); err == nil || err == ErrCircuitOpen {
+			t.Fatalf("cancelled call %d: got %v", i, err)
+		}
+	}
+	if failures := c.breaker.failures; failures != 1 {
+		t.Fatalf("%d failures after cancelled calls, want 1", failures)
+	}
+	if err := callWithTimeout(c, 50*time.Millisecond); err == nil {
+		t.Fatal("call to the hanging endpoint succeeded")
+	}
+	if err := callWithTimeout(c, time.Second); err != ErrCircuitOpen {
+		t.Fatalf("call after 2 timeouts: got %v, want %v", err, ErrCircuitOpen)
+	}
+
+	// a cancelled probe of the half-open breaker leaves it open
+	time.Sleep(150 * time.Millisecond)
+	if err := callCancelled(c, 20*time.Millisecond); err == nil || err == ErrCircuitOpen {
+		t.Fatalf("cancelled probe: got %v", err)
+	}
+	if failures := c.breaker.failures; failures != 2 {
+		t.Fatalf("breaker closed by a cancelled probe: %d failures", failures)
+	}
+	// and lets the next call probe the endpoint again
+	if err := callWithTimeout(c, 50*time.Millisecond); err == nil || err == ErrCircuitOpen {
+		t.Fatalf("probe after a cancelled probe: got %v", err)
+	}
+	if err := callWithTimeout(c, time.Second); err != ErrCircuitOpen {
+		t.Fatalf("call after a failed probe: got %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{io.EOF, true},
+		{&HTTPError{StatusCode: http.StatusServiceUnavailable}, true},
+		{&HTTPError{StatusCode: http.StatusBadRequest}, false},
+		{&jsonError{Code: -32000, Message: "failed"}, false},
+		{&url.Error{Op: "Post", URL: "http://node", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}, true},
+		{&url.Error{Op: "Post", URL: "http://node", Err: io.ErrUnexpectedEOF}, true},
+		{&url.Error{Op: "Post", URL: "https://node", Err: x509.UnknownAuthorityError{}}, false},
+		{&url.Error{Op: "Post", URL: "ftp://node", Err: errors.New("unsupported protocol scheme \"ftp\"")}, false},
+		{errors.New("invalid request"), false},
+	}
+	for i, tt := range tests {
+		if transient := isTransient(tt.err); transient != tt.transient {
+			t.Errorf("test %d: isTransient(%v) = %v, want %v", i, tt.err, transient, tt.transient)
+		}
+	}
+}